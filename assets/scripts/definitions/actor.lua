@@ -9,6 +9,7 @@
 ---@field max_hp number
 ---@field hp number
 ---@field gold number
+---@field block number
 ---@field artifacts guid[]
 ---@field cards guid[]
 ---@field status_effects guid[]
\ No newline at end of file
@@ -25,9 +25,42 @@ GAME_STATE_MERCHANT = ""
 --- Represents the random game state in which the active story teller will decide what happens next.
 GAME_STATE_RANDOM = ""
 
+--- Represents the victory game state. Returning it from a story teller ends the run as won and unlocks the next difficulty.
+GAME_STATE_VICTORY = ""
+
+--- Represents the deck of the player. Cards are drawn from the top of the deck.
+PILE_DECK = ""
+
+--- Represents the exhausted pile of the player. Cards in it can't be drawn for the rest of the fight.
+PILE_EXHAUSTED = ""
+
+--- Represents the hand of the player.
+PILE_HAND = ""
+
+--- Represents the used (discard) pile of the player. It's shuffled back into the deck once the deck is empty.
+PILE_USED = ""
+
 --- Player actor id for use in functions where the guid is needed, for example: ``deal_damage(PLAYER_ID, enemy_guid, 10)``.
 PLAYER_ID = ""
 
+--- Rule for the block an actor keeps at the start of its turn. 0 means all block is lost and -1 means no block is lost.
+RULE_BLOCK_RETAIN = ""
+
+--- Rule for the amount of cards the player draws per round.
+RULE_DRAW_SIZE = ""
+
+--- Rule for the maximum amount of cards in the hand of the player. 0 means no limit.
+RULE_HAND_SIZE = ""
+
+--- Rule for the amount of points the player gets per round.
+RULE_POINTS_PER_ROUND = ""
+
+--- Rule for the cost of removing a card at the merchant.
+RULE_REMOVE_COST = ""
+
+--- Rule for the cost of upgrading a card at the merchant.
+RULE_UPGRADE_COST = ""
+
 -- #####################################
 -- Utility
 -- #####################################
@@ -100,6 +133,11 @@ function log_w(value) end
 ---@param ... any
 function print(...) end
 
+--- Returns a text report of the callbacks that took the most time since the session started. Only available if the game was started with ``EOE_PROFILE=1``. Example: ``print(profile_report(10))``
+---@param n? number
+---@return string
+function profile_report(n) end
+
 -- #####################################
 -- Audio
 -- #####################################
@@ -116,6 +154,10 @@ function play_music(sound) end
 -- Game State
 -- #####################################
 
+--- Gets the difficulty level of the run. 0 is the normal game, higher levels apply the modifiers of all registered difficulties up to that level.
+---@return number
+function get_difficulty() end
+
 --- Gets the ids of all the encountered events in the order of occurrence.
 ---@return string[]
 function get_event_history() end
@@ -128,6 +170,11 @@ function get_fight() end
 ---@return number
 function get_fight_round() end
 
+--- Gets the value of a rule after it was modified by the ``on_rule_calc`` callbacks. See the ``RULE_*`` globals.
+---@param rule string
+---@return number
+function get_rule(rule) end
+
 --- Gets the number of stages cleared.
 ---@return number
 function get_stages_cleared() end
@@ -159,6 +206,11 @@ function set_fight_description(desc) end
 ---@param state next_game_state
 function set_game_state(state) end
 
+--- Sets the base value of a rule for the rest of the run. See the ``RULE_*`` globals.
+---@param rule string
+---@param value number
+function set_rule(rule, value) end
+
 -- #####################################
 -- Actor Operations
 -- #####################################
@@ -350,12 +402,24 @@ function deal_damage(source, target, damage, flat) end
 ---@return number[]
 function deal_damage_multi(source, targets, damage, flat) end
 
+--- Gives block to the target. Block absorbs damage before it reaches the hp and decays at the start of the turn of the target, see ``RULE_BLOCK_RETAIN``. Returns the block that was given.
+---@param target guid
+---@param amount number
+---@return number
+function give_block(target, amount) end
+
 --- Heals the target triggered by the source.
 ---@param source guid
 ---@param target guid
 ---@param amount number
 function heal(source, target, amount) end
 
+--- Removes block from the target. Returns the block that was removed.
+---@param target guid
+---@param amount number
+---@return number
+function remove_block(target, amount) end
+
 --- Simulate damage from a source to a target. If flat is true the damage can't be modified by status effects or artifacts. Returns the damage that would be dealt.
 ---@param source guid
 ---@param target guid
@@ -385,14 +449,50 @@ function player_buy_artifact(card_id) end
 ---@return boolean
 function player_buy_card(card_id) end
 
+--- Moves a card from the hand or the deck to the used pile and triggers the ``on_discard`` callbacks. Returns true if the card was discarded.
+---@param card guid
+---@return boolean
+function player_discard_card(card) end
+
 --- Let the player draw additional cards for this turn.
 ---@param amount number
 function player_draw_card(amount) end
 
+--- Moves a card from any pile to the exhausted pile and triggers the ``on_exhaust`` callbacks. Returns true if the card was exhausted.
+---@param card guid
+---@return boolean
+function player_exhaust_card(card) end
+
 --- Gives the player more action points for this turn.
 ---@param points number
 function player_give_action_points(points) end
 
+--- Gives the player a card that is added to the pile (default ``PILE_HAND``) and removed at the end of the fight. Cards added to the deck are put at the bottom and cards for a full hand are added to ``PILE_USED`` instead. Returns the guid of the card.
+---@param type_id type_id
+---@param pile? string
+---@return guid
+function player_give_temporary_card(type_id, pile) end
+
+--- Moves a card from any pile on the top of the deck, or on the bottom if bottom is true. Returns true if the card was moved.
+---@param card guid
+---@param bottom? boolean
+---@return boolean
+function player_put_card_on_deck(card, bottom) end
+
+--- Returns the top cards of the deck without drawing them. Use ``player_discard_card`` and ``player_put_card_on_deck`` to discard or re-order them.
+---@param amount number
+---@return guid[]
+function player_scry(amount) end
+
+--- Moves up to amount (default 1) cards with the tag from the deck to the hand and triggers the ``on_draw`` callbacks. Cards stay in the deck if the hand is full. Returns the moved cards.
+---@param tag string
+---@param amount? number
+---@return guid[]
+function player_search_deck(tag, amount) end
+
+--- Shuffles the used pile into the deck and triggers the ``on_shuffle`` callbacks.
+function player_shuffle_used_into_deck() end
+
 -- #####################################
 -- Merchant Operations
 -- #####################################
@@ -455,6 +555,7 @@ function l(key, default) end
 --- delete_base_game("event") -- deletes all events
 --- delete_base_game("status_effect") -- deletes all status effects
 --- delete_base_game("story_teller") -- deletes all story tellers
+--- delete_base_game("difficulty") -- deletes all difficulties
 --- 
 --- ```
 ---@param type? string
@@ -468,6 +569,14 @@ function delete_base_game(type) end
 ---@param id type_id
 function delete_card(id) end
 
+--- Deletes a difficulty level.
+--- 
+--- ```lua
+--- delete_difficulty("SOME_DIFFICULTY")
+--- ```
+---@param id type_id
+function delete_difficulty(id) end
+
 --- Deletes an enemy.
 --- 
 --- ```lua
@@ -552,6 +661,25 @@ function register_artifact(id, definition) end
 ---@param definition card
 function register_card(id, definition) end
 
+--- Registers a new difficulty level. A run on a level applies the modifiers of all levels up to it.
+--- 
+--- ```lua
+--- register_difficulty("ASCENSION_2", {
+---     level = 2,
+---     name = "Ascension 2",
+---     description = "Enemies have 10% more HP and start with 1 strength.",
+---     enemy_hp = 0.1,
+---     gold = -0.05,
+---     merchant_price = 0.1,
+---     enemy_status_effects = {
+---         { id = "STRENGTH", stacks = 1 }
+---     }
+--- })
+--- ```
+---@param id type_id
+---@param definition difficulty
+function register_difficulty(id, definition) end
+
 --- Registers a new enemy.
 --- 
 --- ```lua
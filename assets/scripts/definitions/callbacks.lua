@@ -15,18 +15,29 @@
 ---@field heal? number
 ---@field stacks? number
 ---@field round? number
+---@field rule? string
+---@field value? number
+---@field block? number
+---@field amount? number
 
 ---@class callbacks
 ---@field on_actor_die? fun(ctx:ctx):nil
+---@field on_block_break? fun(ctx:ctx):nil
+---@field on_block_gain? fun(ctx:ctx):nil
 ---@field on_cast? fun(ctx:ctx):nil
 ---@field on_actor_did_cast? fun(ctx:ctx, card_ctx:ctx):nil
 ---@field on_damage? fun(ctx:ctx):nil
 ---@field on_damage_calc? fun(ctx:ctx):number|nil
+---@field on_discard? fun(ctx:ctx, card_ctx:ctx):nil Not triggered for cards that go to the used pile at the end of the turn
+---@field on_draw? fun(ctx:ctx, card_ctx:ctx):nil
+---@field on_exhaust? fun(ctx:ctx, card_ctx:ctx):nil
 ---@field on_heal_calc? fun(ctx:ctx):number|nil
 ---@field on_init? fun(ctx:ctx):nil
 ---@field on_pick_up? fun(ctx:ctx):nil
 ---@field on_player_turn? fun(ctx:ctx):nil
 ---@field on_remove? fun(ctx:ctx):nil
+---@field on_rule_calc? fun(ctx:ctx):number|nil
+---@field on_shuffle? fun(ctx:ctx):nil
 ---@field on_status_add? fun(ctx:ctx):nil
 ---@field on_status_remove? fun(ctx:ctx):nil
 ---@field on_status_stack? fun(ctx:ctx):nil
@@ -19,6 +19,10 @@
 ---@field does_exhaust? boolean
 ---@field does_consume? boolean
 ---@field need_target boolean
+---@field retain? boolean
+---@field innate? boolean
+---@field ethereal? boolean
+---@field unplayable? boolean
 ---@field price number
 ---@field callbacks callbacks
 ---@field test? fun():nil|string
@@ -29,4 +33,5 @@
 ---@field guid guid
 ---@field type_id type_id
 ---@field level number
----@field owner guid
\ No newline at end of file
+---@field owner guid
+---@field temporary boolean
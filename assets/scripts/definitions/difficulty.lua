@@ -0,0 +1,18 @@
+---@meta
+
+---@class difficulty_status_effect
+---@field id type_id
+---@field stacks? number
+
+--- Difficulty definition
+---@class difficulty
+---@field id? type_id
+---@field level number
+---@field name string
+---@field description? string
+---@field enemy_hp? number
+---@field gold? number
+---@field merchant_price? number
+---@field enemy_block? number
+---@field enemy_status_effects? difficulty_status_effect[]
+---@field base_game? boolean
@@ -12,6 +12,7 @@
 ---@field description string
 ---@field initial_hp number
 ---@field max_hp number
+---@field gold? number Not used yet
 ---@field look string
 ---@field color string
 ---@field intend? fun(ctx:enemy_intend_ctx):nil
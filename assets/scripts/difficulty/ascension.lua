@@ -0,0 +1,34 @@
+register_difficulty("ASCENSION_1", {
+    level = 1,
+    name = l("difficulties.ASCENSION_1.name", "Ascension 1"),
+    description = l("difficulties.ASCENSION_1.description", "Enemies have 10% more HP."),
+    enemy_hp = 0.1
+})
+
+register_difficulty("ASCENSION_2", {
+    level = 2,
+    name = l("difficulties.ASCENSION_2.name", "Ascension 2"),
+    description = l("difficulties.ASCENSION_2.description", "You receive 15% less gold."),
+    gold = -0.15
+})
+
+register_difficulty("ASCENSION_3", {
+    level = 3,
+    name = l("difficulties.ASCENSION_3.name", "Ascension 3"),
+    description = l("difficulties.ASCENSION_3.description", "The merchant asks for 20% more."),
+    merchant_price = 0.2
+})
+
+register_difficulty("ASCENSION_4", {
+    level = 4,
+    name = l("difficulties.ASCENSION_4.name", "Ascension 4"),
+    description = l("difficulties.ASCENSION_4.description", "Enemies start each fight with 3 block."),
+    enemy_block = 3
+})
+
+register_difficulty("ASCENSION_5", {
+    level = 5,
+    name = l("difficulties.ASCENSION_5.name", "Ascension 5"),
+    description = l("difficulties.ASCENSION_5.description", "Enemies have another 15% more HP."),
+    enemy_hp = 0.15
+})
@@ -17,6 +17,9 @@ register_enemy("DUMMY", {
     color = "#deeb6a",
     initial_hp = 100,
     max_hp = 100,
+    intend = function(ctx)
+        return "Nothing"
+    end,
     callbacks = {
         on_turn = function(ctx)
             return nil
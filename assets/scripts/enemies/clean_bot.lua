@@ -21,7 +21,7 @@ register_enemy("CLEAN_BOT", {
             local self = get_actor(ctx.guid)
 
             if self.hp <= 4 then
-                give_status_effect("BLOCK", ctx.guid, 2)
+                give_block(ctx.guid, 2)
             end
         end,
         on_turn = function(ctx)
@@ -1,6 +1,6 @@
 register_card("BLOCK", {
     name = "Block",
-    description = "Shield yourself and gain 5 " .. highlight("block") .. ".",
+    description = "Shield yourself and gain 1 (+1 for each upgrade) " .. highlight("block") .. ".",
     state = function(ctx)
         return "Shield yourself and gain " .. highlight(1 + ctx.level) .. " block."
     end,
@@ -12,53 +12,58 @@ register_card("BLOCK", {
     price = 40,
     callbacks = {
         on_cast = function(ctx)
-            give_status_effect("BLOCK", ctx.caster, 1 + ctx.level)
+            give_block(ctx.caster, 1 + ctx.level)
             return nil
         end
-    }
+    },
+    test = function()
+        local cards = get_cards(PLAYER_ID)
+        cast_card(cards[1], PLAYER_ID)
+
+        if get_actor(PLAYER_ID).block ~= 1 then
+            return "Expected 1 block, got " .. get_actor(PLAYER_ID).block
+        end
+
+        local dummy = add_actor_by_enemy("DUMMY")
+        local damage = deal_damage(dummy, PLAYER_ID, 3)
+        if damage ~= 2 then
+            return "Expected 2 damage, got " .. damage
+        end
+
+        if get_actor(PLAYER_ID).block ~= 0 then
+            return "Expected 0 block, got " .. get_actor(PLAYER_ID).block
+        end
+    end
 })
 
+-- Deprecated: block is part of the actor now. Mods that still give this status effect get core block instead.
 register_status_effect("BLOCK", {
     name = "Block",
-    description = "Decreases incoming damage for each stack",
+    description = "Deprecated, use " .. highlight("give_block") .. " instead",
     look = "Blk",
     foreground = COLOR_BLUE,
     state = function(ctx)
-        return "Takes " .. highlight(ctx.stacks) .. " less damage"
+        return "Turns into " .. highlight(ctx.stacks) .. " block"
     end,
     can_stack = true,
     decay = DECAY_ALL,
     rounds = 1,
     order = 100,
     callbacks = {
-        on_damage_calc = function(ctx)
-            if ctx.simulated then
-                return ctx.damage
-            end
-            
-            if ctx.target == ctx.owner then
-                add_status_effect_stacks(ctx.guid, -ctx.damage)
-                return ctx.damage - ctx.stacks
-            end
-            return ctx.damage
-        end,
+        on_status_add = function(ctx)
+            local instance = get_status_effect_instance(ctx.guid)
+            give_block(instance.owner, instance.stacks)
+            remove_status_effect(ctx.guid)
+            return nil
+        end
     },
     test = function()
-        return assert_chain({
-            function() return assert_status_effect_count(1) end,
-            function() return assert_status_effect("BLOCK", 1) end,
-            function ()
-                local dummy = add_actor_by_enemy("DUMMY")
-                local damage = deal_damage(dummy, PLAYER_ID, 1)
-                if damage ~= 0 then
-                    return "Expected 0 damage, got " .. damage
-                end
+        if get_actor(PLAYER_ID).block ~= 1 then
+            return "Expected 1 block, got " .. get_actor(PLAYER_ID).block
+        end
 
-                damage = deal_damage(dummy, PLAYER_ID, 2)
-                if damage ~= 2 then
-                    return "Expected 2 damage, got " .. damage
-                end
-            end
-        })
+        if #get_actor_status_effects(PLAYER_ID) ~= 0 then
+            return "Expected the status effect to be removed"
+        end
     end
 })
@@ -19,6 +19,11 @@
             return not table.contains(history, event.id)
         end):totable()
 
+        -- all events of the act have been played, so the run is won
+        if #possible == 0 then
+            return GAME_STATE_VICTORY
+        end
+
         set_event(possible[math.random(#possible)].id)
 
         return GAME_STATE_EVENT
@@ -66,6 +66,7 @@ mkdir -p ./bin
 $GOEXE build -o ./bin/end_of_eden$EXT ./cmd/game/
 $GOEXE build -o ./bin/end_of_eden_win$EXT ./cmd/game/
 $GOEXE build -o ./bin/end_of_eden_browser$EXT ./cmd/game_browser/
+$GOEXE build -o ./bin/end_of_eden_server$EXT ./cmd/game_server/
 $GOEXE build -o ./bin/fuzzy_tester$EXT ./cmd/internal/fuzzy_tester/
 
 # Disable SSH version for now:
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/BigJk/end_of_eden/system/gen"
+	"github.com/BigJk/end_of_eden/system/gen/faces"
+	"github.com/BigJk/end_of_eden/system/localization"
+	"github.com/labstack/echo/v4"
+	"log"
+	"net/http"
+	"os"
+)
+
+func main() {
+	bind := flag.String("bind", "127.0.0.1:8274", "ip and port to bind to")
+	maxSessions := flag.Int("max_sessions", 10, "maximum of concurrent game sessions")
+	language := flag.String("language", "en", "language of the game texts")
+	help := flag.Bool("help", false, "show help")
+	flag.Parse()
+
+	if *help {
+		fmt.Println("End Of Eden :: Game Server")
+		fmt.Println("Hosts game sessions behind a JSON-RPC 2.0 api at POST /rpc. Modding is supported, audio is not.")
+		fmt.Println()
+		flag.PrintDefaults()
+		return
+	}
+
+	// Init generators
+	if err := faces.InitGlobal("./assets/gen/faces"); err != nil {
+		panic(err)
+	}
+	gen.InitGen()
+
+	// Init localization
+	if err := localization.Global.AddFolder("./assets/locals"); err != nil {
+		panic(err)
+	}
+	localization.SetCurrent(*language)
+
+	server := newServer(*maxSessions)
+
+	e := echo.New()
+	e.HideBanner = true
+	e.POST("/rpc", server.handle, recoverRPC)
+
+	log.Println("Listening on", *bind)
+	if err := e.Start(*bind); err != nil && err != http.ErrServerClosed {
+		log.Println("Server error:", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,385 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/BigJk/end_of_eden/game"
+	"github.com/labstack/echo/v4"
+	"github.com/samber/lo"
+	"log"
+	"math/rand"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// JSON-RPC 2.0 error codes.
+const (
+	rpcParseError     = -32700
+	rpcInvalidRequest = -32600
+	rpcMethodNotFound = -32601
+	rpcInvalidParams  = -32602
+	rpcInternalError  = -32603
+	rpcGameError      = -32000
+)
+
+type rpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params"`
+	ID      json.RawMessage `json:"id"`
+}
+
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Result  any             `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+	ID      json.RawMessage `json:"id"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *rpcError) Error() string {
+	return e.Message
+}
+
+// hostedSession is a game session that is hosted by the server. Sessions are not thread-safe, so all
+// access has to be guarded by the mutex.
+type hostedSession struct {
+	sync.Mutex
+
+	session *game.Session
+	done    chan struct{}
+
+	// The lua errors are collected in the background while a call might hold the session lock,
+	// so they are guarded by their own mutex.
+	errMtx    sync.Mutex
+	luaErrors []string
+}
+
+// takeLuaErrors returns the lua errors that happened since the last call.
+func (h *hostedSession) takeLuaErrors() []string {
+	h.errMtx.Lock()
+	defer h.errMtx.Unlock()
+
+	errs := h.luaErrors
+	h.luaErrors = nil
+	return errs
+}
+
+// server hosts the game sessions and dispatches the rpc calls to them.
+type server struct {
+	mtx         sync.Mutex
+	sessions    map[string]*hostedSession
+	maxSessions int
+	methods     map[string]func(params json.RawMessage) (any, error)
+
+	// rnd creates the session ids. It is owned by the server, so the ids don't depend on the seeds of
+	// the sessions. It must only be used while holding mtx.
+	rnd *rand.Rand
+}
+
+func newServer(maxSessions int) *server {
+	s := &server{
+		sessions:    map[string]*hostedSession{},
+		maxSessions: maxSessions,
+		rnd:         rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+
+	s.methods = map[string]func(params json.RawMessage) (any, error){
+		"create_session":        s.createSession,
+		"close_session":         s.closeSession,
+		"get_state":             s.withSession(nil, s.getState),
+		"cast_card":             s.withSession([]game.GameState{game.GameStateFight}, s.castCard),
+		"end_turn":              s.withSession([]game.GameState{game.GameStateFight}, s.endTurn),
+		"choose_event_option":   s.withSession([]game.GameState{game.GameStateEvent}, s.chooseEventOption),
+		"merchant_buy_card":     s.withSession([]game.GameState{game.GameStateMerchant}, s.merchantBuyCard),
+		"merchant_buy_artifact": s.withSession([]game.GameState{game.GameStateMerchant}, s.merchantBuyArtifact),
+		"merchant_leave":        s.withSession([]game.GameState{game.GameStateMerchant}, s.merchantLeave),
+	}
+
+	return s
+}
+
+// recoverRPC is a middleware that turns panics of the rpc handler into internal errors, so the client
+// always gets a JSON-RPC response. The id of the request is unknown at this point, so it is null.
+func recoverRPC(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				log.Println("Recovered from panic:", r)
+				err = c.JSON(http.StatusOK, rpcResponse{JSONRPC: "2.0", Error: &rpcError{Code: rpcInternalError, Message: fmt.Sprint("internal error: ", r)}, ID: json.RawMessage("null")})
+			}
+		}()
+		return next(c)
+	}
+}
+
+// handle is the http handler for the rpc endpoint.
+func (s *server) handle(c echo.Context) error {
+	var req rpcRequest
+	if err := json.NewDecoder(c.Request().Body).Decode(&req); err != nil {
+		return c.JSON(http.StatusOK, rpcResponse{JSONRPC: "2.0", Error: &rpcError{Code: rpcParseError, Message: err.Error()}, ID: json.RawMessage("null")})
+	}
+
+	res := rpcResponse{JSONRPC: "2.0", ID: lo.Ternary(len(req.ID) > 0, req.ID, json.RawMessage("null"))}
+
+	if req.JSONRPC != "2.0" || len(req.Method) == 0 {
+		res.Error = &rpcError{Code: rpcInvalidRequest, Message: "invalid request"}
+		return c.JSON(http.StatusOK, res)
+	}
+
+	method, ok := s.methods[req.Method]
+	if !ok {
+		res.Error = &rpcError{Code: rpcMethodNotFound, Message: fmt.Sprintf("method '%s' not found", req.Method)}
+		return c.JSON(http.StatusOK, res)
+	}
+
+	result, err := method(req.Params)
+	if err != nil {
+		var rpcErr *rpcError
+		if errors.As(err, &rpcErr) {
+			res.Error = rpcErr
+		} else {
+			res.Error = &rpcError{Code: rpcGameError, Message: err.Error()}
+		}
+	} else {
+		res.Result = result
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+// withSession wraps a method that works on a single session. The session is locked for the duration of
+// the call and the method is only allowed in the given game states. After the call the new state of the
+// session is returned if the method itself has no result.
+func (s *server) withSession(states []game.GameState, fn func(hosted *hostedSession, params json.RawMessage) (any, error)) func(params json.RawMessage) (any, error) {
+	return func(params json.RawMessage) (any, error) {
+		var base struct {
+			Session string `json:"session"`
+		}
+		if err := unmarshalParams(params, &base); err != nil {
+			return nil, err
+		}
+
+		s.mtx.Lock()
+		hosted, ok := s.sessions[base.Session]
+		s.mtx.Unlock()
+
+		if !ok {
+			return nil, &rpcError{Code: rpcInvalidParams, Message: fmt.Sprintf("session '%s' not found", base.Session)}
+		}
+
+		hosted.Lock()
+		defer hosted.Unlock()
+
+		if len(states) > 0 && !lo.Contains(states, hosted.session.GetGameState()) {
+			return nil, fmt.Errorf("not allowed in game state %s", hosted.session.GetGameState())
+		}
+
+		res, err := fn(hosted, params)
+		if err != nil || res != nil {
+			return res, err
+		}
+
+		return newStateView(hosted), nil
+	}
+}
+
+// newSessionID returns a new unused session id. It must only be called while holding mtx.
+func (s *server) newSessionID() string {
+	for {
+		id := fmt.Sprintf("%016x", s.rnd.Uint64())
+		if _, ok := s.sessions[id]; !ok {
+			return id
+		}
+	}
+}
+
+func (s *server) createSession(params json.RawMessage) (any, error) {
+	var p struct {
+		Seed int64    `json:"seed"`
+		Mods []string `json:"mods"`
+	}
+	if err := unmarshalParams(params, &p); err != nil {
+		return nil, err
+	}
+
+	// Mods are loaded from the mods folder, so a name must not point anywhere else.
+	for _, mod := range p.Mods {
+		if !isModName(mod) {
+			return nil, &rpcError{Code: rpcInvalidParams, Message: fmt.Sprintf("invalid mod '%s'", mod)}
+		}
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if len(s.sessions) >= s.maxSessions {
+		return nil, errors.New("maximum of sessions reached")
+	}
+
+	hosted := &hostedSession{done: make(chan struct{})}
+	options := []func(s *game.Session){
+		game.WithMods(p.Mods),
+		func(session *game.Session) {
+			// Collect the lua errors, so they can be reported with the state.
+			go func() {
+				for {
+					select {
+					case err := <-session.LuaErrors():
+						hosted.errMtx.Lock()
+						hosted.luaErrors = append(hosted.luaErrors, fmt.Sprintf("%s (%s): %s", err.Callback, err.Type, err.Err))
+						hosted.errMtx.Unlock()
+					case <-hosted.done:
+						return
+					}
+				}
+			}()
+		},
+	}
+	if p.Seed != 0 {
+		options = append(options, game.WithSeed(p.Seed))
+	}
+
+	hosted.Lock()
+	defer hosted.Unlock()
+
+	hosted.session = game.NewSession(options...)
+	id := s.newSessionID()
+	s.sessions[id] = hosted
+
+	log.Println("Created session:", id)
+
+	return struct {
+		Session string    `json:"session"`
+		State   stateView `json:"state"`
+	}{
+		Session: id,
+		State:   newStateView(hosted),
+	}, nil
+}
+
+func (s *server) closeSession(params json.RawMessage) (any, error) {
+	var p struct {
+		Session string `json:"session"`
+	}
+	if err := unmarshalParams(params, &p); err != nil {
+		return nil, err
+	}
+
+	s.mtx.Lock()
+	hosted, ok := s.sessions[p.Session]
+	delete(s.sessions, p.Session)
+	s.mtx.Unlock()
+
+	if !ok {
+		return nil, &rpcError{Code: rpcInvalidParams, Message: fmt.Sprintf("session '%s' not found", p.Session)}
+	}
+
+	hosted.Lock()
+	defer hosted.Unlock()
+
+	close(hosted.done)
+	hosted.session.Close()
+
+	log.Println("Closed session:", p.Session)
+
+	return true, nil
+}
+
+func (s *server) getState(hosted *hostedSession, params json.RawMessage) (any, error) {
+	return nil, nil
+}
+
+func (s *server) castCard(hosted *hostedSession, params json.RawMessage) (any, error) {
+	var p struct {
+		Index  int    `json:"index"`
+		Target string `json:"target"`
+	}
+	if err := unmarshalParams(params, &p); err != nil {
+		return nil, err
+	}
+
+	if p.Index < 0 || p.Index >= len(hosted.session.GetFight().Hand) {
+		return nil, &rpcError{Code: rpcInvalidParams, Message: "invalid index"}
+	}
+
+	return nil, hosted.session.PlayerCastHand(p.Index, p.Target)
+}
+
+func (s *server) endTurn(hosted *hostedSession, params json.RawMessage) (any, error) {
+	hosted.session.FinishPlayerTurn()
+	return nil, nil
+}
+
+func (s *server) chooseEventOption(hosted *hostedSession, params json.RawMessage) (any, error) {
+	var p struct {
+		Choice int `json:"choice"`
+	}
+	if err := unmarshalParams(params, &p); err != nil {
+		return nil, err
+	}
+
+	event := hosted.session.GetEvent()
+	if event == nil || len(event.Choices) > 0 && (p.Choice < 0 || p.Choice >= len(event.Choices)) {
+		return nil, &rpcError{Code: rpcInvalidParams, Message: "invalid choice"}
+	}
+
+	hosted.session.FinishEvent(p.Choice)
+	return nil, nil
+}
+
+func (s *server) merchantBuyCard(hosted *hostedSession, params json.RawMessage) (any, error) {
+	var p struct {
+		TypeID string `json:"type_id"`
+	}
+	if err := unmarshalParams(params, &p); err != nil {
+		return nil, err
+	}
+
+	if !hosted.session.PlayerBuyCard(p.TypeID) {
+		return nil, errors.New("can't buy card")
+	}
+	return nil, nil
+}
+
+func (s *server) merchantBuyArtifact(hosted *hostedSession, params json.RawMessage) (any, error) {
+	var p struct {
+		TypeID string `json:"type_id"`
+	}
+	if err := unmarshalParams(params, &p); err != nil {
+		return nil, err
+	}
+
+	if !hosted.session.PlayerBuyArtifact(p.TypeID) {
+		return nil, errors.New("can't buy artifact")
+	}
+	return nil, nil
+}
+
+func (s *server) merchantLeave(hosted *hostedSession, params json.RawMessage) (any, error) {
+	hosted.session.LeaveMerchant()
+	return nil, nil
+}
+
+// isModName checks that the name is a plain folder or archive name inside the mods folder.
+func isModName(name string) bool {
+	return len(name) > 0 && name != "." && !strings.Contains(name, "..") && !strings.ContainsAny(name, `/\:`)
+}
+
+func unmarshalParams(params json.RawMessage, v any) error {
+	if len(params) == 0 {
+		return nil
+	}
+
+	if err := json.Unmarshal(params, v); err != nil {
+		return &rpcError{Code: rpcInvalidParams, Message: err.Error()}
+	}
+	return nil
+}
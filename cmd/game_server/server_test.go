@@ -0,0 +1,138 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/BigJk/end_of_eden/game"
+	"github.com/BigJk/end_of_eden/system/gen"
+	"github.com/BigJk/end_of_eden/system/gen/faces"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+func TestMain(m *testing.M) {
+	// The sessions need the base game content, which is loaded relative to the repository root.
+	if err := os.Chdir("../.."); err != nil {
+		panic(err)
+	}
+
+	if err := faces.InitGlobal("./assets/gen/faces"); err != nil {
+		panic(err)
+	}
+	gen.InitGen()
+
+	os.Exit(m.Run())
+}
+
+type testClient struct {
+	t   *testing.T
+	url string
+}
+
+func (c testClient) call(method string, params any, result any) *rpcError {
+	body, err := json.Marshal(map[string]any{"jsonrpc": "2.0", "method": method, "params": params, "id": 1})
+	if !assert.NoError(c.t, err) {
+		return nil
+	}
+
+	res, err := http.Post(c.url+"/rpc", "application/json", bytes.NewReader(body))
+	if !assert.NoError(c.t, err) {
+		return nil
+	}
+	defer res.Body.Close()
+
+	var rpcRes struct {
+		Result json.RawMessage `json:"result"`
+		Error  *rpcError       `json:"error"`
+	}
+	if !assert.NoError(c.t, json.NewDecoder(res.Body).Decode(&rpcRes)) || rpcRes.Error != nil {
+		return rpcRes.Error
+	}
+
+	if result != nil {
+		assert.NoError(c.t, json.Unmarshal(rpcRes.Result, result))
+	}
+	return nil
+}
+
+func TestServer(t *testing.T) {
+	e := echo.New()
+	e.POST("/rpc", newServer(1).handle, recoverRPC)
+
+	httpServer := httptest.NewServer(e)
+	defer httpServer.Close()
+
+	client := testClient{t: t, url: httpServer.URL}
+
+	t.Run("InvalidMods", func(t *testing.T) {
+		for _, mod := range []string{"../../some/dir", "..", "a/b", `a\b`, "C:mod", ""} {
+			err := client.call("create_session", map[string]any{"mods": []string{mod}}, nil)
+			if assert.NotNil(t, err, mod) {
+				assert.Equal(t, rpcInvalidParams, err.Code)
+			}
+		}
+	})
+
+	t.Run("Fight", func(t *testing.T) {
+		var created struct {
+			Session string    `json:"session"`
+			State   stateView `json:"state"`
+		}
+		if !assert.Nil(t, client.call("create_session", map[string]any{"seed": 42}, &created)) {
+			return
+		}
+		assert.Equal(t, game.GameStateEvent, created.State.State)
+
+		// The start event is followed by the event of the first fight.
+		state := created.State
+		for i := 0; i < 2 && state.State == game.GameStateEvent; i++ {
+			assert.Nil(t, client.call("choose_event_option", map[string]any{"session": created.Session, "choice": 0}, &state))
+		}
+		if !assert.Equal(t, game.GameStateFight, state.State) || !assert.NotEmpty(t, state.Hand) {
+			return
+		}
+
+		assert.NotNil(t, client.call("end_turn", map[string]any{"session": "unknown"}, nil))
+
+		target := ""
+		for guid := range state.Intends {
+			target = guid
+		}
+		hand := len(state.Hand)
+
+		for _, index := range []int{-1, hand} {
+			err := client.call("cast_card", map[string]any{"session": created.Session, "index": index, "target": target}, nil)
+			if assert.NotNil(t, err, index) {
+				assert.Equal(t, rpcInvalidParams, err.Code)
+			}
+		}
+
+		assert.Nil(t, client.call("cast_card", map[string]any{"session": created.Session, "index": 0, "target": target}, &state))
+		assert.Len(t, state.Hand, hand-1)
+
+		assert.Nil(t, client.call("end_turn", map[string]any{"session": created.Session}, &state))
+		assert.Equal(t, 1, state.Fight.Round)
+
+		assert.Nil(t, client.call("close_session", map[string]any{"session": created.Session}, nil))
+		assert.NotNil(t, client.call("get_state", map[string]any{"session": created.Session}, nil))
+	})
+}
+
+func TestServerRecover(t *testing.T) {
+	e := echo.New()
+	e.POST("/rpc", func(c echo.Context) error {
+		panic("broken")
+	}, recoverRPC)
+
+	httpServer := httptest.NewServer(e)
+	defer httpServer.Close()
+
+	err := testClient{t: t, url: httpServer.URL}.call("get_state", nil, nil)
+	if assert.NotNil(t, err) {
+		assert.Equal(t, rpcInternalError, err.Code)
+	}
+}
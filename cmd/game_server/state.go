@@ -0,0 +1,91 @@
+package main
+
+import (
+	"github.com/BigJk/end_of_eden/game"
+	"github.com/samber/lo"
+)
+
+// stateView is the state of a session as it is returned by the api. It contains everything a client needs to
+// render the current game state and decide on the next action.
+type stateView struct {
+	State         game.GameState     `json:"state"`
+	StagesCleared int                `json:"stages_cleared"`
+	Actors        []game.Actor       `json:"actors"`
+	Intends       map[string]string  `json:"intends,omitempty"`
+	Fight         game.FightState    `json:"fight"`
+	Hand          []cardView         `json:"hand,omitempty"`
+	Merchant      game.MerchantState `json:"merchant"`
+	Event         *eventView         `json:"event,omitempty"`
+	Logs          []game.LogEntry    `json:"logs,omitempty"`
+	LuaErrors     []string           `json:"lua_errors,omitempty"`
+}
+
+type cardView struct {
+	GUID        string `json:"guid"`
+	TypeID      string `json:"type_id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	PointCost   int    `json:"point_cost"`
+	NeedTarget  bool   `json:"need_target"`
+}
+
+type eventView struct {
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Choices     []string `json:"choices"`
+}
+
+// maxStateLogs is the amount of the most recent log entries that are included in the state.
+const maxStateLogs = 20
+
+func newStateView(hosted *hostedSession) stateView {
+	session := hosted.session
+
+	view := stateView{
+		State:         session.GetGameState(),
+		StagesCleared: session.GetStagesCleared(),
+		Actors: lo.Map(session.GetActors(), func(guid string, index int) game.Actor {
+			return session.GetActor(guid)
+		}),
+		Fight:     session.GetFight(),
+		Merchant:  session.GetMerchant(),
+		Logs:      lo.Subset(session.Logs, -maxStateLogs, maxStateLogs),
+		LuaErrors: hosted.takeLuaErrors(),
+	}
+
+	switch view.State {
+	case game.GameStateFight:
+		view.Intends = lo.SliceToMap(session.GetOpponentGUIDs(game.PlayerActorID), func(guid string) (string, string) {
+			return guid, session.GetActorIntend(guid)
+		})
+		view.Hand = lo.Map(view.Fight.Hand, func(guid string, index int) cardView {
+			card, instance := session.GetCard(guid)
+			if card == nil {
+				return cardView{GUID: guid, TypeID: instance.TypeID}
+			}
+
+			return cardView{
+				GUID:        guid,
+				TypeID:      card.ID,
+				Name:        card.Name,
+				Description: session.GetCardState(guid),
+				PointCost:   card.PointCost,
+				NeedTarget:  card.NeedTarget,
+			}
+		})
+	case game.GameStateEvent:
+		if event := session.GetEvent(); event != nil {
+			view.Event = &eventView{
+				ID:          event.ID,
+				Name:        event.Name,
+				Description: event.Description,
+				Choices: lo.Map(event.Choices, func(choice game.EventChoice, index int) string {
+					return session.GetEventChoiceDescription(index)
+				}),
+			}
+		}
+	}
+
+	return view
+}
@@ -5,11 +5,13 @@ import (
 	"errors"
 	"flag"
 	"fmt"
+	"github.com/BigJk/end_of_eden/game"
 	"github.com/BigJk/end_of_eden/system/settings"
 	"github.com/BigJk/end_of_eden/ui/menus/mainmenu"
 	"github.com/BigJk/end_of_eden/ui/root"
 	"github.com/charmbracelet/lipgloss"
 	zone "github.com/lrstanley/bubblezone"
+	"github.com/samber/lo"
 	"os"
 	"os/signal"
 	"sync"
@@ -37,6 +39,8 @@ func main() {
 	bind := flag.String("bind", ":8273", "ip and port to bind to")
 	timeout := flag.Int("timeout", 0, "ssh idle timeout")
 	maxInstance := flag.Int("max_inst", 10, "maximum of game instances")
+	debug := flag.Bool("debug", false, "start a debug server for each game session on a random local port, address and token are written to the session logs")
+	debugReadOnly := flag.Bool("debug_read_only", true, "don't allow the debug servers to execute lua")
 	help := flag.Bool("help", false, "show help")
 	flag.Parse()
 
@@ -64,7 +68,11 @@ func main() {
 					handler(session)
 				}
 			},
-			gameMiddleware(),
+			gameMiddleware(lo.Ternary(*debug, &game.DebugConfig{
+				// Each game session gets its own debug server, so a fixed port would only work for the first one.
+				Bind:     "127.0.0.1:0",
+				ReadOnly: *debugReadOnly,
+			}, nil)),
 			func(handler ssh.Handler) ssh.Handler {
 				return func(session ssh.Session) {
 					mtx.Lock()
@@ -101,7 +109,7 @@ func main() {
 
 	done := make(chan os.Signal, 1)
 	signal.Notify(done, os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
-	log.Info("Starting SSH server:", "bind", *bind, "max_inst", *maxInstance, "timeout", *timeout)
+	log.Info("Starting SSH server:", "bind", *bind, "max_inst", *maxInstance, "timeout", *timeout, "debug", *debug)
 	go func() {
 		if err = s.ListenAndServe(); err != nil && !errors.Is(err, ssh.ErrServerClosed) {
 			log.Error("could not start server", "error", err)
@@ -117,7 +125,9 @@ func main() {
 	}
 }
 
-func gameMiddleware() wish.Middleware {
+// gameMiddleware starts a game for each ssh session. If debugConfig is set, each game session starts its own
+// debug server with it.
+func gameMiddleware(debugConfig *game.DebugConfig) wish.Middleware {
 	newProg := func(m tea.Model, opts ...tea.ProgramOption) *tea.Program {
 		p := tea.NewProgram(m, opts...)
 		lipgloss.SetColorProfile(termenv.TrueColor)
@@ -130,8 +140,12 @@ func gameMiddleware() wish.Middleware {
 			return nil
 		}
 		zones := zone.New()
+		menu := mainmenu.NewModel(zones, settings.GetGlobal(), nil, nil)
+		if debugConfig != nil {
+			menu = menu.WithDebugConfig(*debugConfig)
+		}
 		return newProg(
-			root.New(zones, mainmenu.NewModel(zones, settings.GetGlobal(), nil, nil)),
+			root.New(zones, menu),
 			tea.WithInput(s),
 			tea.WithOutput(s),
 			tea.WithANSICompressor(),
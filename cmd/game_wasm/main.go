@@ -94,6 +94,8 @@ func main() {
 		set.SetDefault("audio", true)
 		set.SetDefault("volume", 1)
 		set.SetDefault("language", "en")
+		set.SetDefault("casual", false)
+		set.SetDefault("difficulty", 0)
 		settings.SetSettings(set)
 
 		if err := settings.LoadSettings(); err != nil {
@@ -136,6 +138,8 @@ func main() {
 		{Key: "audio", Name: "Audio", Description: "Enable or disable audio", Type: uiset.Bool, Val: settings.GetBool("audio"), Min: nil, Max: nil},
 		{Key: "volume", Name: "Volume", Description: "Change the volume", Type: uiset.Float, Val: settings.GetFloat("volume"), Min: 0.0, Max: 2.0},
 		{Key: "language", Name: "Language", Description: fmt.Sprintf("Change the language (supported: %s)", strings.Join(localization.Global.GetLocales(), ", ")), Type: uiset.String, Val: settings.GetString("language")},
+		{Key: "casual", Name: "Casual Mode", Description: "Allow to undo cards during fights", Type: uiset.Bool, Val: settings.GetBool("casual"), Min: nil, Max: nil},
+		{Key: "difficulty", Name: "Difficulty", Description: "Difficulty of new runs. Winning a run unlocks the next level", Type: uiset.Int, Val: settings.GetInt("difficulty"), Min: 0, Max: nil},
 		{Key: "font_size", Name: "Font Size", Description: "Change the font size (page reload required)", Type: uiset.Float, Val: settings.GetFloat("font_size"), Min: 6.0, Max: 64.0},
 	}
 
@@ -95,6 +95,8 @@ func main() {
 	vi.SetDefault("audio", true)
 	vi.SetDefault("volume", 1)
 	vi.SetDefault("language", "en")
+	vi.SetDefault("casual", false)
+	vi.SetDefault("difficulty", 0)
 	vi.SetDefault("font_size", 12)
 	vi.SetDefault("font_normal", "IosevkaTermNerdFontMono-Regular.ttf")
 	vi.SetDefault("font_italic", "IosevkaTermNerdFontMono-Italic.ttf")
@@ -120,6 +122,8 @@ func main() {
 		{Key: "audio", Name: "Audio", Description: "Enable or disable audio", Type: uiset.Bool, Val: settings.GetBool("audio"), Min: nil, Max: nil},
 		{Key: "volume", Name: "Volume", Description: "Change the volume", Type: uiset.Float, Val: settings.GetFloat("volume"), Min: 0.0, Max: 2.0},
 		{Key: "language", Name: "Language", Description: fmt.Sprintf("Change the language (supported: %s)", strings.Join(localization.Global.GetLocales(), ", ")), Type: uiset.String, Val: settings.GetString("language")},
+		{Key: "casual", Name: "Casual Mode", Description: "Allow to undo cards during fights", Type: uiset.Bool, Val: settings.GetBool("casual"), Min: nil, Max: nil},
+		{Key: "difficulty", Name: "Difficulty", Description: "Difficulty of new runs. Winning a run unlocks the next level", Type: uiset.Int, Val: settings.GetInt("difficulty"), Min: 0, Max: nil},
 		{Key: "font_size", Name: "Font Size", Description: "Change the font size", Type: uiset.Float, Val: settings.GetFloat("font_size"), Min: 6.0, Max: 64.0},
 		{Key: "width", Name: "Width", Description: "Change the window width", Type: uiset.Float, Val: settings.GetFloat("width"), Min: 450.0, Max: 5000.0},
 		{Key: "height", Name: "Height", Description: "Change the window height", Type: uiset.Float, Val: settings.GetFloat("height"), Min: 450.0, Max: 5000.0},
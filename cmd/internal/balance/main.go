@@ -0,0 +1,156 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/BigJk/end_of_eden/game"
+	"github.com/BigJk/end_of_eden/system/gen"
+	"github.com/BigJk/end_of_eden/system/gen/faces"
+	"github.com/BigJk/end_of_eden/ui/style"
+	"github.com/samber/lo"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+func splitList(s string, sep string) []string {
+	items := lo.Map(strings.Split(s, sep), func(item string, index int) string {
+		return strings.TrimSpace(item)
+	})
+	return lo.Filter(items, func(item string, index int) bool {
+		return len(item) > 0
+	})
+}
+
+func main() {
+	modsFlag := flag.String("mods", "", "mods to load (e.g. 'my-mod,test-mod,another-mod')")
+	decksFlag := flag.String("decks", "MELEE_HIT,MELEE_HIT,MELEE_HIT,BLOCK,BLOCK", "starter decks separated by ';', cards of a deck separated by ','")
+	fights := flag.Int("fights", 50, "fights per enemy and deck")
+	hp := flag.Int("hp", 0, "hp of the player at the start of each fight (0 uses the starting hp of a new run)")
+	maxTurns := flag.Int("max_turns", 30, "turns after which a fight is counted as timeout")
+	agentFlag := flag.String("agent", "greedy", "agent that plays the fights (greedy, random)")
+	seed := flag.Int64("seed", 1, "base seed of the simulated sessions")
+	routines := flag.Int("n", 1, "number of goroutines")
+	out := flag.String("out", "balance", "output prefix, writes <out>_enemies.csv, <out>_cards.csv and <out>.md")
+	help := flag.Bool("help", false, "show help")
+	flag.Parse()
+
+	if *help {
+		fmt.Println("End Of Eden :: Balance")
+		fmt.Println("The balance tool simulates fights against all enemies with the given starter decks and reports win rates and card usage.")
+		fmt.Println()
+		flag.PrintDefaults()
+		return
+	}
+
+	if *agentFlag != "greedy" && *agentFlag != "random" {
+		fmt.Println(style.RedText.Render(fmt.Sprintf("Unknown agent '%s'", *agentFlag)))
+		os.Exit(1)
+	}
+
+	if err := faces.InitGlobal("./assets/gen/faces"); err != nil {
+		panic(err)
+	}
+	gen.InitGen()
+
+	sim := simulator{
+		mods:     splitList(*modsFlag, ","),
+		decks:    lo.Map(splitList(*decksFlag, ";"), func(deck string, index int) []string { return splitList(deck, ",") }),
+		fights:   *fights,
+		hp:       *hp,
+		maxTurns: *maxTurns,
+		agent:    *agentFlag,
+	}
+
+	// Load the resources once to validate the decks and to know which enemies exist. Only the names are
+	// kept, as the resources belong to the lua state of the session.
+	session := game.NewSession(game.WithMods(sim.mods))
+	cardNames := lo.MapValues(session.GetResources().Cards, func(card *game.Card, id string) string { return card.Name })
+	enemyNames := lo.MapValues(session.GetResources().Enemies, func(enemy *game.Enemy, id string) string { return enemy.Name })
+	if sim.hp <= 0 {
+		sim.hp = session.GetPlayer().MaxHP
+	}
+	session.Close()
+
+	for _, deck := range sim.decks {
+		for _, card := range deck {
+			if _, ok := cardNames[card]; !ok {
+				fmt.Println(style.RedText.Render(fmt.Sprintf("Unknown card '%s' in starter deck", card)))
+				os.Exit(1)
+			}
+		}
+	}
+
+	enemies := lo.Keys(enemyNames)
+	sort.Strings(enemies)
+
+	var jobs []job
+	for deck := range sim.decks {
+		for _, enemy := range enemies {
+			jobs = append(jobs, job{Deck: deck, Enemy: enemy, Seed: *seed + int64(len(jobs))})
+		}
+	}
+
+	fmt.Println("Decks   :", len(sim.decks))
+	fmt.Println("Enemies :", len(enemies))
+	fmt.Println("Fights  :", len(jobs)*sim.fights)
+	fmt.Println("\nWorking...")
+
+	rep := report{
+		Agent:      sim.agent,
+		Fights:     sim.fights,
+		HP:         sim.hp,
+		Decks:      sim.decks,
+		Enemies:    make([]fightStats, len(jobs)),
+		Cards:      map[string]*cardStats{},
+		CardNames:  cardNames,
+		EnemyNames: enemyNames,
+	}
+
+	mtx := sync.Mutex{}
+	queue := make(chan int)
+	wg := &sync.WaitGroup{}
+	for i := 0; i < lo.Max([]int{*routines, 1}); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range queue {
+				stats, cards := sim.run(jobs[index])
+
+				mtx.Lock()
+				rep.Enemies[index] = stats
+				for id, s := range cards {
+					if rep.Cards[id] == nil {
+						rep.Cards[id] = &cardStats{}
+					}
+					rep.Cards[id].Played += s.Played
+					rep.Cards[id].Effective += s.Effective
+				}
+				mtx.Unlock()
+			}
+		}()
+	}
+	for i := range jobs {
+		queue <- i
+	}
+	close(queue)
+	wg.Wait()
+
+	outputs := []struct {
+		file  string
+		write func(w io.Writer) error
+	}{
+		{*out + "_enemies.csv", rep.writeEnemiesCSV},
+		{*out + "_cards.csv", rep.writeCardsCSV},
+		{*out + ".md", rep.writeMarkdown},
+	}
+	for _, output := range outputs {
+		if err := writeFile(output.file, output.write); err != nil {
+			fmt.Println(style.RedText.Render(fmt.Sprintf("Error while writing %s: %s", output.file, err)))
+			os.Exit(1)
+		}
+		fmt.Println(style.GreenText.Render("Written " + output.file))
+	}
+}
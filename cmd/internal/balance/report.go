@@ -0,0 +1,134 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// report is the result of a balance simulation.
+type report struct {
+	Agent   string
+	Fights  int
+	HP      int
+	Decks   [][]string
+	Enemies []fightStats
+	Cards   map[string]*cardStats
+
+	// CardNames and EnemyNames map the ids of all loaded cards and enemies to their names.
+	CardNames  map[string]string
+	EnemyNames map[string]string
+}
+
+// sortedCards returns the ids of all loaded cards sorted by id.
+func (r report) sortedCards() []string {
+	ids := make([]string, 0, len(r.CardNames))
+	for id := range r.CardNames {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func (r report) card(id string) cardStats {
+	if stats, ok := r.Cards[id]; ok {
+		return *stats
+	}
+	return cardStats{}
+}
+
+func (r report) enemyName(id string) string {
+	if name, ok := r.EnemyNames[id]; ok {
+		return name
+	}
+	return id
+}
+
+func (r report) writeEnemiesCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	_ = cw.Write([]string{"deck", "enemy", "name", "fights", "wins", "win_rate", "losses", "loss_rate", "timeouts", "timeout_rate", "avg_turns", "avg_damage_taken", "lua_errors"})
+	for _, s := range r.Enemies {
+		_ = cw.Write([]string{
+			strings.Join(r.Decks[s.Deck], " "),
+			s.Enemy,
+			r.enemyName(s.Enemy),
+			fmt.Sprint(s.Fights),
+			fmt.Sprint(s.Wins),
+			fmt.Sprintf("%.3f", s.winRate()),
+			fmt.Sprint(s.Losses),
+			fmt.Sprintf("%.3f", s.lossRate()),
+			fmt.Sprint(s.Timeouts),
+			fmt.Sprintf("%.3f", s.timeoutRate()),
+			fmt.Sprintf("%.2f", s.avgTurns()),
+			fmt.Sprintf("%.2f", s.avgDamageTaken()),
+			fmt.Sprint(s.Errors),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func (r report) writeCardsCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	_ = cw.Write([]string{"card", "name", "played", "effective", "effective_rate"})
+	for _, id := range r.sortedCards() {
+		s := r.card(id)
+		_ = cw.Write([]string{
+			id,
+			r.CardNames[id],
+			fmt.Sprint(s.Played),
+			fmt.Sprint(s.Effective),
+			fmt.Sprintf("%.3f", s.effectiveRate()),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func (r report) writeMarkdown(w io.Writer) error {
+	sb := &strings.Builder{}
+
+	sb.WriteString("# Balance Report\n\n")
+	sb.WriteString(fmt.Sprintf("- Agent: ``%s``\n", r.Agent))
+	sb.WriteString(fmt.Sprintf("- Fights per enemy and deck: %d\n", r.Fights))
+	sb.WriteString(fmt.Sprintf("- Player HP: %d\n\n", r.HP))
+
+	for i, deck := range r.Decks {
+		sb.WriteString(fmt.Sprintf("## Deck %d\n\n", i+1))
+		sb.WriteString(fmt.Sprintf("``%s``\n\n", strings.Join(deck, ", ")))
+		sb.WriteString("| Enemy | Win Rate | Loss Rate | Timeout Rate | Avg. Turns | Avg. Damage Taken | Lua Errors |\n")
+		sb.WriteString("|-------|----------|-----------|--------------|------------|-------------------|------------|\n")
+		for _, s := range r.Enemies {
+			if s.Deck != i {
+				continue
+			}
+			sb.WriteString(fmt.Sprintf("| %s (``%s``) | %s | %s | %s | %.2f | %.2f | %d |\n", r.enemyName(s.Enemy), s.Enemy, formatPercent(s.winRate()), formatPercent(s.lossRate()), formatPercent(s.timeoutRate()), s.avgTurns(), s.avgDamageTaken(), s.Errors))
+		}
+		sb.WriteString("\n")
+	}
+
+	sb.WriteString("## Cards\n\n")
+	sb.WriteString("| Card | Played | Effective | Effective Rate |\n")
+	sb.WriteString("|------|--------|-----------|----------------|\n")
+	for _, id := range r.sortedCards() {
+		s := r.card(id)
+		sb.WriteString(fmt.Sprintf("| %s (``%s``) | %d | %d | %s |\n", r.CardNames[id], id, s.Played, s.Effective, formatPercent(s.effectiveRate())))
+	}
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// writeFile creates the file and writes to it with the given writer function.
+func writeFile(file string, fn func(w io.Writer) error) error {
+	f, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return fn(f)
+}
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"fmt"
+	"github.com/BigJk/end_of_eden/game"
+	"github.com/BigJk/end_of_eden/game/agent"
+)
+
+// job simulates all fights of one starter deck against one enemy with a single session.
+type job struct {
+	Deck  int
+	Enemy string
+	Seed  int64
+}
+
+// fightStats is the aggregated outcome of all fights of a job. Fights that were aborted because of an error
+// are neither counted as win, loss nor timeout.
+type fightStats struct {
+	Deck        int
+	Enemy       string
+	Fights      int
+	Wins        int
+	Losses      int
+	Timeouts    int
+	Turns       int
+	DamageTaken int
+	Errors      int
+}
+
+// cardStats counts how often a card was played and how often it was effective. A cast is effective if
+// it dealt damage, healed, changed a status effect or drew a card.
+type cardStats struct {
+	Played    int
+	Effective int
+}
+
+type simulator struct {
+	mods     []string
+	decks    [][]string
+	fights   int
+	hp       int
+	maxTurns int
+	agent    string
+}
+
+func (sim simulator) newAgent(seed int64) agent.Agent {
+	if sim.agent == "random" {
+		return agent.NewRandomAgent(seed)
+	}
+	return agent.NewGreedyAgent()
+}
+
+// setupFight resets the player to the starter deck and starts a fight against a single enemy.
+func (sim simulator) setupFight(session *game.Session, deck []string, enemy string) {
+	session.UpdatePlayer(func(actor *game.Actor) bool {
+		actor.HP = sim.hp
+		actor.MaxHP = sim.hp
+		return true
+	})
+
+	player := session.GetPlayer()
+	for _, artifact := range player.Artifacts.ToSlice() {
+		session.RemoveArtifact(artifact)
+	}
+	for _, card := range player.Cards.ToSlice() {
+		session.RemoveCard(card)
+	}
+	for _, card := range deck {
+		session.GiveCard(card, game.PlayerActorID)
+	}
+
+	session.RemoveNonPlayer()
+	session.AddActorFromEnemy(enemy)
+	session.SetGameState(game.GameStateFight)
+}
+
+// run plays all fights of the job and returns the stats of the fights and the played cards.
+func (sim simulator) run(j job) (fightStats, map[string]*cardStats) {
+	stats := fightStats{Deck: j.Deck, Enemy: j.Enemy}
+	cards := map[string]*cardStats{}
+
+	session := game.NewSession(game.WithSeed(j.Seed), game.WithMods(sim.mods))
+	defer session.Close()
+
+	ag := sim.newAgent(j.Seed)
+
+	var ended, won bool
+	var effects int
+	game.Subscribe(session, func(event game.GameEventStateChanged) {
+		if event.From == game.GameStateFight && event.To != game.GameStateFight {
+			ended = true
+			won = event.To != game.GameStateGameOver
+		}
+	})
+	game.Subscribe(session, func(event game.GameEventDamage) {
+		if event.Target == game.PlayerActorID {
+			stats.DamageTaken += event.Damage
+		}
+	})
+	session.Events().SubscribeAll(func(event game.GameEvent) {
+		switch event.(type) {
+		case game.GameEventDamage, game.GameEventHeal, game.GameEventBlockChanged, game.GameEventStatusAdded, game.GameEventStatusStacked, game.GameEventStatusRemoved, game.GameEventCardDrawn:
+			effects += 1
+		}
+	})
+
+	for i := 0; i < sim.fights; i++ {
+		sim.setupFight(session, sim.decks[j.Deck], j.Enemy)
+		ended, won = false, false
+
+		turns := 0
+		for !ended {
+			stats.Errors += drainLuaErrors(session)
+
+			if turns >= sim.maxTurns {
+				stats.Timeouts += 1
+				break
+			}
+
+			obs := agent.Observe(session)
+			if obs.State != game.GameStateFight || len(obs.Legal) == 0 {
+				break
+			}
+
+			action := ag.Act(session, obs)
+			if !obs.IsLegal(action) {
+				break
+			}
+
+			var typeId string
+			if action.Type == agent.ActionCastCard {
+				typeId = obs.Hand[action.Index].TypeID
+			}
+
+			effects = 0
+			if err := agent.Apply(session, action); err != nil {
+				stats.Errors += 1
+				break
+			}
+
+			switch action.Type {
+			case agent.ActionCastCard:
+				if cards[typeId] == nil {
+					cards[typeId] = &cardStats{}
+				}
+				cards[typeId].Played += 1
+				if effects > 0 {
+					cards[typeId].Effective += 1
+				}
+			case agent.ActionEndTurn:
+				turns += 1
+			}
+		}
+		stats.Errors += drainLuaErrors(session)
+
+		stats.Fights += 1
+		stats.Turns += turns
+		if won {
+			stats.Wins += 1
+		} else if ended {
+			stats.Losses += 1
+		}
+	}
+
+	return stats, cards
+}
+
+// drainLuaErrors drains the error channel of the session and returns the amount of errors. The channel is
+// buffered, so it needs to be drained regularly or the session would block on the next error.
+func drainLuaErrors(session *game.Session) int {
+	count := 0
+	for {
+		select {
+		case <-session.LuaErrors():
+			count += 1
+		default:
+			return count
+		}
+	}
+}
+
+func (s fightStats) winRate() float64 {
+	if s.Fights == 0 {
+		return 0
+	}
+	return float64(s.Wins) / float64(s.Fights)
+}
+
+func (s fightStats) lossRate() float64 {
+	if s.Fights == 0 {
+		return 0
+	}
+	return float64(s.Losses) / float64(s.Fights)
+}
+
+func (s fightStats) timeoutRate() float64 {
+	if s.Fights == 0 {
+		return 0
+	}
+	return float64(s.Timeouts) / float64(s.Fights)
+}
+
+func (s fightStats) avgTurns() float64 {
+	if s.Fights == 0 {
+		return 0
+	}
+	return float64(s.Turns) / float64(s.Fights)
+}
+
+func (s fightStats) avgDamageTaken() float64 {
+	if s.Fights == 0 {
+		return 0
+	}
+	return float64(s.DamageTaken) / float64(s.Fights)
+}
+
+func (c cardStats) effectiveRate() float64 {
+	if c.Played == 0 {
+		return 0
+	}
+	return float64(c.Effective) / float64(c.Played)
+}
+
+func formatPercent(v float64) string {
+	return fmt.Sprintf("%.1f%%", v*100)
+}
@@ -4,7 +4,6 @@ import (
 	"flag"
 	"fmt"
 	"github.com/BigJk/end_of_eden/game"
-	"github.com/samber/lo"
 	"math/rand"
 	"os"
 	"runtime/debug"
@@ -61,11 +60,13 @@ func tester(index int, wg *sync.WaitGroup) {
 	defer wg.Done()
 
 	rnd := rand.New(rand.NewSource(seed + int64(index)))
-	opKeys := lo.Keys(Operations)
+	opKeys := sortedKeys(Operations)
 	stack := [][]string{}
+	sessionSeed := int64(0)
 
 	defer func() {
 		if r := recover(); r != nil {
+			fmt.Println("Session Seed :", sessionSeed)
 			fmt.Println(stack)
 			fmt.Println(r)
 			fmt.Println(string(debug.Stack()))
@@ -74,8 +75,9 @@ func tester(index int, wg *sync.WaitGroup) {
 	}()
 
 	for time.Now().Unix() < endTime {
-		s := game.NewSession(game.WithMods(mods))
-		ops := 5 + rand.Intn(1000)
+		sessionSeed = rnd.Int63()
+		s := game.NewSession(game.WithMods(mods), game.WithSeed(sessionSeed))
+		ops := 5 + rnd.Intn(1000)
 		stack = [][]string{}
 		s.SetOnLuaError(func(file string, line int, callback string, typeId string, err error) {
 			fmt.Println("File     :", file)
@@ -87,7 +89,7 @@ func tester(index int, wg *sync.WaitGroup) {
 		})
 
 		for i := 0; i < ops; i++ {
-			next := lo.Shuffle(opKeys)[0]
+			next := Shuffle(rnd, opKeys)[0]
 			stack = append(stack, []string{next, Operations[next](rnd, s)})
 		}
 	}
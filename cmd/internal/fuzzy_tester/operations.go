@@ -5,6 +5,7 @@ import (
 	"github.com/BigJk/end_of_eden/game"
 	"github.com/samber/lo"
 	"math/rand"
+	"sort"
 )
 
 func Shuffle[T any](rnd *rand.Rand, collection []T) []T {
@@ -15,6 +16,13 @@ func Shuffle[T any](rnd *rand.Rand, collection []T) []T {
 	return collection
 }
 
+// sortedKeys returns the sorted keys of a map, so that the order is stable for a given seed.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := lo.Keys(m)
+	sort.Strings(keys)
+	return keys
+}
+
 var Operations = map[string]func(rnd *rand.Rand, s *game.Session) string{
 	"FinishPlayerTurn": func(rnd *rand.Rand, s *game.Session) string {
 		s.FinishPlayerTurn()
@@ -32,25 +40,25 @@ var Operations = map[string]func(rnd *rand.Rand, s *game.Session) string{
 	},
 	"AddActorFromEnemy": func(rnd *rand.Rand, s *game.Session) string {
 		res := s.GetResources()
-		enemyId := Shuffle(rnd, lo.Flatten([][]string{{""}, lo.Keys(res.Enemies)}))[0]
+		enemyId := Shuffle(rnd, lo.Flatten([][]string{{""}, sortedKeys(res.Enemies)}))[0]
 		s.AddActorFromEnemy(enemyId)
 		return fmt.Sprintf("Added enemy '%s'", enemyId)
 	},
 	"SetEvent": func(rnd *rand.Rand, s *game.Session) string {
 		res := s.GetResources()
-		eventId := Shuffle(rnd, lo.Flatten([][]string{{""}, lo.Keys(res.Events)}))[0]
+		eventId := Shuffle(rnd, lo.Flatten([][]string{{""}, sortedKeys(res.Events)}))[0]
 		s.SetEvent(eventId)
 		return fmt.Sprintf("Set event '%s'", eventId)
 	},
 	"SetGameState": func(rnd *rand.Rand, s *game.Session) string {
 		res := s.GetResources()
-		eventId := Shuffle(rnd, lo.Flatten([][]string{{""}, lo.Keys(res.Events)}))[0]
+		eventId := Shuffle(rnd, lo.Flatten([][]string{{""}, sortedKeys(res.Events)}))[0]
 		s.SetGameState(Shuffle(rnd, []game.GameState{game.GameStateGameOver, game.GameStateMerchant, game.GameStateRandom, game.GameStateEvent, game.GameStateFight, game.GameState("")})[0])
 		return fmt.Sprintf("Set event '%s'", eventId)
 	},
 	"FinishEvent": func(rnd *rand.Rand, s *game.Session) string {
 		res := s.GetResources()
-		eventId := Shuffle(rnd, lo.Flatten([][]string{lo.Keys(res.Events)}))[0]
+		eventId := Shuffle(rnd, lo.Flatten([][]string{sortedKeys(res.Events)}))[0]
 		event := res.Events[eventId]
 		choice := rnd.Intn(len(event.Choices) + 1)
 		s.FinishEvent(choice)
@@ -84,31 +92,31 @@ var Operations = map[string]func(rnd *rand.Rand, s *game.Session) string{
 	},
 	"AddCard": func(rnd *rand.Rand, s *game.Session) string {
 		res := s.GetResources()
-		cardId := Shuffle(rnd, lo.Flatten([][]string{{""}, lo.Keys(res.Cards)}))[0]
+		cardId := Shuffle(rnd, lo.Flatten([][]string{{""}, sortedKeys(res.Cards)}))[0]
 		s.GiveCard(cardId, game.PlayerActorID)
 		return fmt.Sprintf("Give '%s' card to player", cardId)
 	},
 	"AddArtifact": func(rnd *rand.Rand, s *game.Session) string {
 		res := s.GetResources()
-		artifactId := Shuffle(rnd, lo.Flatten([][]string{{""}, lo.Keys(res.Artifacts)}))[0]
+		artifactId := Shuffle(rnd, lo.Flatten([][]string{{""}, sortedKeys(res.Artifacts)}))[0]
 		s.GiveArtifact(artifactId, game.PlayerActorID)
 		return fmt.Sprintf("Give '%s' artifact to player", artifactId)
 	},
 	"PlayerBuyCard": func(rnd *rand.Rand, s *game.Session) string {
 		res := s.GetResources()
-		cardId := Shuffle(rnd, lo.Flatten([][]string{{""}, lo.Keys(res.Cards)}))[0]
+		cardId := Shuffle(rnd, lo.Flatten([][]string{{""}, sortedKeys(res.Cards)}))[0]
 		s.PlayerBuyCard(cardId)
 		return fmt.Sprintf("Buy '%s' card as player", cardId)
 	},
 	"PlayerBuyArtifact": func(rnd *rand.Rand, s *game.Session) string {
 		res := s.GetResources()
-		artifactId := Shuffle(rnd, lo.Flatten([][]string{{""}, lo.Keys(res.Artifacts)}))[0]
+		artifactId := Shuffle(rnd, lo.Flatten([][]string{{""}, sortedKeys(res.Artifacts)}))[0]
 		s.PlayerBuyArtifact(artifactId)
 		return fmt.Sprintf("Buy '%s' artifact as player", artifactId)
 	},
 	"AddStatusEffect": func(rnd *rand.Rand, s *game.Session) string {
 		res := s.GetResources()
-		effectId := Shuffle(rnd, lo.Flatten([][]string{{""}, lo.Keys(res.StatusEffects)}))[0]
+		effectId := Shuffle(rnd, lo.Flatten([][]string{{""}, sortedKeys(res.StatusEffects)}))[0]
 		stacks := rnd.Intn(10)
 		s.GiveStatusEffect(effectId, game.PlayerActorID, stacks)
 		return fmt.Sprintf("Give '%s' status effect with %d stacks to player", effectId, stacks)
@@ -0,0 +1,328 @@
+package main
+
+import (
+	"fmt"
+	"github.com/BigJk/end_of_eden/game"
+	"github.com/BigJk/end_of_eden/internal/fs"
+	"github.com/BigJk/end_of_eden/system/localization"
+	"github.com/samber/lo"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Severity of a finding. Errors make the linter exit with a non-zero exit code.
+type Severity string
+
+const (
+	SeverityError   = Severity("ERROR")
+	SeverityWarning = Severity("WARN")
+)
+
+// finding is a single problem found by the linter.
+type finding struct {
+	Severity Severity
+	Kind     string
+	ID       string
+	Message  string
+}
+
+type linter struct {
+	session   *game.Session
+	resources *game.ResourcesManager
+	findings  []finding
+}
+
+func (l *linter) error(kind string, id string, format string, args ...any) {
+	l.findings = append(l.findings, finding{Severity: SeverityError, Kind: kind, ID: id, Message: fmt.Sprintf(format, args...)})
+}
+
+func (l *linter) warn(kind string, id string, format string, args ...any) {
+	l.findings = append(l.findings, finding{Severity: SeverityWarning, Kind: kind, ID: id, Message: fmt.Sprintf(format, args...)})
+}
+
+// sortedKeys returns the keys of a resource map sorted, so that the findings are stable between runs.
+func sortedKeys[T any](m map[string]T) []string {
+	keys := lo.Keys(m)
+	sort.Strings(keys)
+	return keys
+}
+
+func (l *linter) checkCallbacks(kind string, id string, callbacks []string) {
+	sort.Strings(callbacks)
+	for _, name := range callbacks {
+		if !lo.Contains(game.Callbacks, name) {
+			l.error(kind, id, "unknown callback '%s'", name)
+		}
+	}
+}
+
+func (l *linter) checkPrice(kind string, id string, price int) {
+	if price == 0 {
+		l.warn(kind, id, "price is 0 so the merchant offers it for free, use -1 to exclude it from the merchant")
+	}
+}
+
+//
+// Static checks
+//
+
+func (l *linter) checkArtifacts() {
+	for _, id := range sortedKeys(l.resources.Artifacts) {
+		artifact := l.resources.Artifacts[id]
+
+		if len(artifact.Name) == 0 {
+			l.warn("artifact", id, "missing name")
+		}
+		l.checkPrice("artifact", id, artifact.Price)
+		l.checkCallbacks("artifact", id, lo.Keys(artifact.Callbacks))
+	}
+}
+
+func (l *linter) checkCards() {
+	for _, id := range sortedKeys(l.resources.Cards) {
+		card := l.resources.Cards[id]
+		hasCast := card.Callbacks[game.CallbackOnCast].Present()
+
+		if len(card.Name) == 0 {
+			l.warn("card", id, "missing name")
+		}
+		if card.NeedTarget && !hasCast {
+			l.error("card", id, "needs a target but has no on_cast callback")
+		} else if !hasCast {
+			l.warn("card", id, "has no on_cast callback and can't be played")
+		}
+		if card.PointCost < 0 {
+			l.error("card", id, "negative point cost %d", card.PointCost)
+		}
+		if card.MaxLevel < 0 {
+			l.error("card", id, "negative max level %d", card.MaxLevel)
+		}
+		l.checkPrice("card", id, card.Price)
+		l.checkCallbacks("card", id, lo.Keys(card.Callbacks))
+	}
+}
+
+func (l *linter) checkEvents() {
+	for _, id := range sortedKeys(l.resources.Events) {
+		event := l.resources.Events[id]
+
+		if len(event.Choices) == 0 {
+			if event.OnEnd == nil {
+				l.error("event", id, "has no choices and no on_end callback, so it can't be finished")
+			} else {
+				l.warn("event", id, "has no choices")
+			}
+		}
+		for i, choice := range event.Choices {
+			if len(choice.Description) == 0 && choice.DescriptionFn == nil {
+				l.error("event", id, "choice %d has no description", i+1)
+			}
+		}
+	}
+}
+
+func (l *linter) checkStatusEffects() {
+	for _, id := range sortedKeys(l.resources.StatusEffects) {
+		status := l.resources.StatusEffects[id]
+
+		if len(status.Name) == 0 {
+			l.warn("status_effect", id, "missing name")
+		}
+		switch status.Decay {
+		case game.DecayOne, game.DecayAll:
+			if status.Rounds <= 0 {
+				l.error("status_effect", id, "decay is %s but rounds is %d", status.Decay, status.Rounds)
+			}
+		case game.DecayNone:
+		default:
+			l.error("status_effect", id, "unknown decay '%s'", status.Decay)
+		}
+		l.checkCallbacks("status_effect", id, lo.Keys(status.Callbacks))
+	}
+}
+
+func (l *linter) checkEnemies() {
+	for _, id := range sortedKeys(l.resources.Enemies) {
+		enemy := l.resources.Enemies[id]
+
+		if len(enemy.Name) == 0 {
+			l.warn("enemy", id, "missing name")
+		}
+		if enemy.Intend == nil {
+			l.error("enemy", id, "has no intend callback")
+		}
+		if enemy.InitialHP <= 0 {
+			l.error("enemy", id, "initial hp is %d", enemy.InitialHP)
+		}
+		if enemy.MaxHP < enemy.InitialHP {
+			l.error("enemy", id, "max hp %d is lower than initial hp %d", enemy.MaxHP, enemy.InitialHP)
+		}
+		l.checkCallbacks("enemy", id, lo.Keys(enemy.Callbacks))
+	}
+}
+
+func (l *linter) checkStoryTellers() {
+	for _, id := range sortedKeys(l.resources.StoryTeller) {
+		teller := l.resources.StoryTeller[id]
+
+		if teller.Active == nil {
+			l.error("story_teller", id, "has no active callback")
+		}
+		if teller.Decide == nil {
+			l.error("story_teller", id, "has no decide callback")
+		}
+	}
+}
+
+// findByTagsRegex matches the find_*_by_tags helpers with a literal tag list.
+var findByTagsRegex = regexp.MustCompile(`find_(artifacts|cards|events)_by_tags\(\s*\{([^}]*)}`)
+
+// checkTags scans the lua scripts for tags that are searched by the find_*_by_tags helpers, e.g. in story
+// tellers, and reports tags that no content has.
+func (l *linter) checkTags(folders []string) {
+	tags := map[string][]string{
+		"artifacts": lo.FlatMap(lo.Values(l.resources.Artifacts), func(item *game.Artifact, index int) []string { return item.Tags }),
+		"cards":     lo.FlatMap(lo.Values(l.resources.Cards), func(item *game.Card, index int) []string { return item.Tags }),
+		"events":    lo.FlatMap(lo.Values(l.resources.Events), func(item *game.Event, index int) []string { return item.Tags }),
+	}
+
+	for _, folder := range folders {
+		_ = fs.Walk(folder, func(path string, isDir bool) error {
+			if isDir || !strings.HasSuffix(path, ".lua") || strings.Contains(path, "definitions") {
+				return nil
+			}
+
+			data, err := fs.ReadFile(path)
+			if err != nil {
+				l.error("script", path, "can't be read: %s", err)
+				return nil
+			}
+
+			for i, line := range strings.Split(string(data), "\n") {
+				for _, match := range findByTagsRegex.FindAllStringSubmatch(line, -1) {
+					for _, tag := range strings.Split(match[2], ",") {
+						tag = strings.Trim(strings.TrimSpace(tag), `"'`)
+						if len(tag) > 0 && !lo.Contains(tags[match[1]], tag) {
+							l.error("tag", tag, "used at %s:%d but no %s have it", path, i+1, match[1])
+						}
+					}
+				}
+			}
+
+			return nil
+		})
+	}
+}
+
+//
+// Dynamic checks
+//
+
+// checkLuaErrors reports all lua errors that happened since the last call as errors of the given content.
+func (l *linter) checkLuaErrors(kind string, id string) {
+	for {
+		select {
+		case err := <-l.session.LuaErrors():
+			l.error(kind, id, "lua error in %s: %s", err.Callback, stripTraceback(err.Err.Error()))
+		default:
+			return
+		}
+	}
+}
+
+// checkRegisterErrors reports the content that failed to be registered based on the session log.
+func (l *linter) checkRegisterErrors(logs string) {
+	for _, line := range strings.Split(logs, "\n") {
+		if strings.HasPrefix(line, "Error while luaRegister") {
+			l.error("register", "", "%s", line)
+		}
+	}
+}
+
+// checkDynamic calls the callbacks that render content, e.g. card states or enemy intends, and reports
+// lua errors they produce.
+func (l *linter) checkDynamic() {
+	s := l.session
+	l.checkLuaErrors("session", "")
+
+	s.RemoveNonPlayer()
+	s.SetGameState(game.GameStateFight)
+	l.checkLuaErrors("session", "")
+
+	for _, id := range sortedKeys(l.resources.Cards) {
+		guid := s.GiveCard(id, game.PlayerActorID)
+		s.GetCardState(guid)
+		s.RemoveCard(guid)
+		l.checkLuaErrors("card", id)
+	}
+
+	for _, id := range sortedKeys(l.resources.StatusEffects) {
+		guid := s.GiveStatusEffect(id, game.PlayerActorID, 1)
+		s.GetStatusEffectState(guid)
+		s.RemoveStatusEffect(guid)
+		l.checkLuaErrors("status_effect", id)
+	}
+
+	for _, id := range sortedKeys(l.resources.Enemies) {
+		guid := s.AddActorFromEnemy(id)
+		s.GetActorIntend(guid)
+		s.RemoveActor(guid)
+		l.checkLuaErrors("enemy", id)
+	}
+
+	for _, id := range sortedKeys(l.resources.Events) {
+		s.SetEvent(id)
+		for i := range l.resources.Events[id].Choices {
+			s.GetEventChoiceDescription(i)
+		}
+		s.SetEvent("")
+		s.RemoveNonPlayer()
+		l.checkLuaErrors("event", id)
+	}
+
+	for _, id := range sortedKeys(l.resources.StoryTeller) {
+		if teller := l.resources.StoryTeller[id]; teller.Active != nil {
+			res, err := teller.Active.Call(game.CreateContext("type_id", id))
+			if err != nil {
+				l.error("story_teller", id, "lua error in active: %s", stripTraceback(err.Error()))
+			} else if _, ok := res.(float64); !ok {
+				l.error("story_teller", id, "active returned %T instead of a number", res)
+			}
+		}
+		l.checkLuaErrors("story_teller", id)
+	}
+}
+
+// checkLocalization reports keys that were requested during loading and the dynamic checks, but have no
+// translation. Keys without a default and without an english translation would be shown as raw key.
+func (l *linter) checkLocalization() {
+	tracked := localization.Global.Tracked()
+	keys := sortedKeys(tracked)
+
+	for _, key := range keys {
+		if !tracked[key] && !localization.Global.Has("en", key) {
+			l.error("localization", key, "has no default and no 'en' translation")
+		}
+	}
+
+	locales := localization.Global.GetLocales()
+	sort.Strings(locales)
+	for _, locale := range locales {
+		if locale == "en" {
+			continue
+		}
+
+		missing := lo.Filter(keys, func(key string, index int) bool {
+			return !localization.Global.Has(locale, key)
+		})
+		for _, key := range missing {
+			l.warn("localization", key, "missing in locale '%s'", locale)
+		}
+	}
+}
+
+// stripTraceback cuts the stack traceback from lua error messages.
+func stripTraceback(s string) string {
+	return strings.TrimSpace(strings.SplitN(s, "stack traceback:", 2)[0])
+}
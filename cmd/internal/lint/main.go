@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"github.com/BigJk/end_of_eden/game"
+	"github.com/BigJk/end_of_eden/system/gen"
+	"github.com/BigJk/end_of_eden/system/gen/faces"
+	"github.com/BigJk/end_of_eden/system/localization"
+	"github.com/BigJk/end_of_eden/ui/style"
+	"github.com/samber/lo"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func main() {
+	modsFlag := flag.String("mods", "", "mods to load (e.g. 'my-mod,test-mod,another-mod')")
+	strict := flag.Bool("strict", false, "exit with a non-zero exit code on warnings too")
+	help := flag.Bool("help", false, "show help")
+	flag.Parse()
+
+	if *help {
+		fmt.Println("End Of Eden :: Lint")
+		fmt.Println("The linter checks all registered content for mistakes that would otherwise only show up at runtime.")
+		fmt.Println()
+		flag.PrintDefaults()
+		return
+	}
+
+	mods := lo.Map(strings.Split(*modsFlag, ","), func(item string, index int) string {
+		return strings.TrimSpace(item)
+	})
+	mods = lo.Filter(mods, func(item string, index int) bool {
+		return len(item) > 0
+	})
+
+	if err := faces.InitGlobal("./assets/gen/faces"); err != nil {
+		panic(err)
+	}
+	gen.InitGen()
+
+	// Track the requested localization keys before any content is loaded.
+	localization.Global.Track()
+	if err := localization.Global.AddFolder("./assets/locals"); err != nil {
+		panic(err)
+	}
+
+	logs := &bytes.Buffer{}
+	session := game.NewSession(game.WithMods(mods), game.WithLogging(log.New(logs, "", 0)))
+	defer session.Close()
+
+	l := &linter{session: session, resources: session.GetResources()}
+
+	l.checkLuaErrors("mod", "")
+	l.checkRegisterErrors(logs.String())
+	l.checkArtifacts()
+	l.checkCards()
+	l.checkEvents()
+	l.checkStatusEffects()
+	l.checkEnemies()
+	l.checkStoryTellers()
+	l.checkTags(append([]string{"./assets/scripts"}, lo.Map(mods, func(mod string, index int) string {
+		return filepath.Join("./mods", mod)
+	})...))
+	l.checkDynamic()
+	l.checkLocalization()
+
+	errors := 0
+	warnings := 0
+	for _, f := range l.findings {
+		line := fmt.Sprintf("%-5s %-13s %-32s %s", f.Severity, f.Kind, f.ID, f.Message)
+		if f.Severity == SeverityError {
+			errors += 1
+			fmt.Println(style.RedText.Render(line))
+		} else {
+			warnings += 1
+			fmt.Println(line)
+		}
+	}
+
+	fmt.Println()
+	if errors > 0 || *strict && warnings > 0 {
+		fmt.Println(style.RedText.Render(fmt.Sprintf("Lint failed with %d errors and %d warnings!", errors, warnings)))
+		session.Close()
+		os.Exit(1)
+	}
+	fmt.Println(style.GreenText.Render(fmt.Sprintf("Lint passed with %d warnings!", warnings)))
+}
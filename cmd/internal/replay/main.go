@@ -0,0 +1,72 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/BigJk/end_of_eden/game"
+	"github.com/BigJk/end_of_eden/internal/git"
+	"github.com/BigJk/end_of_eden/system/gen"
+	"github.com/BigJk/end_of_eden/system/gen/faces"
+	"github.com/BigJk/end_of_eden/ui/style"
+	"log"
+	"os"
+)
+
+func main() {
+	fileFlag := flag.String("file", "", "replay file to play")
+	verbose := flag.Bool("verbose", false, "log the session to stdout")
+	help := flag.Bool("help", false, "show help")
+	flag.Parse()
+
+	if *help || len(*fileFlag) == 0 {
+		fmt.Println("End Of Eden :: Replay")
+		fmt.Println("The replay re-executes a recorded session and checks if it still results in the same state.")
+		fmt.Println()
+		flag.PrintDefaults()
+		return
+	}
+
+	replay, err := game.LoadReplay(*fileFlag)
+	if err != nil {
+		fmt.Println(style.RedText.Render("Can't load replay: " + err.Error()))
+		os.Exit(1)
+	}
+
+	if replay.GameVersion != "" && replay.GameVersion != git.Tag {
+		fmt.Printf("Warning: replay was recorded with game version %s\n", replay.GameVersion)
+	}
+
+	// The generators are part of the session randomness, so they need to be loaded like in the game.
+	if err := faces.InitGlobal("./assets/gen/faces"); err != nil {
+		panic(err)
+	}
+	gen.InitGen()
+
+	fmt.Printf("--- Playing %d actions with seed %d...\n", len(replay.Actions), replay.Seed)
+
+	var options []func(s *game.Session)
+	if *verbose {
+		options = append(options, game.WithLogging(log.New(os.Stdout, "SESSION ", log.Ltime)))
+	}
+	options = append(options, game.WithOnLuaError(func(file string, line int, callback string, typeId string, err error) {
+		fmt.Printf("Lua error in %s:%d callback=%s type=%s: %s\n", file, line, callback, typeId, err)
+	}), func(s *game.Session) {
+		// Drain the lua errors, they are already reported by the callback above.
+		go func() {
+			for range s.LuaErrors() {
+			}
+		}()
+	})
+
+	session := replay.Play(options...)
+	err = replay.Check(session)
+	session.Close()
+
+	if err != nil {
+		fmt.Println("\n--- " + style.RedText.Render("Replay diverged!"))
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	fmt.Println("\n--- " + style.GreenText.Render("Replay matches!"))
+}
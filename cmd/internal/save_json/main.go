@@ -0,0 +1,61 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/BigJk/end_of_eden/game"
+	"os"
+)
+
+func main() {
+	dirFlag := flag.String("dir", "./saves", "directory of the save slots")
+	slotFlag := flag.String("slot", "", "id of the save slot")
+	importFlag := flag.String("import", "", "json file to import into the save slot")
+	help := flag.Bool("help", false, "show help")
+	flag.Parse()
+
+	if *help || len(*slotFlag) == 0 {
+		fmt.Println("End Of Eden :: Save JSON")
+		fmt.Println("Exports a save slot as json to stdout or imports a json file into a save slot.")
+		fmt.Println()
+		flag.PrintDefaults()
+		return
+	}
+
+	slots := game.NewSaveSlots(*dirFlag)
+
+	if len(*importFlag) > 0 {
+		data, err := os.ReadFile(*importFlag)
+		if err != nil {
+			fmt.Println("Can't read file:", err)
+			os.Exit(1)
+		}
+
+		var save game.SavedState
+		if err := json.Unmarshal(data, &save); err != nil {
+			fmt.Println("Can't decode json:", err)
+			os.Exit(1)
+		}
+
+		if err := slots.SaveState(*slotFlag, save); err != nil {
+			fmt.Println("Can't write save slot:", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	save, err := slots.LoadState(*slotFlag)
+	if err != nil {
+		fmt.Println("Can't read save slot:", err)
+		os.Exit(1)
+	}
+
+	data, err := json.MarshalIndent(save, "", "\t")
+	if err != nil {
+		fmt.Println("Can't encode json:", err)
+		os.Exit(1)
+	}
+
+	fmt.Println(string(data))
+}
@@ -25,7 +25,7 @@ type TestArgs struct {
 // ApplyArgs applies the test setup to the game based on the given cli arguments.
 func (ta *TestArgs) ApplyArgs(baseModel tea.Model, zones *zone.Manager) tea.Model {
 	if len(*ta.Cards) > 0 || len(*ta.Enemies) > 0 || len(*ta.Artifacts) > 0 || len(*ta.GameState) > 0 || len(*ta.Event) > 0 {
-		session := game.NewSession(game.WithLogging(log.Default()), game.WithMods(settings.GetStrings("mods")), lo.Ternary(os.Getenv("EOE_DEBUG") == "1", game.WithDebugEnabled(8272), nil))
+		session := game.NewSession(game.WithLogging(log.Default()), game.WithMods(settings.GetStrings("mods")), lo.Ternary(os.Getenv("EOE_DEBUG") == "1", game.WithDebugConfig(game.DebugConfigFromEnv(8272)), nil), lo.Ternary(os.Getenv("EOE_HOT_RELOAD") == "1", game.WithHotReload(), nil), lo.Ternary(os.Getenv("EOE_PROFILE") == "1", game.WithProfiling(), nil))
 		session.SetGameState(game.GameStateFight)
 		session.GetPlayer().Cards.Clear()
 
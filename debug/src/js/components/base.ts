@@ -5,6 +5,11 @@ import "@vaadin/app-layout/vaadin-drawer-toggle.js";
 import "@vaadin/tabs";
 
 const tabs = [
+  {
+    title: "Session",
+    link: "/session",
+    icon: "lumo:view-list",
+  },
   {
     title: "Registered",
     link: "/registered",
@@ -15,6 +20,11 @@ const tabs = [
     link: "/state-vis",
     icon: "lumo:eye",
   },
+  {
+    title: "Profiler",
+    link: "/profile",
+    icon: "lumo:clock",
+  },
 ];
 
 const getTabIndex = (path: string): number => {
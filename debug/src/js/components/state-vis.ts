@@ -1,7 +1,12 @@
 import m from "mithril";
 import L from "leaflet";
 
+// Interval in ms in which the visualization is refreshed.
+const refreshInterval = 2000;
+
 export default (): m.Component => {
+  let timer: number | undefined;
+
   return {
     oncreate: ({ dom }) => {
       let map = L.map(dom as HTMLElement, {
@@ -15,7 +20,15 @@ export default (): m.Component => {
           [40.773941, -74.12544],
         ];
 
-      L.imageOverlay(imageUrl, imageBounds).addTo(map);
+      let overlay = L.imageOverlay(imageUrl, imageBounds).addTo(map);
+
+      // The timestamp avoids that the browser serves the old svg from cache.
+      timer = window.setInterval(() => {
+        overlay.setUrl(imageUrl + "?t=" + Date.now());
+      }, refreshInterval);
+    },
+    onremove: () => {
+      window.clearInterval(timer);
     },
     view: () => m("div", { style: { height: "800px" } }),
   };
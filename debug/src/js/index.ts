@@ -10,9 +10,13 @@ import "leaflet/dist/leaflet.css";
 import Home from "./pages/home";
 import Registered from "./pages/registered";
 import StateVis from "./pages/state-vis";
+import Profile from "./pages/profile";
+import Session from "./pages/session";
 
 m.route(document.getElementById("app")!, "/", {
   "/": Home,
   "/registered": Registered,
   "/state-vis": StateVis,
+  "/profile": Profile,
+  "/session": Session,
 });
@@ -0,0 +1,108 @@
+import m from "mithril";
+
+import Base from "src/js/components/base";
+
+import "active-table/dist/activeTable.js";
+
+type ProfileEntry = {
+  key: string;
+  calls: number;
+  total: number;
+  max: number;
+  self: number;
+  allocs: number;
+};
+
+// Durations are sent as nanoseconds.
+const ms = (ns: number) => (ns / 1e6).toFixed(3);
+
+const kb = (bytes: number) => (bytes / 1024).toFixed(1);
+
+export default (): m.Component => {
+  const data = {
+    entries: null,
+    error: null,
+  } as {
+    entries: ProfileEntry[] | null;
+    error: string | null;
+  };
+
+  const fetchProfile = () => {
+    m.request<ProfileEntry[]>({
+      method: "GET",
+      url: "/api/profile",
+    })
+      .then((entries) => {
+        data.entries = entries;
+        data.error = null;
+      })
+      .catch((err) => {
+        data.error = err.response ?? "Can't fetch profile";
+      });
+  };
+
+  const resetProfile = () => {
+    m.request({
+      method: "DELETE",
+      url: "/api/profile",
+    }).then(fetchProfile);
+  };
+
+  const toTable = (entries: ProfileEntry[]) => {
+    return [
+      ["Callback", "Calls", "Self (ms)", "Total (ms)", "Avg (ms)", "Max (ms)", "Allocs (KB)"],
+      ...entries.map((e) => [
+        e.key,
+        e.calls,
+        ms(e.self),
+        ms(e.total),
+        ms(e.total / e.calls),
+        ms(e.max),
+        kb(e.allocs),
+      ]),
+    ];
+  };
+
+  return {
+    oninit: () => {
+      fetchProfile();
+    },
+    view: () => {
+      return m(
+        Base,
+        {
+          title: "Profiler",
+          subtitle: "Time and allocations of content callbacks, sorted by self time",
+        },
+        [
+          m(".mb3", [
+            m("sl-button.mr2", { onclick: fetchProfile }, "Refresh"),
+            m("sl-button", { onclick: resetProfile }, "Reset"),
+          ]),
+          data.error
+            ? m("sl-alert[open]", { variant: "warning" }, data.error)
+            : data.entries === null
+              ? null
+              : m("active-table", {
+                  tableStyle: {
+                    width: "100%",
+                  },
+                  rowDropdown: {
+                    isMoveAvailable: false,
+                    isInsertUpAvailable: false,
+                    isInsertDownAvailable: false,
+                    isDeleteAvailable: false,
+                    canEditHeaderRow: false,
+                  },
+                  pagination: true,
+                  isCellTextEditable: false,
+                  isHeaderTextEditable: false,
+                  displayAddNewRow: false,
+                  displayAddNewColumn: false,
+                  content: toTable(data.entries),
+                }),
+        ],
+      );
+    },
+  };
+};
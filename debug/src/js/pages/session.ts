@@ -0,0 +1,68 @@
+import m from "mithril";
+
+import Base from "src/js/components/base";
+
+// Interval in ms in which the session is refreshed.
+const refreshInterval = 2000;
+
+const endpoints = ["session", "fight", "merchant", "actors", "history", "logs", "errors"];
+
+export default (): m.Component => {
+  const data = {} as Record<string, any>;
+  let error: string | null = null;
+  let timer: number | undefined;
+
+  const fetchAll = () => {
+    Promise.all(
+      endpoints.map((e) =>
+        m.request({
+          method: "GET",
+          url: "/api/" + e,
+        }),
+      ),
+    )
+      .then((res) => {
+        res.forEach((r, i) => {
+          data[endpoints[i]] = r;
+        });
+        error = null;
+      })
+      .catch(() => {
+        error = "Can't fetch session, is the game still running?";
+      });
+  };
+
+  const json = (title: string, value: any) => {
+    return m("sl-details", { summary: title }, m("pre.f7.ma0", JSON.stringify(value, null, 2)));
+  };
+
+  return {
+    oninit: () => {
+      fetchAll();
+      timer = window.setInterval(fetchAll, refreshInterval);
+    },
+    onremove: () => {
+      window.clearInterval(timer);
+    },
+    view: () => {
+      return m(
+        Base,
+        {
+          title: "Session",
+          subtitle: "Live state of the running session, refreshed every few seconds",
+        },
+        error
+          ? m("sl-alert[open]", { variant: "warning" }, error)
+          : [
+              json("Session", data.session),
+              json("Fight", data.fight),
+              json("Merchant", data.merchant),
+              json("Actors", data.actors),
+              json("Event History", data.history),
+              json("Logs", data.logs),
+              json("Lua Errors", data.errors),
+            ],
+      );
+    },
+  };
+};
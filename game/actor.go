@@ -17,6 +17,7 @@ type Actor struct {
 	HP            int
 	MaxHP         int
 	Gold          int
+	Block         int
 	Artifacts     *StringSet
 	Cards         *StringSet
 	StatusEffects *StringSet
@@ -0,0 +1,250 @@
+// Package agent contains a gym-style interface to let programs play the game. An Agent gets an Observation
+// of the session and decides on the next Action. The Runner applies the actions to the session until the
+// run is over, which makes it possible to simulate lots of runs to balance the game content.
+package agent
+
+import (
+	"errors"
+	"fmt"
+	"github.com/BigJk/end_of_eden/game"
+	"github.com/samber/lo"
+)
+
+// Agent decides on the next action based on the observation of a session. The returned action has to be
+// one of the legal actions of the observation.
+type Agent interface {
+	Act(session *game.Session, obs Observation) Action
+}
+
+// ActionType represents the type of action an agent can take.
+type ActionType string
+
+const (
+	ActionCastCard      = ActionType("CAST_CARD")
+	ActionEndTurn       = ActionType("END_TURN")
+	ActionChooseEvent   = ActionType("CHOOSE_EVENT")
+	ActionBuyCard       = ActionType("BUY_CARD")
+	ActionBuyArtifact   = ActionType("BUY_ARTIFACT")
+	ActionLeaveMerchant = ActionType("LEAVE_MERCHANT")
+)
+
+// Action is a single action of the player. Index is the hand index for CAST_CARD and the choice for
+// CHOOSE_EVENT, Target the guid of the target actor and TypeID the ware that should be bought.
+type Action struct {
+	Type   ActionType `json:"type"`
+	Index  int        `json:"index,omitempty"`
+	Target string     `json:"target,omitempty"`
+	TypeID string     `json:"type_id,omitempty"`
+}
+
+func (a Action) String() string {
+	switch a.Type {
+	case ActionCastCard:
+		return fmt.Sprintf("%s %d -> %s", a.Type, a.Index, lo.Ternary(len(a.Target) > 0, a.Target, "none"))
+	case ActionChooseEvent:
+		return fmt.Sprintf("%s %d", a.Type, a.Index)
+	case ActionBuyCard, ActionBuyArtifact:
+		return fmt.Sprintf("%s %s", a.Type, a.TypeID)
+	}
+	return string(a.Type)
+}
+
+// StatusObservation is a status effect of an actor.
+type StatusObservation struct {
+	GUID       string
+	TypeID     string
+	Stacks     int
+	RoundsLeft int
+}
+
+// ActorObservation is the player or an opponent.
+type ActorObservation struct {
+	GUID          string
+	TypeID        string
+	HP            int
+	MaxHP         int
+	Gold          int
+	Intend        string
+	StatusEffects []StatusObservation
+}
+
+// CardObservation is a card in the hand of the player.
+type CardObservation struct {
+	Index      int
+	GUID       string
+	TypeID     string
+	PointCost  int
+	NeedTarget bool
+	Castable   bool
+}
+
+// WareObservation is a card or artifact the merchant offers.
+type WareObservation struct {
+	TypeID string
+	Price  int
+}
+
+// Observation is the part of the session state an agent needs to decide on the next action.
+type Observation struct {
+	State         game.GameState
+	StagesCleared int
+	Round         int
+	Points        int
+	Player        ActorObservation
+	Opponents     []ActorObservation
+	Hand          []CardObservation
+	EventID       string
+	EventChoices  []string
+	MerchantCards []WareObservation
+	MerchantArts  []WareObservation
+
+	// Legal contains all actions that are allowed in the current state.
+	Legal []Action
+}
+
+// Observe creates the observation of the session.
+func Observe(session *game.Session) Observation {
+	fight := session.GetFight()
+
+	obs := Observation{
+		State:         session.GetGameState(),
+		StagesCleared: session.GetStagesCleared(),
+		Round:         fight.Round,
+		Points:        fight.CurrentPoints,
+		Player:        observeActor(session, session.GetPlayer()),
+	}
+
+	switch obs.State {
+	case game.GameStateFight:
+		obs.Opponents = lo.Map(session.GetOpponents(game.PlayerActorID), func(actor game.Actor, index int) ActorObservation {
+			return observeActor(session, actor)
+		})
+
+		obs.Hand = lo.Map(fight.Hand, func(guid string, index int) CardObservation {
+			card, instance := session.GetCard(guid)
+			if card == nil {
+				return CardObservation{Index: index, GUID: guid}
+			}
+
+			return CardObservation{
+				Index:      index,
+				GUID:       guid,
+				TypeID:     instance.TypeID,
+				PointCost:  card.PointCost,
+				NeedTarget: card.NeedTarget,
+				Castable:   card.Callbacks[game.CallbackOnCast].Present() && !card.Unplayable && card.PointCost <= fight.CurrentPoints,
+			}
+		})
+
+		for _, card := range obs.Hand {
+			if !card.Castable {
+				continue
+			}
+
+			if card.NeedTarget {
+				for _, opponent := range obs.Opponents {
+					obs.Legal = append(obs.Legal, Action{Type: ActionCastCard, Index: card.Index, Target: opponent.GUID})
+				}
+			} else {
+				obs.Legal = append(obs.Legal, Action{Type: ActionCastCard, Index: card.Index})
+			}
+		}
+		obs.Legal = append(obs.Legal, Action{Type: ActionEndTurn})
+	case game.GameStateEvent:
+		obs.EventID = session.GetEventID()
+		if event := session.GetEvent(); event != nil {
+			obs.EventChoices = lo.Map(event.Choices, func(choice game.EventChoice, index int) string {
+				return session.GetEventChoiceDescription(index)
+			})
+		}
+
+		// Events without choices are finished by their OnEnd callback.
+		if len(obs.EventChoices) == 0 {
+			obs.Legal = append(obs.Legal, Action{Type: ActionChooseEvent, Index: -1})
+		}
+		for i := range obs.EventChoices {
+			obs.Legal = append(obs.Legal, Action{Type: ActionChooseEvent, Index: i})
+		}
+	case game.GameStateMerchant:
+		merchant := session.GetMerchant()
+
+		obs.MerchantCards = lo.Map(merchant.Cards, func(typeId string, index int) WareObservation {
+			ware := WareObservation{TypeID: typeId}
+			if card, _ := session.GetCard(typeId); card != nil {
+				ware.Price = session.MerchantPrice(card.Price)
+			}
+			return ware
+		})
+		obs.MerchantArts = lo.Map(merchant.Artifacts, func(typeId string, index int) WareObservation {
+			ware := WareObservation{TypeID: typeId}
+			if art, _ := session.GetArtifact(typeId); art != nil {
+				ware.Price = session.MerchantPrice(art.Price)
+			}
+			return ware
+		})
+
+		for _, ware := range obs.MerchantCards {
+			if ware.Price <= obs.Player.Gold {
+				obs.Legal = append(obs.Legal, Action{Type: ActionBuyCard, TypeID: ware.TypeID})
+			}
+		}
+		for _, ware := range obs.MerchantArts {
+			if ware.Price <= obs.Player.Gold {
+				obs.Legal = append(obs.Legal, Action{Type: ActionBuyArtifact, TypeID: ware.TypeID})
+			}
+		}
+		obs.Legal = append(obs.Legal, Action{Type: ActionLeaveMerchant})
+	}
+
+	return obs
+}
+
+// IsLegal checks if the action is one of the legal actions of the observation.
+func (o Observation) IsLegal(action Action) bool {
+	return lo.Contains(o.Legal, action)
+}
+
+// Apply applies the action to the session.
+func Apply(session *game.Session, action Action) error {
+	switch action.Type {
+	case ActionCastCard:
+		return session.PlayerCastHand(action.Index, action.Target)
+	case ActionEndTurn:
+		session.FinishPlayerTurn()
+	case ActionChooseEvent:
+		session.FinishEvent(action.Index)
+	case ActionBuyCard:
+		if !session.PlayerBuyCard(action.TypeID) {
+			return errors.New("can't buy card")
+		}
+	case ActionBuyArtifact:
+		if !session.PlayerBuyArtifact(action.TypeID) {
+			return errors.New("can't buy artifact")
+		}
+	case ActionLeaveMerchant:
+		session.LeaveMerchant()
+	default:
+		return fmt.Errorf("unknown action type '%s'", action.Type)
+	}
+	return nil
+}
+
+func observeActor(session *game.Session, actor game.Actor) ActorObservation {
+	return ActorObservation{
+		GUID:   actor.GUID,
+		TypeID: actor.TypeID,
+		HP:     actor.HP,
+		MaxHP:  actor.MaxHP,
+		Gold:   actor.Gold,
+		Intend: lo.Ternary(actor.GUID != game.PlayerActorID, session.GetActorIntend(actor.GUID), ""),
+		StatusEffects: lo.Map(session.GetActorStatusEffects(actor.GUID), func(guid string, index int) StatusObservation {
+			instance := session.GetStatusEffectInstance(guid)
+			return StatusObservation{
+				GUID:       guid,
+				TypeID:     instance.TypeID,
+				Stacks:     instance.Stacks,
+				RoundsLeft: instance.RoundsLeft,
+			}
+		}),
+	}
+}
@@ -0,0 +1,100 @@
+package agent
+
+import (
+	"github.com/BigJk/end_of_eden/game"
+	"github.com/BigJk/end_of_eden/system/gen"
+	"github.com/BigJk/end_of_eden/system/gen/faces"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMain(m *testing.M) {
+	// The runs need the base game content, which is loaded relative to the repository root.
+	if err := os.Chdir("../.."); err != nil {
+		panic(err)
+	}
+
+	if err := faces.InitGlobal("./assets/gen/faces"); err != nil {
+		panic(err)
+	}
+	gen.InitGen()
+
+	os.Exit(m.Run())
+}
+
+func TestRunner(t *testing.T) {
+	agents := map[string]func() Agent{
+		"Random": func() Agent { return NewRandomAgent(1) },
+		"Greedy": func() Agent { return NewGreedyAgent() },
+	}
+
+	for name, agent := range agents {
+		t.Run(name, func(t *testing.T) {
+			run := func() Result {
+				session := game.NewSession(game.WithSeed(42))
+				defer session.Close()
+
+				return Runner{MaxStages: 2}.Run(session, agent())
+			}
+
+			res := run()
+			assert.NoError(t, res.Err)
+			assert.False(t, res.Aborted)
+			assert.Greater(t, res.Steps, 0)
+			assert.True(t, res.FinalState == game.GameStateGameOver || res.StagesCleared >= 2)
+
+			// Same seed and agent result in the same run. The lua errors contain stack traces, so only
+			// their amount is compared.
+			again := run()
+			assert.Len(t, again.LuaErrors, len(res.LuaErrors))
+			res.LuaErrors, again.LuaErrors = nil, nil
+			assert.Equal(t, res, again)
+		})
+	}
+}
+
+func TestVictory(t *testing.T) {
+	profile := filepath.Join(t.TempDir(), "profile.json")
+
+	session := game.NewSession(game.WithSeed(42), game.WithProfile(profile))
+	defer session.Close()
+
+	// The base game is won after all events of the first act are played.
+	res := Runner{}.Run(session, NewGreedyAgent())
+	assert.NoError(t, res.Err)
+	assert.Equal(t, game.GameStateVictory, res.FinalState)
+	assert.Equal(t, game.Profile{UnlockedDifficulty: 1, Victories: 1}, session.GetProfile())
+}
+
+func TestObserve(t *testing.T) {
+	session := game.NewSession(game.WithSeed(42))
+	defer session.Close()
+
+	obs := Observe(session)
+	assert.Equal(t, game.GameStateEvent, obs.State)
+	assert.NotEmpty(t, obs.Legal)
+	assert.True(t, obs.IsLegal(Action{Type: ActionChooseEvent, Index: 0}))
+	assert.False(t, obs.IsLegal(Action{Type: ActionEndTurn}))
+	assert.Error(t, Apply(session, Action{Type: "UNKNOWN"}))
+}
+
+func TestObserveUnknownWare(t *testing.T) {
+	session := game.NewSession(game.WithSeed(42))
+	defer session.Close()
+
+	session.SetGameState(game.GameStateMerchant)
+	merchant := session.GetMerchant()
+	if !assert.NotEmpty(t, merchant.Cards) || !assert.NotEmpty(t, merchant.Artifacts) {
+		return
+	}
+
+	// Wares whose type was removed after the merchant was set up have no price.
+	delete(session.GetResources().Cards, merchant.Cards[0])
+	delete(session.GetResources().Artifacts, merchant.Artifacts[0])
+
+	obs := Observe(session)
+	assert.Equal(t, WareObservation{TypeID: merchant.Cards[0]}, obs.MerchantCards[0])
+	assert.Equal(t, WareObservation{TypeID: merchant.Artifacts[0]}, obs.MerchantArts[0])
+}
@@ -0,0 +1,84 @@
+package agent
+
+import (
+	"github.com/BigJk/end_of_eden/game"
+	"github.com/samber/lo"
+	"math"
+	"sort"
+)
+
+// greedyProbeDamage is the damage that is simulated against each opponent to estimate how much damage
+// modifiers like armor or weaknesses change the damage the player deals to it.
+const greedyProbeDamage = 10
+
+// GreedyAgent plays the most expensive castable card each step and focuses the opponent that would die
+// the fastest based on SimulateDealDamage. At the merchant it buys the most expensive affordable ware and
+// in events it always takes the first choice.
+type GreedyAgent struct{}
+
+// NewGreedyAgent creates a greedy agent.
+func NewGreedyAgent() *GreedyAgent {
+	return &GreedyAgent{}
+}
+
+func (a *GreedyAgent) Act(session *game.Session, obs Observation) Action {
+	switch obs.State {
+	case game.GameStateFight:
+		cards := lo.Filter(obs.Hand, func(card CardObservation, index int) bool {
+			return card.Castable
+		})
+		if len(cards) == 0 {
+			return Action{Type: ActionEndTurn}
+		}
+
+		sort.SliceStable(cards, func(i, j int) bool {
+			return cards[i].PointCost > cards[j].PointCost
+		})
+
+		card := cards[0]
+		if !card.NeedTarget {
+			return Action{Type: ActionCastCard, Index: card.Index}
+		}
+
+		return Action{Type: ActionCastCard, Index: card.Index, Target: a.focusTarget(session, obs.Opponents)}
+	case game.GameStateMerchant:
+		wares := lo.Filter(obs.Legal, func(action Action, index int) bool {
+			return action.Type == ActionBuyCard || action.Type == ActionBuyArtifact
+		})
+		if len(wares) == 0 {
+			return Action{Type: ActionLeaveMerchant}
+		}
+
+		price := func(action Action) int {
+			ware, _ := lo.Find(append(obs.MerchantCards, obs.MerchantArts...), func(ware WareObservation) bool {
+				return ware.TypeID == action.TypeID
+			})
+			return ware.Price
+		}
+		return lo.MaxBy(wares, func(a Action, b Action) bool {
+			return price(a) > price(b)
+		})
+	}
+
+	return obs.Legal[0]
+}
+
+// focusTarget returns the opponent that needs the fewest hits to die. Opponents that take more damage
+// because of status effects or artifacts are preferred.
+func (a *GreedyAgent) focusTarget(session *game.Session, opponents []ActorObservation) string {
+	hits := func(opponent ActorObservation) float64 {
+		damage := session.SimulateDealDamage(game.PlayerActorID, opponent.GUID, greedyProbeDamage, false)
+		if damage <= 0 {
+			return math.Inf(1)
+		}
+		return math.Ceil(float64(opponent.HP) / float64(damage))
+	}
+
+	return lo.MinBy(opponents, func(a ActorObservation, b ActorObservation) bool {
+		ha, hb := hits(a), hits(b)
+		if ha == hb {
+			return a.HP < b.HP
+		}
+		return ha < hb
+	}).GUID
+}
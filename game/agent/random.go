@@ -0,0 +1,20 @@
+package agent
+
+import (
+	"github.com/BigJk/end_of_eden/game"
+	"math/rand"
+)
+
+// RandomAgent picks a random legal action.
+type RandomAgent struct {
+	rnd *rand.Rand
+}
+
+// NewRandomAgent creates a random agent. The same seed results in the same decisions.
+func NewRandomAgent(seed int64) *RandomAgent {
+	return &RandomAgent{rnd: rand.New(rand.NewSource(seed))}
+}
+
+func (a *RandomAgent) Act(session *game.Session, obs Observation) Action {
+	return obs.Legal[a.rnd.Intn(len(obs.Legal))]
+}
@@ -0,0 +1,116 @@
+package agent
+
+import (
+	"fmt"
+	"github.com/BigJk/end_of_eden/game"
+)
+
+// DefaultMaxSteps is the default amount of actions after which a run is aborted.
+const DefaultMaxSteps = 5000
+
+// Result is the outcome of a run.
+type Result struct {
+	Seed          int64
+	Steps         int
+	Turns         int
+	StagesCleared int
+	FinalState    game.GameState
+	PlayerHP      int
+	PlayerMaxHP   int
+	PlayerGold    int
+	Aborted       bool
+	Err           error
+	LuaErrors     []game.LuaError
+}
+
+// Runner plays a whole run with an agent. The story teller, events and merchants are handled by the session,
+// so the runner only needs to apply the actions of the agent until the player dies.
+type Runner struct {
+	// MaxSteps is the amount of actions after which the run is aborted. Defaults to DefaultMaxSteps.
+	MaxSteps int
+
+	// MaxStages stops the run after the given amount of cleared stages. Zero means no limit.
+	MaxStages int
+
+	// OnStep is called before each action is applied.
+	OnStep func(obs Observation, action Action)
+}
+
+// Run plays the session with the agent until the game is over, the stage limit is reached or the run
+// is aborted. Agents only have to handle observations with at least one legal action. The session is
+// not closed.
+func (r Runner) Run(session *game.Session, agent Agent) Result {
+	maxSteps := r.MaxSteps
+	if maxSteps <= 0 {
+		maxSteps = DefaultMaxSteps
+	}
+
+	res := Result{Seed: session.GetSeed()}
+	for {
+		res.LuaErrors = append(res.LuaErrors, drainLuaErrors(session)...)
+
+		obs := Observe(session)
+		if obs.State == game.GameStateGameOver || obs.State == game.GameStateVictory || r.MaxStages > 0 && obs.StagesCleared >= r.MaxStages {
+			break
+		}
+
+		if res.Steps >= maxSteps {
+			res.Aborted = true
+			break
+		}
+
+		// A lua error, e.g. in a story teller, can leave the session in a state without any action.
+		if len(obs.Legal) == 0 {
+			res.Aborted = true
+			res.Err = fmt.Errorf("step %d: no legal action in state %s", res.Steps, obs.State)
+			break
+		}
+
+		action := agent.Act(session, obs)
+		if !obs.IsLegal(action) {
+			res.Aborted = true
+			res.Err = fmt.Errorf("step %d: illegal action %s", res.Steps, action)
+			break
+		}
+
+		if r.OnStep != nil {
+			r.OnStep(obs, action)
+		}
+
+		if err := Apply(session, action); err != nil {
+			res.Aborted = true
+			res.Err = fmt.Errorf("step %d: %s: %w", res.Steps, action, err)
+			break
+		}
+
+		if action.Type == ActionEndTurn {
+			res.Turns += 1
+		}
+		res.Steps += 1
+	}
+
+	res.LuaErrors = append(res.LuaErrors, drainLuaErrors(session)...)
+
+	player := session.GetPlayer()
+	res.FinalState = session.GetGameState()
+	res.StagesCleared = session.GetStagesCleared()
+	res.PlayerHP = player.HP
+	res.PlayerMaxHP = player.MaxHP
+	res.PlayerGold = player.Gold
+
+	return res
+}
+
+// drainLuaErrors returns the lua errors that are waiting in the error channel of the session. The channel
+// is buffered, so it needs to be drained regularly or the session would block on the next error.
+func drainLuaErrors(session *game.Session) []game.LuaError {
+	var errs []game.LuaError
+	for {
+		select {
+		case err := <-session.LuaErrors():
+			errs = append(errs, err)
+		default:
+			return errs
+		}
+	}
+}
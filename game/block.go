@@ -0,0 +1,72 @@
+package game
+
+import (
+	"fmt"
+	"github.com/samber/lo"
+)
+
+// GiveBlock gives block to the target and returns the amount of block that was given. Block absorbs
+// damage before it reaches the hp of the actor and decays at the start of the turn of the actor, see
+// RuleBlockRetain.
+func (s *Session) GiveBlock(target string, amount int) int {
+	if _, ok := s.actors[target]; !ok || amount <= 0 {
+		return 0
+	}
+
+	s.UpdateActor(target, func(actor *Actor) bool {
+		actor.Block += amount
+		return true
+	})
+
+	if target == PlayerActorID {
+		s.Log(LogTypeSuccess, fmt.Sprintf("You gained %d block", amount))
+	} else {
+		s.Log(LogTypeDanger, fmt.Sprintf("%s gained %d block", s.actors[target].Name, amount))
+	}
+
+	// Trigger OnBlockGain callbacks
+	TriggerCallbackSimple(s, CallbackOnBlockGain, TriggerAll, CreateContext("target", target, "block", amount))
+
+	return amount
+}
+
+// RemoveBlock removes block from the target and returns the amount of block that was removed.
+func (s *Session) RemoveBlock(target string, amount int) int {
+	if _, ok := s.actors[target]; !ok || amount <= 0 {
+		return 0
+	}
+
+	removed := lo.Min([]int{s.actors[target].Block, amount})
+	s.UpdateActor(target, func(actor *Actor) bool {
+		actor.Block -= removed
+		return removed > 0
+	})
+	return removed
+}
+
+// absorbBlock lets the block of the target absorb the damage and returns the amount that was absorbed.
+// If the damage breaks the block the OnBlockBreak callbacks are triggered.
+func (s *Session) absorbBlock(source string, target string, damage int) int {
+	block := s.actors[target].Block
+	if block <= 0 || damage <= 0 {
+		return 0
+	}
+
+	blocked := s.RemoveBlock(target, damage)
+	if blocked == block {
+		// Trigger OnBlockBreak callbacks
+		TriggerCallbackSimple(s, CallbackOnBlockBreak, TriggerAll, CreateContext("source", source, "target", target, "damage", damage, "block", blocked))
+	}
+
+	return blocked
+}
+
+// decayBlock removes the block of the actor that isn't retained at the start of its turn.
+func (s *Session) decayBlock(guid string) {
+	retain := s.GetRule(RuleBlockRetain)
+	if retain < 0 {
+		return
+	}
+
+	s.RemoveBlock(guid, s.actors[guid].Block-retain)
+}
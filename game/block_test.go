@@ -0,0 +1,98 @@
+package game
+
+import (
+	"github.com/stretchr/testify/assert"
+	lua "github.com/yuin/gopher-lua"
+	"io"
+	"log"
+	"testing"
+)
+
+func TestBlock(t *testing.T) {
+	session := NewSession(WithLogging(log.New(io.Discard, "", 0)))
+	defer session.Close()
+
+	assert.NoError(t, session.luaState.DoString(`
+block_gained = 0
+block_broken = 0
+
+register_artifact("SHIELD_WATCHER", {
+	name = "Shield Watcher",
+	description = "Counts block.",
+	price = 0,
+	order = 0,
+	callbacks = {
+		on_block_gain = function(ctx)
+			block_gained = block_gained + ctx.block
+			return nil
+		end,
+		on_block_break = function(ctx)
+			block_broken = block_broken + ctx.block
+			return nil
+		end
+	}
+})
+`))
+	session.GiveArtifact("SHIELD_WATCHER", PlayerActorID)
+
+	enemy := NewActor("ENEMY")
+	enemy.HP = 10
+	enemy.MaxHP = 10
+	session.AddActor(enemy)
+
+	var events []GameEventBlockChanged
+	Subscribe(session, func(event GameEventBlockChanged) {
+		events = append(events, event)
+	})
+
+	hp := session.GetPlayer().HP
+
+	t.Run("Absorb", func(t *testing.T) {
+		assert.Equal(t, 5, session.GiveBlock(PlayerActorID, 5))
+		assert.Equal(t, 0, session.GiveBlock(PlayerActorID, -1))
+		assert.Equal(t, lua.LNumber(5), session.luaState.GetGlobal("block_gained"))
+
+		assert.Equal(t, 0, session.SimulateDealDamage("ENEMY", PlayerActorID, 3, true))
+		assert.Equal(t, 0, session.DealDamage("ENEMY", PlayerActorID, 3, true))
+		assert.Equal(t, 2, session.GetPlayer().Block)
+		assert.Equal(t, hp, session.GetPlayer().HP)
+		assert.Equal(t, lua.LNumber(0), session.luaState.GetGlobal("block_broken"))
+
+		assert.Equal(t, 2, session.SimulateDealDamage("ENEMY", PlayerActorID, 4, true))
+		assert.Equal(t, 2, session.DealDamage("ENEMY", PlayerActorID, 4, true))
+		assert.Equal(t, 0, session.GetPlayer().Block)
+		assert.Equal(t, hp-2, session.GetPlayer().HP)
+		assert.Equal(t, lua.LNumber(2), session.luaState.GetGlobal("block_broken"))
+
+		assert.Equal(t, []GameEventBlockChanged{
+			{Target: PlayerActorID, Change: 5, Block: 5},
+			{Target: PlayerActorID, Change: -3, Block: 2},
+			{Target: PlayerActorID, Change: -2, Block: 0},
+		}, events)
+	})
+
+	t.Run("Decay", func(t *testing.T) {
+		session.GiveBlock(PlayerActorID, 5)
+		session.decayBlock(PlayerActorID)
+		assert.Equal(t, 0, session.GetPlayer().Block)
+
+		assert.NoError(t, session.SetRule(RuleBlockRetain, 2))
+		session.GiveBlock(PlayerActorID, 5)
+		session.decayBlock(PlayerActorID)
+		assert.Equal(t, 2, session.GetPlayer().Block)
+
+		assert.NoError(t, session.SetRule(RuleBlockRetain, -1))
+		session.decayBlock(PlayerActorID)
+		assert.Equal(t, 2, session.GetPlayer().Block)
+	})
+
+	t.Run("Lua", func(t *testing.T) {
+		assert.NoError(t, session.luaState.DoString(`
+removed = remove_block(PLAYER_ID, 5)
+give_block("ENEMY", 3)
+enemy_block = get_actor("ENEMY").block
+`))
+		assert.Equal(t, lua.LNumber(2), session.luaState.GetGlobal("removed"))
+		assert.Equal(t, lua.LNumber(3), session.luaState.GetGlobal("enemy_block"))
+	})
+}
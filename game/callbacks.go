@@ -21,8 +21,24 @@ const (
 	CallbackOnRemove        = "OnRemove"
 	CallbackOnActorDie      = "OnActorDie"
 	CallbackOnMerchantEnter = "OnMerchantEnter"
+	CallbackOnRuleCalc      = "OnRuleCalc"
+	CallbackOnBlockGain     = "OnBlockGain"
+	CallbackOnBlockBreak    = "OnBlockBreak"
+	CallbackOnDraw          = "OnDraw"
+	CallbackOnDiscard       = "OnDiscard"
+	CallbackOnExhaust       = "OnExhaust"
+	CallbackOnShuffle       = "OnShuffle"
 )
 
+// Callbacks contains the names of all callbacks that can be defined in the callbacks table of content.
+var Callbacks = []string{
+	CallbackOnDamage, CallbackOnDamageCalc, CallbackOnHealCalc, CallbackOnCast, CallbackOnActorDidCast, CallbackOnInit,
+	CallbackOnPickUp, CallbackOnTurn, CallbackOnPlayerTurn, CallbackOnStatusAdd, CallbackOnStatusStack,
+	CallbackOnStatusRemove, CallbackOnRemove, CallbackOnActorDie, CallbackOnMerchantEnter,
+	CallbackOnRuleCalc, CallbackOnBlockGain, CallbackOnBlockBreak, CallbackOnDraw, CallbackOnDiscard,
+	CallbackOnExhaust, CallbackOnShuffle,
+}
+
 // Context represents the context arguments for a callback.
 type Context map[string]any
 
@@ -127,7 +143,7 @@ func TriggerCallback(s *Session, callback string, who Trigger, fn func(val any,
 		Done:     false,
 	}
 
-	s.TraverseArtifactsStatus(lo.Keys(s.instances),
+	s.TraverseArtifactsStatus(s.GetInstances(),
 		func(instance ArtifactInstance, artifact *Artifact) {
 			if state.Done || state.Who&TriggerArtifact == 0 {
 				return
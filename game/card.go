@@ -26,6 +26,36 @@ type Card struct {
 	Callbacks   map[string]luhelp.OwnedCallback
 	Test        luhelp.OwnedCallback
 	BaseGame    bool
+
+	// Retain keeps the card in the hand at the end of the turn.
+	Retain bool
+
+	// Innate puts the card in the opening hand of each fight.
+	Innate bool
+
+	// Ethereal exhausts the card if it's still in the hand at the end of the turn.
+	Ethereal bool
+
+	// Unplayable cards can't be cast from the hand.
+	Unplayable bool
+}
+
+// Keywords returns the names of the keywords of the card.
+func (c Card) Keywords() []string {
+	var keywords []string
+	if c.Innate {
+		keywords = append(keywords, "Innate")
+	}
+	if c.Retain {
+		keywords = append(keywords, "Retain")
+	}
+	if c.Ethereal {
+		keywords = append(keywords, "Ethereal")
+	}
+	if c.Unplayable {
+		keywords = append(keywords, "Unplayable")
+	}
+	return keywords
 }
 
 // CardInstance represents an instance of a card owned by some actor.
@@ -34,6 +64,9 @@ type CardInstance struct {
 	GUID   string
 	Level  int
 	Owner  string
+
+	// Temporary cards are removed at the end of the fight.
+	Temporary bool
 }
 
 func (c CardInstance) IsNone() bool {
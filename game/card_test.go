@@ -49,3 +49,80 @@ func TestCards(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, "hello_world", res)
 }
+
+func TestCardKeywords(t *testing.T) {
+	session := NewSession(WithLogging(log.New(io.Discard, "", 0)))
+	defer session.Close()
+
+	assert.NoError(t, session.luaState.DoString(`
+local function keyword_card(id, keywords)
+	local def = {
+		name = id,
+		description = id,
+		color = "#cccccc",
+		point_cost = 0,
+		callbacks = {
+			on_cast = function(ctx)
+				return nil
+			end,
+		}
+	}
+	for k, v in pairs(keywords) do
+		def[k] = v
+	end
+	register_card(id, def)
+end
+
+keyword_card("PLAIN", {})
+keyword_card("RETAIN", { retain = true })
+keyword_card("INNATE", { innate = true })
+keyword_card("ETHEREAL", { ethereal = true })
+keyword_card("UNPLAYABLE", { unplayable = true })
+`))
+
+	assert.Equal(t, []string{"Innate"}, session.resources.Cards["INNATE"].Keywords())
+	assert.Empty(t, session.resources.Cards["PLAIN"].Keywords())
+
+	for i := 0; i < 5; i++ {
+		session.GiveCard("PLAIN", PlayerActorID)
+	}
+	innate := session.GiveCard("INNATE", PlayerActorID)
+	retain := session.GiveCard("RETAIN", PlayerActorID)
+	ethereal := session.GiveCard("ETHEREAL", PlayerActorID)
+	unplayable := session.GiveCard("UNPLAYABLE", PlayerActorID)
+
+	enemy := NewActor("ENEMY")
+	enemy.HP = 10
+	enemy.MaxHP = 10
+	session.AddActor(enemy)
+
+	t.Run("Innate", func(t *testing.T) {
+		for i := 0; i < 10; i++ {
+			session.SetupFight()
+			assert.Contains(t, session.GetFight().Hand, innate)
+		}
+	})
+
+	t.Run("Unplayable", func(t *testing.T) {
+		session.currentFight.Hand = []string{unplayable}
+		assert.EqualError(t, session.PlayerCastHand(0, ""), "card is unplayable")
+		assert.Equal(t, []string{unplayable}, session.GetFight().Hand)
+
+		// Indices outside the hand are rejected instead of panicking.
+		assert.Error(t, session.PlayerCastHand(-1, ""))
+		assert.Error(t, session.PlayerCastHand(1, ""))
+	})
+
+	t.Run("TurnEnd", func(t *testing.T) {
+		session.currentFight.Hand = []string{retain, ethereal, innate}
+		session.currentFight.Used = []string{}
+		session.currentFight.Exhausted = []string{}
+		session.currentFight.Deck = []string{}
+		assert.NoError(t, session.SetRule(RuleDrawSize, 0))
+
+		session.FinishPlayerTurn()
+		assert.Equal(t, []string{retain}, session.GetFight().Hand)
+		assert.Equal(t, []string{ethereal}, session.GetFight().Exhausted)
+		assert.Equal(t, []string{innate}, session.GetFight().Used)
+	})
+}
@@ -3,13 +3,22 @@ package game
 import (
 	"encoding/gob"
 	"github.com/samber/lo"
+	"reflect"
+	"slices"
+	"sort"
 )
 
 func init() {
+	// The data of all state events has to be registered, as the checkpoints are part of the saves.
 	gob.Register(StateEventDeathData{})
 	gob.Register(StateEventDamageData{})
 	gob.Register(StateEventHealData{})
 	gob.Register(StateEventMoneyData{})
+	gob.Register(StateEventArtifactAddedData{})
+	gob.Register(StateEventArtifactRemovedData{})
+	gob.Register(StateEventCardAddedData{})
+	gob.Register(StateEventCardRemovedData{})
+	gob.Register(StateEventCastHandData{})
 	gob.Register(StateCheckpoint{})
 	gob.Register(StateCheckpointMarker{})
 }
@@ -25,6 +34,7 @@ const (
 	StateEventArtifactRemoved = StateEvent("ArtifactRemoved")
 	StateEventCardAdded       = StateEvent("CardAdded")
 	StateEventCardRemoved     = StateEvent("CardRemoved")
+	StateEventCastHand        = StateEvent("CastHand")
 )
 
 type StateEventDeathData struct {
@@ -73,31 +83,76 @@ type StateEventCardRemovedData struct {
 	TypeID string
 }
 
+// StateEventCastHandData marks the checkpoint that is pushed right before the player casts a card from
+// the hand. It contains the position of the random source and replay, so Undo can restore them.
+type StateEventCastHandData struct {
+	Index         int
+	Target        string
+	RandomDraws   uint64
+	ReplayActions int
+}
+
+// DefaultCheckpointSnapshotEvery is the default amount of checkpoints after which a full snapshot is kept
+// when the checkpoints are compacted.
+const DefaultCheckpointSnapshotEvery = 25
+
 // StateCheckpoint saves the state of a session at a certain point. This can be used
 // to retroactively check what happened between certain actions.
+//
+// After compaction only some checkpoints keep their full Session snapshot, the others only contain
+// the Delta to the previous checkpoint. Checkpoints returned by Diff, DiffEvent and GetFormerState
+// always contain a full Session.
+//
+// Once a fight ends, the checkpoints before that fight are pruned. Only the first checkpoint and the
+// last checkpoint before each fight keep their state, all other pruned checkpoints only keep their
+// Events. The Session of a pruned checkpoint is the one of the last kept checkpoint before it, so it
+// shows the state at the start or at an earlier fight boundary instead of the state at that time.
 type StateCheckpoint struct {
 	Session *Session
+	Delta   *StateDelta
 
 	// Events describe the events that
 	Events map[StateEvent]any
 }
 
+// StateDelta contains the changes of the game objects and fight state between two checkpoints.
+// CurrentFight, Merchant and CtxData are nil if they didn't change. EventHistory only contains the
+// events that were appended after the first EventHistoryFrom entries of the previous checkpoint.
+type StateDelta struct {
+	State            GameState
+	StagesCleared    int
+	CurrentEvent     string
+	CurrentFight     *FightState
+	Merchant         *MerchantState
+	EventHistoryFrom int
+	EventHistory     []string
+	Rules            Rules
+	CtxData          map[string]any
+	Actors           map[string]Actor
+	RemovedActors    []string
+	Instances        map[string]any
+	RemovedInstances []string
+}
+
 // StateCheckpointMarker is a saved state of a checkpoint log.
 type StateCheckpointMarker struct {
-	checkpoints []StateCheckpoint
+	index int
 }
 
 // Diff returns the new states that happened between the marker and a new session.
 func (sm StateCheckpointMarker) Diff(session *Session) []StateCheckpoint {
-	if len(sm.checkpoints) >= len(session.stateCheckpoints) {
+	if sm.index >= len(session.stateCheckpoints) {
 		return nil
 	}
-	return session.stateCheckpoints[len(sm.checkpoints):]
+	return session.restoreCheckpoints(sm.index, len(session.stateCheckpoints), nil)
 }
 
 // DiffEvent returns the new states that happened between the marker and a new session that contain a certain event.
 func (sm StateCheckpointMarker) DiffEvent(session *Session, event StateEvent) []StateCheckpoint {
-	return lo.Filter(sm.Diff(session), func(item StateCheckpoint, index int) bool {
+	if sm.index >= len(session.stateCheckpoints) {
+		return nil
+	}
+	return session.restoreCheckpoints(sm.index, len(session.stateCheckpoints), func(item StateCheckpoint) bool {
 		if item.Events == nil {
 			return false
 		}
@@ -105,3 +160,190 @@ func (sm StateCheckpointMarker) DiffEvent(session *Session, event StateEvent) []
 		return ok
 	})
 }
+
+// restoreCheckpoint returns the checkpoint at the given index with its full session snapshot.
+func (s *Session) restoreCheckpoint(index int) StateCheckpoint {
+	return s.restoreCheckpoints(index, index+1, nil)[0]
+}
+
+// restoreCheckpoints returns the checkpoints in the range [from, to) that match the filter with their full
+// session snapshot. Compacted checkpoints are restored from the last full snapshot before them by applying
+// the deltas in between.
+func (s *Session) restoreCheckpoints(from int, to int, filter func(item StateCheckpoint) bool) []StateCheckpoint {
+	start := from
+	for start > 0 && s.stateCheckpoints[start].Session == nil {
+		start--
+	}
+
+	var cur *Session
+	var res []StateCheckpoint
+	for i := start; i < to; i++ {
+		checkpoint := s.stateCheckpoints[i]
+
+		switch {
+		case checkpoint.Session != nil:
+			cur = checkpoint.Session
+		case checkpoint.Delta == nil || cur == nil:
+			// Pruned checkpoints keep the state of the last checkpoint before them.
+			checkpoint.Session = cur
+		default:
+			restored := *cur
+			restored.actors = CopyMap(restored.actors)
+			restored.instances = CopyMap(restored.instances)
+			checkpoint.Delta.apply(&restored)
+
+			cur = &restored
+			checkpoint.Session = cur
+		}
+
+		if i >= from && (filter == nil || filter(checkpoint)) {
+			res = append(res, checkpoint)
+		}
+	}
+
+	return res
+}
+
+// pruneCheckpoints drops the state of the checkpoints since the last pruning up to the given index, only
+// their events are kept. The last checkpoint before the index keeps a full snapshot, so each fight boundary
+// can still be restored.
+func (s *Session) pruneCheckpoints(to int) {
+	if to <= s.checkpointsPruned || to > len(s.stateCheckpoints) {
+		return
+	}
+
+	// The first checkpoint keeps its state, so all pruned checkpoints have a state before them.
+	from := s.checkpointsPruned
+	if from == 0 {
+		first := s.restoreCheckpoint(0)
+		s.stateCheckpoints[0].Session = first.Session
+		s.stateCheckpoints[0].Delta = nil
+		from = 1
+	}
+
+	last := s.restoreCheckpoint(to - 1)
+	for i := from; i < to-1; i++ {
+		s.stateCheckpoints[i].Session = nil
+		s.stateCheckpoints[i].Delta = nil
+	}
+	s.stateCheckpoints[to-1].Session = last.Session
+	s.stateCheckpoints[to-1].Delta = nil
+
+	s.checkpointsPruned = to
+}
+
+// compactCheckpoints replaces the checkpoints of the session with their compacted form.
+func (s *Session) compactCheckpoints() {
+	s.stateCheckpoints = s.compactedCheckpoints()
+	s.checkpointsCompacted = len(s.stateCheckpoints)
+}
+
+// compactedCheckpoints returns a copy of the checkpoints in which the full snapshots of all checkpoints
+// since the last compaction are replaced with deltas. The first checkpoint and every n-th checkpoint after
+// it keep their full snapshot.
+func (s *Session) compactedCheckpoints() []StateCheckpoint {
+	snapshotEvery := lo.Ternary(s.checkpointSnapshotEvery > 0, s.checkpointSnapshotEvery, DefaultCheckpointSnapshotEvery)
+	checkpoints := slices.Clone(s.stateCheckpoints)
+
+	var prev *Session
+	for i := s.checkpointsCompacted; i < len(checkpoints); i++ {
+		cur := checkpoints[i].Session
+		if cur == nil {
+			// Already compacted, so the next full snapshot can't be based on the previous one.
+			prev = nil
+			continue
+		}
+
+		if prev != nil && (i-s.checkpointsCompacted)%snapshotEvery != 0 {
+			checkpoints[i].Delta = newStateDelta(prev, cur)
+			checkpoints[i].Session = nil
+		}
+
+		prev = cur
+	}
+
+	return checkpoints
+}
+
+func newStateDelta(prev *Session, cur *Session) *StateDelta {
+	// The event history only grows, so only the new entries are stored.
+	historyFrom := 0
+	for historyFrom < len(prev.eventHistory) && historyFrom < len(cur.eventHistory) && prev.eventHistory[historyFrom] == cur.eventHistory[historyFrom] {
+		historyFrom++
+	}
+
+	delta := &StateDelta{
+		State:            cur.state,
+		StagesCleared:    cur.stagesCleared,
+		CurrentEvent:     cur.currentEvent,
+		EventHistoryFrom: historyFrom,
+		EventHistory:     slices.Clone(cur.eventHistory[historyFrom:]),
+		Rules:            cur.rules,
+		Actors: lo.PickBy(cur.actors, func(key string, value Actor) bool {
+			old, ok := prev.actors[key]
+			return !ok || !reflect.DeepEqual(old, value)
+		}),
+		Instances: lo.PickBy(cur.instances, func(key string, value any) bool {
+			old, ok := prev.instances[key]
+			return !ok || !reflect.DeepEqual(old, value)
+		}),
+	}
+
+	// The fight, merchant and context data are only stored if they changed, as they can be big.
+	if !reflect.DeepEqual(prev.currentFight, cur.currentFight) {
+		delta.CurrentFight = lo.ToPtr(cur.currentFight)
+	}
+	if !reflect.DeepEqual(prev.merchant, cur.merchant) {
+		delta.Merchant = lo.ToPtr(cur.merchant)
+	}
+	if !reflect.DeepEqual(prev.ctxData, cur.ctxData) {
+		delta.CtxData = cur.ctxData
+	}
+
+	for key := range prev.actors {
+		if _, ok := cur.actors[key]; !ok {
+			delta.RemovedActors = append(delta.RemovedActors, key)
+		}
+	}
+
+	for key := range prev.instances {
+		if _, ok := cur.instances[key]; !ok {
+			delta.RemovedInstances = append(delta.RemovedInstances, key)
+		}
+	}
+
+	sort.Strings(delta.RemovedActors)
+	sort.Strings(delta.RemovedInstances)
+
+	return delta
+}
+
+func (d *StateDelta) apply(s *Session) {
+	s.state = d.State
+	s.stagesCleared = d.StagesCleared
+	s.currentEvent = d.CurrentEvent
+	s.eventHistory = append(slices.Clone(s.eventHistory[:min(d.EventHistoryFrom, len(s.eventHistory))]), d.EventHistory...)
+	s.rules = d.Rules
+	if d.CurrentFight != nil {
+		s.currentFight = *d.CurrentFight
+	}
+	if d.Merchant != nil {
+		s.merchant = *d.Merchant
+	}
+	if d.CtxData != nil {
+		s.ctxData = d.CtxData
+	}
+
+	for key, actor := range d.Actors {
+		s.actors[key] = actor.Clone()
+	}
+	for _, key := range d.RemovedActors {
+		delete(s.actors, key)
+	}
+	for key, instance := range d.Instances {
+		s.instances[key] = instance
+	}
+	for _, key := range d.RemovedInstances {
+		delete(s.instances, key)
+	}
+}
@@ -2,6 +2,9 @@ package game
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
 	"fmt"
 	luhelp2 "github.com/BigJk/end_of_eden/internal/lua/luhelp"
 	"github.com/labstack/echo/v4"
@@ -11,12 +14,13 @@ import (
 	lua "github.com/yuin/gopher-lua"
 	"io"
 	"log"
+	"net"
 	"net/http"
 	"net/url"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
-	"sync"
 	"time"
 )
 
@@ -40,11 +44,133 @@ func setupStaticDir(e *echo.Echo) {
 	e.Static("/", filepath.Join(filepath.Dir(exe), "debug/dist"))
 }
 
-// ExposeDebug exposes a debug interface on the given port. This interface can be used to execute lua code on the server.
+func sortedKeys[T any](m map[string]T) []string {
+	keys := lo.Keys(m)
+	sort.Strings(keys)
+	return keys
+}
+
+// DebugConfig configures the debug server that is started by ExposeDebug.
+type DebugConfig struct {
+	// Bind is the address the server listens on. Use port 0 to pick a free port, e.g. if multiple sessions
+	// run in the same process.
+	Bind string
+
+	// Token has to be sent by all clients, either as bearer token, as token query parameter or as cookie. The
+	// cookie is set when the token query parameter is used once, so opening /?token=... in a browser is enough.
+	// If empty a random token is generated.
+	Token string
+
+	// ReadOnly rejects all requests that execute lua or modify the session.
+	ReadOnly bool
+}
+
+const debugTokenCookie = "eoe_debug_token"
+
+// DebugConfigFromEnv returns the debug config that only binds to localhost on the given port. It can be changed
+// with EOE_DEBUG_BIND, EOE_DEBUG_TOKEN and EOE_DEBUG_READ_ONLY=1.
+func DebugConfigFromEnv(port int) DebugConfig {
+	config := DebugConfig{
+		Bind:     fmt.Sprintf("127.0.0.1:%d", port),
+		Token:    os.Getenv("EOE_DEBUG_TOKEN"),
+		ReadOnly: os.Getenv("EOE_DEBUG_READ_ONLY") == "1",
+	}
+	if bind := os.Getenv("EOE_DEBUG_BIND"); bind != "" {
+		config.Bind = bind
+	}
+	return config
+}
+
+func newDebugToken() string {
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(token)
+}
+
+// debugAuth rejects all requests that don't contain the token.
+func debugAuth(token string) echo.MiddlewareFunc {
+	isValid := func(value string) bool {
+		return subtle.ConstantTimeCompare([]byte(value), []byte(token)) == 1
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if auth := c.Request().Header.Get(echo.HeaderAuthorization); strings.HasPrefix(auth, "Bearer ") && isValid(strings.TrimPrefix(auth, "Bearer ")) {
+				return next(c)
+			}
+
+			if query := c.QueryParam("token"); query != "" && isValid(query) {
+				c.SetCookie(&http.Cookie{
+					Name:     debugTokenCookie,
+					Value:    token,
+					Path:     "/",
+					HttpOnly: true,
+					SameSite: http.SameSiteStrictMode,
+				})
+				return next(c)
+			}
+
+			if cookie, err := c.Cookie(debugTokenCookie); err == nil && isValid(cookie.Value) {
+				return next(c)
+			}
+
+			return c.JSONPretty(http.StatusUnauthorized, "missing or invalid debug token", "\t")
+		}
+	}
+}
+
+// debugReadOnly rejects all requests that are not GET requests.
+func debugReadOnly(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if c.Request().Method != http.MethodGet && c.Request().Method != http.MethodHead {
+			return c.JSONPretty(http.StatusForbidden, "debug server is read-only", "\t")
+		}
+		return next(c)
+	}
+}
+
+// debugSessionLock holds the read lock of the session while serving GET requests and the write lock for all
+// other requests, so the handlers never race with the goroutine that drives the session.
+func debugSessionLock(session *Session) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if c.Request().Method == http.MethodGet || c.Request().Method == http.MethodHead {
+				session.mtx.RLock()
+				defer session.mtx.RUnlock()
+			} else {
+				session.mtx.Lock()
+				defer session.mtx.Unlock()
+			}
+			return next(c)
+		}
+	}
+}
+
+// ExposeDebug exposes a debug interface with the given config. This interface can be used to execute lua code on the server.
+// Besides the REPL there are read-only JSON endpoints under /api that return the state of the session, so it can be
+// watched while playtesting. The handlers lock the session, so it must only be changed while holding Session.Mutex.
+// All requests need the token of the config, which is written to the log on start.
 // This is a very dangerous function, which should only be used for debugging purposes. It should never be exposed to the public.
-func ExposeDebug(port int, session *Session, l *lua.LState, log *log.Logger) func() error {
+func ExposeDebug(config DebugConfig, session *Session, l *lua.LState, log *log.Logger) func() error {
+	if config.Token == "" {
+		config.Token = newDebugToken()
+	}
+
+	listener, err := net.Listen("tcp", config.Bind)
+	if err != nil {
+		log.Println("Error starting debug server:", err)
+		return func() error { return nil }
+	}
+
 	e := echo.New()
-	mtx := sync.Mutex{}
+	e.Listener = listener
+	e.Use(debugAuth(config.Token))
+	if config.ReadOnly {
+		e.Use(debugReadOnly)
+	}
+
 	m := melody.New()
 	mapper := luhelp2.NewMapper(l)
 
@@ -62,8 +188,13 @@ func ExposeDebug(port int, session *Session, l *lua.LState, log *log.Logger) fun
 	}))
 
 	m.HandleMessage(func(s *melody.Session, msg []byte) {
-		mtx.Lock()
-		defer mtx.Unlock()
+		if config.ReadOnly {
+			_ = s.Write([]byte("Error: debug server is read-only"))
+			return
+		}
+
+		session.mtx.Lock()
+		defer session.mtx.Unlock()
 
 		if _, err := l.LoadString(string(msg)); err != nil {
 			_ = s.Write([]byte(fmt.Sprintf("Error: %s", err.Error())))
@@ -77,16 +208,51 @@ func ExposeDebug(port int, session *Session, l *lua.LState, log *log.Logger) fun
 	m.HandleConnect(func(session *melody.Session) {
 		_ = session.Write([]byte("::: Welcome to the End of Eden REPL        :::"))
 		_ = session.Write([]byte("::: Use debug_r(args...) to send data back :::"))
+		if config.ReadOnly {
+			_ = session.Write([]byte("::: Read-only, lua can't be executed      :::"))
+		}
 
 		log.Println("Debug connected:", session.RemoteAddr())
 	})
 
-	api := e.Group("/api")
+	api := e.Group("/api", debugSessionLock(session))
 
 	api.GET("/state", func(c echo.Context) error {
 		return c.JSONPretty(http.StatusOK, session.GetGameState(), "\t")
 	})
 
+	api.GET("/session", func(c echo.Context) error {
+		return c.JSONPretty(http.StatusOK, struct {
+			State         GameState
+			Event         string
+			StagesCleared int
+			PlayTime      time.Duration
+			Seed          int64
+			LoadedMods    []string
+			Difficulty    int
+		}{
+			State:         session.GetGameState(),
+			Event:         session.GetEventID(),
+			StagesCleared: session.GetStagesCleared(),
+			PlayTime:      session.GetPlayTime(),
+			Seed:          session.GetSeed(),
+			LoadedMods:    session.GetLoadedMods(),
+			Difficulty:    session.GetDifficulty(),
+		}, "\t")
+	})
+
+	api.GET("/history", func(c echo.Context) error {
+		return c.JSONPretty(http.StatusOK, session.GetEventHistory(), "\t")
+	})
+
+	api.GET("/logs", func(c echo.Context) error {
+		return c.JSONPretty(http.StatusOK, session.Logs, "\t")
+	})
+
+	api.GET("/errors", func(c echo.Context) error {
+		return c.JSONPretty(http.StatusOK, session.RecentLuaErrors(), "\t")
+	})
+
 	api.GET("/fight", func(c echo.Context) error {
 		return c.JSONPretty(http.StatusOK, session.GetFight(), "\t")
 	})
@@ -115,9 +281,6 @@ func ExposeDebug(port int, session *Session, l *lua.LState, log *log.Logger) fun
 			return c.JSONPretty(http.StatusBadRequest, err.Error(), "\t")
 		}
 
-		mtx.Lock()
-		defer mtx.Unlock()
-
 		if _, err := l.LoadString(string(lua)); err != nil {
 			return c.JSONPretty(http.StatusBadRequest, err.Error(), "\t")
 		} else {
@@ -129,6 +292,19 @@ func ExposeDebug(port int, session *Session, l *lua.LState, log *log.Logger) fun
 		return c.NoContent(http.StatusOK)
 	})
 
+	api.GET("/registered", func(c echo.Context) error {
+		res := session.resources
+		return c.JSONPretty(http.StatusOK, map[string][]string{
+			"artifacts":      sortedKeys(res.Artifacts),
+			"cards":          sortedKeys(res.Cards),
+			"events":         sortedKeys(res.Events),
+			"enemies":        sortedKeys(res.Enemies),
+			"status_effects": sortedKeys(res.StatusEffects),
+			"story_tellers":  sortedKeys(res.StoryTeller),
+			"difficulties":   sortedKeys(res.Difficulties),
+		}, "\t")
+	})
+
 	api.GET("/registered/enemies", func(c echo.Context) error {
 		return c.JSONPretty(http.StatusOK, session.resources.Enemies, "\t")
 	})
@@ -159,6 +335,20 @@ func ExposeDebug(port int, session *Session, l *lua.LState, log *log.Logger) fun
 		}), "\t")
 	})
 
+	api.GET("/profile", func(c echo.Context) error {
+		if session.Profiler() == nil {
+			return c.JSONPretty(http.StatusNotFound, "profiling is disabled, start the game with EOE_PROFILE=1", "\t")
+		}
+		return c.JSONPretty(http.StatusOK, session.Profiler().Entries(), "\t")
+	})
+
+	api.DELETE("/profile", func(c echo.Context) error {
+		if session.Profiler() != nil {
+			session.Profiler().Reset()
+		}
+		return c.NoContent(http.StatusOK)
+	})
+
 	api.GET("/svg", func(c echo.Context) error {
 		svg, _, err := session.ToSVG()
 		if err != nil {
@@ -181,11 +371,14 @@ func ExposeDebug(port int, session *Session, l *lua.LState, log *log.Logger) fun
 		setupVite(e)
 	}
 
+	log.Printf("Debug server listening on http://%s/?token=%s (read-only: %v)\n", listener.Addr(), config.Token, config.ReadOnly)
+
 	go func() {
 		e.StdLogger = log
 		e.HideBanner = true
-		if err := e.Start(fmt.Sprintf("127.0.0.1:%d", port)); err != nil && err != http.ErrServerClosed {
-			log.Fatal("shutting down the server")
+		e.HidePort = true
+		if err := e.Start(config.Bind); err != nil && err != http.ErrServerClosed {
+			log.Println("Error running debug server:", err)
 		}
 	}()
 
@@ -0,0 +1,81 @@
+package game
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestDebugAuth(t *testing.T) {
+	e := echo.New()
+	e.Use(debugAuth("secret"))
+	e.Use(debugReadOnly)
+	e.GET("/api/state", func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+	e.POST("/api/exec", func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+
+	do := func(method string, target string, modify func(r *http.Request)) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, nil)
+		if modify != nil {
+			modify(req)
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, do(http.MethodGet, "/api/state", nil).Code)
+	assert.Equal(t, http.StatusUnauthorized, do(http.MethodGet, "/api/state?token=wrong", nil).Code)
+	assert.Equal(t, http.StatusOK, do(http.MethodGet, "/api/state", func(r *http.Request) {
+		r.Header.Set(echo.HeaderAuthorization, "Bearer secret")
+	}).Code)
+
+	// The query parameter sets the cookie, which is enough for all following requests.
+	rec := do(http.MethodGet, "/api/state?token=secret", nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	cookies := rec.Result().Cookies()
+	assert.Len(t, cookies, 1)
+	assert.Equal(t, http.StatusOK, do(http.MethodGet, "/api/state", func(r *http.Request) {
+		r.AddCookie(cookies[0])
+	}).Code)
+
+	assert.Equal(t, http.StatusForbidden, do(http.MethodPost, "/api/exec", func(r *http.Request) {
+		r.Header.Set(echo.HeaderAuthorization, "Bearer secret")
+	}).Code)
+}
+
+func TestDebugSessionLock(t *testing.T) {
+	session := NewSession()
+	defer session.Close()
+
+	e := echo.New()
+	e.Use(debugSessionLock(session))
+	e.GET("/api/actors", func(c echo.Context) error {
+		return c.JSON(http.StatusOK, len(session.GetActors()))
+	})
+
+	done := make(chan int)
+	session.Mutex().Lock()
+	go func() {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/actors", nil))
+		done <- rec.Code
+	}()
+
+	// Reads wait until the session is unlocked by the goroutine that drives it.
+	select {
+	case <-done:
+		t.Fatal("request was served while the session was locked")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	session.AddActor(NewActor("ENEMY"))
+	session.Mutex().Unlock()
+	assert.Equal(t, http.StatusOK, <-done)
+}
@@ -0,0 +1,95 @@
+package game
+
+import (
+	"github.com/samber/lo"
+	"math"
+	"sort"
+)
+
+// Difficulty represents a definition of a difficulty level. A run on a level applies the modifiers of all
+// difficulties from level 1 up to that level, so the modifiers of the levels stack.
+type Difficulty struct {
+	ID          string
+	Level       int
+	Name        string
+	Description string
+
+	// EnemyHP, Gold and MerchantPrice are added to the multiplier of the enemy hp, the gold the player
+	// receives and the prices at the merchant. For example 0.1 means 10% more and -0.1 means 10% less.
+	EnemyHP       float64
+	Gold          float64
+	MerchantPrice float64
+
+	// EnemyBlock is the block each enemy gets at the start of a fight.
+	EnemyBlock int
+
+	// EnemyStatusEffects are given to each enemy at the start of a fight.
+	EnemyStatusEffects []DifficultyStatusEffect
+
+	BaseGame bool
+}
+
+// DifficultyStatusEffect is a status effect that a difficulty gives to enemies.
+type DifficultyStatusEffect struct {
+	ID     string
+	Stacks int
+}
+
+// GetDifficulty returns the difficulty level of the run. 0 is the normal game without modifiers.
+func (s *Session) GetDifficulty() int {
+	return s.difficulty
+}
+
+// GetMaxDifficulty returns the highest difficulty level that is registered.
+func (s *Session) GetMaxDifficulty() int {
+	return lo.Reduce(lo.Values(s.resources.Difficulties), func(agg int, item *Difficulty, _ int) int {
+		return lo.Max([]int{agg, item.Level})
+	}, 0)
+}
+
+// GetActiveDifficulties returns the difficulties whose modifiers apply to the run, ordered by level.
+func (s *Session) GetActiveDifficulties() []*Difficulty {
+	active := lo.Filter(lo.Values(s.resources.Difficulties), func(item *Difficulty, _ int) bool {
+		return item.Level > 0 && item.Level <= s.difficulty
+	})
+	sort.Slice(active, func(i, j int) bool {
+		if active[i].Level == active[j].Level {
+			return active[i].ID < active[j].ID
+		}
+		return active[i].Level < active[j].Level
+	})
+	return active
+}
+
+// MerchantPrice returns the price the merchant asks for an item with the given base price.
+func (s *Session) MerchantPrice(price int) int {
+	return s.applyDifficulty(price, func(d *Difficulty) float64 { return d.MerchantPrice })
+}
+
+// applyDifficulty scales the value with the sum of the modifiers of all active difficulties. The multiplier
+// can't be negative.
+func (s *Session) applyDifficulty(value int, modifier func(d *Difficulty) float64) int {
+	if s.difficulty <= 0 {
+		return value
+	}
+
+	multiplier := 1.0
+	for _, d := range s.GetActiveDifficulties() {
+		multiplier += modifier(d)
+	}
+	return int(math.Round(float64(value) * math.Max(multiplier, 0)))
+}
+
+// applyDifficultyStatusEffects gives the block and status effects of the active difficulties to all enemies.
+func (s *Session) applyDifficultyStatusEffects() {
+	for _, d := range s.GetActiveDifficulties() {
+		for _, guid := range s.GetOpponentGUIDs(PlayerActorID) {
+			s.GiveBlock(guid, d.EnemyBlock)
+		}
+		for _, effect := range d.EnemyStatusEffects {
+			for _, guid := range s.GetOpponentGUIDs(PlayerActorID) {
+				s.GiveStatusEffect(effect.ID, guid, lo.Max([]int{effect.Stacks, 1}))
+			}
+		}
+	}
+}
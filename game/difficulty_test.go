@@ -0,0 +1,130 @@
+package game
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io"
+	"log"
+	"path/filepath"
+	"testing"
+)
+
+const testDifficulties = `
+register_enemy("DUMMY", {
+	name = "Dummy",
+	description = "Does nothing",
+	look = "D",
+	color = "#ffffff",
+	initial_hp = 20,
+	max_hp = 20,
+	gold = 0,
+	callbacks = {}
+})
+
+register_status_effect("HARDENED", {
+	name = "Hardened",
+	description = "Does nothing",
+	look = "H",
+	foreground = "#ffffff",
+	can_stack = true,
+	decay = DECAY_NONE,
+	rounds = 0,
+	callbacks = {}
+})
+
+register_difficulty("LEVEL_1", {
+	level = 1,
+	name = "Level 1",
+	enemy_hp = 0.5,
+	gold = -0.5,
+})
+
+register_difficulty("LEVEL_2", {
+	level = 2,
+	name = "Level 2",
+	enemy_hp = 0.5,
+	merchant_price = 0.25,
+	enemy_status_effects = {
+		{ id = "HARDENED", stacks = 2 }
+	}
+})
+`
+
+func newDifficultySession(t *testing.T, options ...func(s *Session)) *Session {
+	session := NewSession(append([]func(s *Session){WithLogging(log.New(io.Discard, "", 0))}, options...)...)
+	assert.NoError(t, session.luaState.DoString(testDifficulties))
+	return session
+}
+
+func TestDifficulty(t *testing.T) {
+	t.Run("Normal", func(t *testing.T) {
+		session := newDifficultySession(t)
+		defer session.Close()
+
+		assert.Equal(t, 2, session.GetMaxDifficulty())
+		assert.Empty(t, session.GetActiveDifficulties())
+		assert.Equal(t, 20, session.GetActor(session.AddActorFromEnemy("DUMMY")).MaxHP)
+		assert.Equal(t, 100, session.MerchantPrice(100))
+	})
+
+	t.Run("Stacking", func(t *testing.T) {
+		session := newDifficultySession(t, WithDifficulty(2))
+		defer session.Close()
+
+		assert.Len(t, session.GetActiveDifficulties(), 2)
+
+		// Both levels add 50% enemy hp.
+		guid := session.AddActorFromEnemy("DUMMY")
+		assert.Equal(t, 40, session.GetActor(guid).HP)
+		assert.Equal(t, 40, session.GetActor(guid).MaxHP)
+
+		gold := session.GetPlayer().Gold
+		session.GivePlayerGold(10)
+		assert.Equal(t, gold+5, session.GetPlayer().Gold)
+
+		assert.Equal(t, 125, session.MerchantPrice(100))
+
+		session.applyDifficultyStatusEffects()
+		effects := session.GetActorStatusEffects(guid)
+		assert.Len(t, effects, 1)
+		assert.Equal(t, 2, session.GetStatusEffectInstance(effects[0]).Stacks)
+		assert.Empty(t, session.GetActorStatusEffects(PlayerActorID))
+	})
+
+	t.Run("Lua", func(t *testing.T) {
+		session := newDifficultySession(t, WithDifficulty(1))
+		defer session.Close()
+
+		assert.NoError(t, session.luaState.DoString(`difficulty = get_difficulty()`))
+		assert.Equal(t, "1", session.luaState.GetGlobal("difficulty").String())
+	})
+}
+
+func TestProfileUnlock(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "profile.json")
+
+	profile, err := LoadProfile(file)
+	assert.NoError(t, err)
+	assert.Equal(t, Profile{}, profile)
+
+	win := func(difficulty int) Profile {
+		session := newDifficultySession(t, WithProfile(file), WithDifficulty(difficulty))
+		defer session.Close()
+
+		session.SetGameState(GameStateVictory)
+		return session.GetProfile()
+	}
+
+	assert.Equal(t, Profile{UnlockedDifficulty: 1, Victories: 1}, win(0))
+
+	// Winning below the highest unlocked level doesn't unlock anything.
+	assert.Equal(t, Profile{UnlockedDifficulty: 1, Victories: 2}, win(0))
+
+	assert.Equal(t, Profile{UnlockedDifficulty: 2, Victories: 3}, win(1))
+
+	// There is no level above the highest registered difficulty.
+	assert.Equal(t, Profile{UnlockedDifficulty: 2, Victories: 4}, win(2))
+
+	profile, err = LoadProfile(file)
+	assert.NoError(t, err)
+	assert.Equal(t, Profile{UnlockedDifficulty: 2, Victories: 4}, profile)
+}
@@ -0,0 +1,184 @@
+package game
+
+import (
+	"reflect"
+)
+
+// GameEvent is implemented by all events that are published on the event bus of a session.
+type GameEvent interface {
+	gameEvent()
+}
+
+// GameEventDamage is published when an actor took damage.
+type GameEventDamage struct {
+	Source string
+	Target string
+	Damage int
+	HPLeft int
+
+	// Blocked is the part of the damage that was absorbed by block and isn't included in Damage.
+	Blocked int
+}
+
+// GameEventHeal is published when an actor was healed.
+type GameEventHeal struct {
+	Source string
+	Target string
+	Heal   int
+}
+
+// GameEventCardCast is published when a card was successfully cast.
+type GameEventCardCast struct {
+	GUID   string
+	TypeID string
+	Caster string
+	Target string
+}
+
+// GameEventCardDrawn is published when the player drew a card into the hand.
+type GameEventCardDrawn struct {
+	GUID   string
+	TypeID string
+}
+
+// GameEventStatusAdded is published when a new status effect instance was given to an actor.
+type GameEventStatusAdded struct {
+	GUID   string
+	TypeID string
+	Owner  string
+	Stacks int
+}
+
+// GameEventStatusStacked is published when the stacks of an existing status effect changed.
+type GameEventStatusStacked struct {
+	GUID   string
+	TypeID string
+	Owner  string
+	Stacks int
+	Change int
+}
+
+// GameEventStatusRemoved is published when a status effect was removed from an actor.
+type GameEventStatusRemoved struct {
+	GUID   string
+	TypeID string
+	Owner  string
+}
+
+// GameEventArtifactGained is published when an actor gained an artifact.
+type GameEventArtifactGained struct {
+	GUID   string
+	TypeID string
+	Owner  string
+}
+
+// GameEventGoldChanged is published when the gold of an actor changed.
+type GameEventGoldChanged struct {
+	Target string
+	Change int
+	Gold   int
+}
+
+// GameEventBlockChanged is published when the block of an actor changed.
+type GameEventBlockChanged struct {
+	Target string
+	Change int
+	Block  int
+}
+
+// GameEventActorDeath is published when an actor died, before the death callbacks are triggered.
+type GameEventActorDeath struct {
+	Source string
+	Target string
+	Damage int
+}
+
+// GameEventStateChanged is published when the game state changed.
+type GameEventStateChanged struct {
+	From GameState
+	To   GameState
+}
+
+// GameEventChoice is published when the player selected a choice of an event.
+type GameEventChoice struct {
+	EventID string
+	Choice  int
+}
+
+func (GameEventDamage) gameEvent()         {}
+func (GameEventHeal) gameEvent()           {}
+func (GameEventCardCast) gameEvent()       {}
+func (GameEventCardDrawn) gameEvent()      {}
+func (GameEventStatusAdded) gameEvent()    {}
+func (GameEventStatusStacked) gameEvent()  {}
+func (GameEventStatusRemoved) gameEvent()  {}
+func (GameEventArtifactGained) gameEvent() {}
+func (GameEventGoldChanged) gameEvent()    {}
+func (GameEventBlockChanged) gameEvent()   {}
+func (GameEventActorDeath) gameEvent()     {}
+func (GameEventStateChanged) gameEvent()   {}
+func (GameEventChoice) gameEvent()         {}
+
+type eventSubscriber struct {
+	id int
+	fn func(event GameEvent)
+}
+
+// EventBus dispatches the game events of a session to the subscribers. Subscribers are called synchronously
+// while the session is modified, so they should not block and must not modify the session themselves.
+type EventBus struct {
+	nextID      int
+	subscribers map[reflect.Type][]eventSubscriber
+}
+
+// SubscribeAll subscribes to all events of the session. The returned function removes the subscription.
+func (b *EventBus) SubscribeAll(fn func(event GameEvent)) func() {
+	return b.subscribe(nil, fn)
+}
+
+// Subscribe subscribes to all events of type T that are published by the session. The returned
+// function removes the subscription.
+//
+//	unsubscribe := game.Subscribe(session, func(e game.GameEventDamage) {
+//		fmt.Println(e.Target, "took", e.Damage, "damage")
+//	})
+func Subscribe[T GameEvent](s *Session, fn func(event T)) func() {
+	return s.events.subscribe(reflect.TypeOf(*new(T)), func(event GameEvent) {
+		fn(event.(T))
+	})
+}
+
+func (b *EventBus) subscribe(t reflect.Type, fn func(event GameEvent)) func() {
+	if b.subscribers == nil {
+		b.subscribers = map[reflect.Type][]eventSubscriber{}
+	}
+
+	b.nextID += 1
+	id := b.nextID
+	b.subscribers[t] = append(b.subscribers[t], eventSubscriber{id: id, fn: fn})
+
+	return func() {
+		subs := b.subscribers[t]
+		for i := range subs {
+			if subs[i].id == id {
+				b.subscribers[t] = append(subs[:i:i], subs[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// publish calls the subscribers of the event type and then the subscribers of all events.
+func (b *EventBus) publish(event GameEvent) {
+	if b == nil || len(b.subscribers) == 0 {
+		return
+	}
+
+	for _, t := range []reflect.Type{reflect.TypeOf(event), nil} {
+		// Copy so subscribers can unsubscribe while being called.
+		subs := append([]eventSubscriber(nil), b.subscribers[t]...)
+		for i := range subs {
+			subs[i].fn(event)
+		}
+	}
+}
@@ -11,3 +11,9 @@ import (
 func NewGuid(tags ...string) string {
 	return strings.Join(append(tags, fmt.Sprint(time.Now().UnixMilli()), fmt.Sprint(rand.Intn(100000))), "-")
 }
+
+// NewGuid creates a new guid with the given tags from the random source of the session. A seeded
+// session will therefore always create the same guids.
+func (s *Session) NewGuid(tags ...string) string {
+	return strings.Join(append(tags, fmt.Sprint(s.rnd.Int63())), "-")
+}
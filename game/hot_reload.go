@@ -0,0 +1,83 @@
+package game
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/BigJk/end_of_eden/system/localization"
+)
+
+// hotReloader collects the scripts and locals that changed on disk. The files are re-run on the goroutine
+// that drives the session by calling Session.ApplyHotReload, as the lua state is not safe for concurrent use.
+type hotReloader struct {
+	mtx     sync.Mutex
+	pending []string
+	watcher watcher
+}
+
+func (h *hotReloader) push(file string) {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+
+	for i := range h.pending {
+		if h.pending[i] == file {
+			return
+		}
+	}
+	h.pending = append(h.pending, file)
+}
+
+func (h *hotReloader) take() []string {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+
+	pending := h.pending
+	h.pending = nil
+	return pending
+}
+
+// shouldHotReload checks if a changed file is a script or locals file that is loaded by the session.
+func shouldHotReload(file string) bool {
+	file = filepath.ToSlash(file)
+	if strings.Contains(file, "__") {
+		return false
+	}
+
+	switch filepath.Ext(file) {
+	case ".lua":
+		return !strings.Contains(file, "scripts/libs") && !strings.Contains(file, "scripts/definitions")
+	case ".yml", ".yaml":
+		return strings.Contains(file, "locals/")
+	}
+	return false
+}
+
+// ApplyHotReload re-runs all scripts that changed since the last call and adds changed locals. It returns
+// the reloaded files. This does nothing if hot reload is not enabled.
+func (s *Session) ApplyHotReload() []string {
+	if s.hotReload == nil {
+		return nil
+	}
+
+	files := s.hotReload.take()
+	for _, file := range files {
+		var err error
+		if filepath.Ext(file) == ".lua" {
+			err = s.resources.ReloadScript(file, strings.HasPrefix(filepath.ToSlash(filepath.Clean(file)), "assets/scripts"))
+		} else {
+			err = localization.Global.AddFile(file)
+		}
+
+		if err != nil {
+			s.logLuaError("HotReload", file, err)
+			continue
+		}
+
+		s.log.Println("Hot reloaded:", file)
+		s.Log(LogTypeInfo, fmt.Sprintf("Reloaded %s", filepath.ToSlash(file)))
+	}
+
+	return files
+}
@@ -0,0 +1,71 @@
+//go:build !js
+// +build !js
+
+package game
+
+import (
+	"github.com/BigJk/end_of_eden/internal/fs"
+	"github.com/fsnotify/fsnotify"
+	"log"
+)
+
+type watcher = *fsnotify.Watcher
+
+// start starts the file watcher. The returned function stops it.
+func (h *hotReloader) start(logger *log.Logger) func() error {
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		logger.Println("Can't start hot reload:", err)
+		return func() error { return nil }
+	}
+	h.watcher = w
+
+	go func() {
+		for {
+			select {
+			case event, ok := <-w.Events:
+				if !ok {
+					return
+				}
+
+				switch {
+				case event.Has(fsnotify.Create) && isFolder(event.Name):
+					h.add(logger, event.Name)
+				case event.Has(fsnotify.Write) || event.Has(fsnotify.Create):
+					if shouldHotReload(event.Name) {
+						h.push(event.Name)
+					}
+				}
+			case err, ok := <-w.Errors:
+				if !ok {
+					return
+				}
+				logger.Println("Hot reload watcher error:", err)
+			}
+		}
+	}()
+
+	return w.Close
+}
+
+// add watches the folder and all its sub-folders for changes.
+func (h *hotReloader) add(logger *log.Logger, folder string) {
+	if h.watcher == nil {
+		return
+	}
+
+	_ = fs.Walk(folder, func(path string, isDir bool) error {
+		if isDir {
+			if err := h.watcher.Add(path); err != nil {
+				logger.Println("Can't watch folder:", path, err)
+			}
+		}
+		return nil
+	})
+	logger.Println("Hot reload watching:", folder)
+}
+
+func isFolder(path string) bool {
+	_, err := fs.ReadDir(path)
+	return err == nil
+}
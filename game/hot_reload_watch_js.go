@@ -0,0 +1,16 @@
+//go:build js
+// +build js
+
+package game
+
+import "log"
+
+type watcher = any
+
+// start does nothing in the browser, as there is no file system to watch.
+func (h *hotReloader) start(logger *log.Logger) func() error {
+	logger.Println("Hot reload is not supported in the browser")
+	return func() error { return nil }
+}
+
+func (h *hotReloader) add(logger *log.Logger, folder string) {}
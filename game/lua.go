@@ -1,6 +1,7 @@
 package game
 
 import (
+	"bytes"
 	"github.com/BigJk/end_of_eden/internal/fs"
 	"github.com/BigJk/end_of_eden/internal/lua/ludoc"
 	luhelp2 "github.com/BigJk/end_of_eden/internal/lua/luhelp"
@@ -57,6 +58,34 @@ require("fun")()
 fun = require "fun"
 `)
 
+	// Replace math.random with the session random source, so that a seeded session is deterministic
+	// in lua as well. math.randomseed is disabled as the seed is owned by the session.
+
+	mathTable := l.GetGlobal("math").(*lua.LTable)
+	l.SetField(mathTable, "random", l.NewFunction(func(state *lua.LState) int {
+		switch state.GetTop() {
+		case 0:
+			state.Push(lua.LNumber(session.rnd.Float64()))
+		case 1:
+			n := state.CheckInt(1)
+			if n < 1 {
+				state.ArgError(1, "interval is empty")
+			}
+			state.Push(lua.LNumber(session.rnd.Intn(n) + 1))
+		default:
+			min := state.CheckInt(1)
+			max := state.CheckInt(2)
+			if min > max {
+				state.ArgError(2, "interval is empty")
+			}
+			state.Push(lua.LNumber(session.rnd.Intn(max-min+1) + min))
+		}
+		return 1
+	}))
+	l.SetField(mathTable, "randomseed", l.NewFunction(func(state *lua.LState) int {
+		return 0
+	}))
+
 	// Constants
 
 	d.Category("Game Constants", "General game constants.", 0)
@@ -69,11 +98,13 @@ fun = require "fun"
 	d.Global("GAME_STATE_EVENT", "Represents the event game state.")
 	d.Global("GAME_STATE_MERCHANT", "Represents the merchant game state.")
 	d.Global("GAME_STATE_RANDOM", "Represents the random game state in which the active story teller will decide what happens next.")
+	d.Global("GAME_STATE_VICTORY", "Represents the victory game state. Returning it from a story teller ends the run as won and unlocks the next difficulty.")
 
 	l.SetGlobal("GAME_STATE_FIGHT", lua.LString(GameStateFight))
 	l.SetGlobal("GAME_STATE_EVENT", lua.LString(GameStateEvent))
 	l.SetGlobal("GAME_STATE_MERCHANT", lua.LString(GameStateMerchant))
 	l.SetGlobal("GAME_STATE_RANDOM", lua.LString(GameStateRandom))
+	l.SetGlobal("GAME_STATE_VICTORY", lua.LString(GameStateVictory))
 
 	d.Global("DECAY_ONE", "Status effect decays by 1 stack per turn.")
 	d.Global("DECAY_ALL", "Status effect decays by all stacks per turn.")
@@ -83,13 +114,37 @@ fun = require "fun"
 	l.SetGlobal("DECAY_ALL", lua.LString(DecayAll))
 	l.SetGlobal("DECAY_NONE", lua.LString(DecayNone))
 
+	d.Global("RULE_POINTS_PER_ROUND", "Rule for the amount of points the player gets per round.")
+	d.Global("RULE_DRAW_SIZE", "Rule for the amount of cards the player draws per round.")
+	d.Global("RULE_HAND_SIZE", "Rule for the maximum amount of cards in the hand of the player. 0 means no limit.")
+	d.Global("RULE_UPGRADE_COST", "Rule for the cost of upgrading a card at the merchant.")
+	d.Global("RULE_REMOVE_COST", "Rule for the cost of removing a card at the merchant.")
+	d.Global("RULE_BLOCK_RETAIN", "Rule for the block an actor keeps at the start of its turn. 0 means all block is lost and -1 means no block is lost.")
+
+	l.SetGlobal("RULE_POINTS_PER_ROUND", lua.LString(RulePointsPerRound))
+	l.SetGlobal("RULE_DRAW_SIZE", lua.LString(RuleDrawSize))
+	l.SetGlobal("RULE_HAND_SIZE", lua.LString(RuleHandSize))
+	l.SetGlobal("RULE_UPGRADE_COST", lua.LString(RuleUpgradeCost))
+	l.SetGlobal("RULE_REMOVE_COST", lua.LString(RuleRemoveCost))
+	l.SetGlobal("RULE_BLOCK_RETAIN", lua.LString(RuleBlockRetain))
+
+	d.Global("PILE_DECK", "Represents the deck of the player. Cards are drawn from the top of the deck.")
+	d.Global("PILE_HAND", "Represents the hand of the player.")
+	d.Global("PILE_USED", "Represents the used (discard) pile of the player. It's shuffled back into the deck once the deck is empty.")
+	d.Global("PILE_EXHAUSTED", "Represents the exhausted pile of the player. Cards in it can't be drawn for the rest of the fight.")
+
+	l.SetGlobal("PILE_DECK", lua.LString(PileDeck))
+	l.SetGlobal("PILE_HAND", lua.LString(PileHand))
+	l.SetGlobal("PILE_USED", lua.LString(PileUsed))
+	l.SetGlobal("PILE_EXHAUSTED", lua.LString(PileExhausted))
+
 	// Utility
 
 	d.Category("Utility", "General game constants.", 1)
 
 	d.Function("guid", "returns a new random guid.", "guid")
 	l.SetGlobal("guid", l.NewFunction(func(state *lua.LState) int {
-		state.Push(lua.LString(NewGuid("LUA")))
+		state.Push(lua.LString(session.NewGuid("LUA")))
 		return 1
 	}))
 
@@ -195,6 +250,20 @@ fun = require "fun"
 		panic("Can't overwrite print with debug_log")
 	}
 
+	d.Function("profile_report", "Returns a text report of the callbacks that took the most time since the session started. Only available if the game was started with ``EOE_PROFILE=1``. Example: ``print(profile_report(10))``", "string", "(optional) n : number")
+	l.SetGlobal("profile_report", l.NewFunction(func(state *lua.LState) int {
+		profiler := luhelp2.GetProfiler(state)
+		if profiler == nil {
+			state.Push(lua.LString("profiling is disabled, start the game with EOE_PROFILE=1"))
+			return 1
+		}
+
+		sb := &strings.Builder{}
+		_ = profiler.WriteReport(sb, state.OptInt(1, 0))
+		state.Push(lua.LString(sb.String()))
+		return 1
+	}))
+
 	// Audio
 
 	d.Category("Audio", "Audio helper functions.", 4)
@@ -245,6 +314,12 @@ fun = require "fun"
 		return 1
 	}))
 
+	d.Function("get_difficulty", "Gets the difficulty level of the run. 0 is the normal game, higher levels apply the modifiers of all registered difficulties up to that level.", "number")
+	l.SetGlobal("get_difficulty", l.NewFunction(func(state *lua.LState) int {
+		state.Push(lua.LNumber(session.GetDifficulty()))
+		return 1
+	}))
+
 	d.Function("get_fight", "Gets the fight state. This contains the player hand, used, exhausted and round information.", "fight_state")
 	l.SetGlobal("get_fight", l.NewFunction(func(state *lua.LState) int {
 		state.Push(luhelp2.ToLua(state, session.GetFight()))
@@ -287,6 +362,25 @@ fun = require "fun"
 		return 1
 	}))
 
+	d.Function("get_rule", "Gets the value of a rule after it was modified by the ``on_rule_calc`` callbacks. See the ``RULE_*`` globals.", "number", "rule : string")
+	l.SetGlobal("get_rule", l.NewFunction(func(state *lua.LState) int {
+		name := state.ToString(1)
+		if _, err := session.GetRules().Get(name); err != nil {
+			session.logLuaError("get_rule", "", err)
+			return 0
+		}
+		state.Push(lua.LNumber(session.GetRule(name)))
+		return 1
+	}))
+
+	d.Function("set_rule", "Sets the base value of a rule for the rest of the run. See the ``RULE_*`` globals.", "", "rule : string", "value : number")
+	l.SetGlobal("set_rule", l.NewFunction(func(state *lua.LState) int {
+		if err := session.SetRule(state.ToString(1), int(state.ToNumber(2))); err != nil {
+			session.logLuaError("set_rule", "", err)
+		}
+		return 0
+	}))
+
 	// Actor Operations
 
 	d.Category("Actor Operations", "Functions that modify or access the actors. Actors are either the player or enemies.", 6)
@@ -564,6 +658,18 @@ fun = require "fun"
 		return 1
 	}))
 
+	d.Function("give_block", "Gives block to the target. Block absorbs damage before it reaches the hp and decays at the start of the turn of the target, see ``RULE_BLOCK_RETAIN``. Returns the block that was given.", "number", "target : guid", "amount : number")
+	l.SetGlobal("give_block", l.NewFunction(func(state *lua.LState) int {
+		state.Push(lua.LNumber(session.GiveBlock(state.ToString(1), int(state.ToNumber(2)))))
+		return 1
+	}))
+
+	d.Function("remove_block", "Removes block from the target. Returns the block that was removed.", "number", "target : guid", "amount : number")
+	l.SetGlobal("remove_block", l.NewFunction(func(state *lua.LState) int {
+		state.Push(lua.LNumber(session.RemoveBlock(state.ToString(1), int(state.ToNumber(2)))))
+		return 1
+	}))
+
 	// Player
 
 	d.Category("Player Operations", "Functions that are related to the player.", 11)
@@ -574,6 +680,56 @@ fun = require "fun"
 		return 0
 	}))
 
+	d.Function("player_discard_card", "Moves a card from the hand or the deck to the used pile and triggers the ``on_discard`` callbacks. Returns true if the card was discarded.", "boolean", "card : guid")
+	l.SetGlobal("player_discard_card", l.NewFunction(func(state *lua.LState) int {
+		state.Push(lua.LBool(session.PlayerDiscardCard(state.ToString(1))))
+		return 1
+	}))
+
+	d.Function("player_exhaust_card", "Moves a card from any pile to the exhausted pile and triggers the ``on_exhaust`` callbacks. Returns true if the card was exhausted.", "boolean", "card : guid")
+	l.SetGlobal("player_exhaust_card", l.NewFunction(func(state *lua.LState) int {
+		state.Push(lua.LBool(session.PlayerExhaustCard(state.ToString(1))))
+		return 1
+	}))
+
+	d.Function("player_put_card_on_deck", "Moves a card from any pile on the top of the deck, or on the bottom if bottom is true. Returns true if the card was moved.", "boolean", "card : guid", "(optional) bottom : boolean")
+	l.SetGlobal("player_put_card_on_deck", l.NewFunction(func(state *lua.LState) int {
+		state.Push(lua.LBool(session.PlayerPutCardOnDeck(state.ToString(1), state.ToBool(2))))
+		return 1
+	}))
+
+	d.Function("player_scry", "Returns the top cards of the deck without drawing them. Use ``player_discard_card`` and ``player_put_card_on_deck`` to discard or re-order them.", "guid[]", "amount : number")
+	l.SetGlobal("player_scry", l.NewFunction(func(state *lua.LState) int {
+		state.Push(luhelp2.ToLua(state, session.PlayerScry(int(state.ToNumber(1)))))
+		return 1
+	}))
+
+	d.Function("player_search_deck", "Moves up to amount (default 1) cards with the tag from the deck to the hand and triggers the ``on_draw`` callbacks. Cards stay in the deck if the hand is full. Returns the moved cards.", "guid[]", "tag : string", "(optional) amount : number")
+	l.SetGlobal("player_search_deck", l.NewFunction(func(state *lua.LState) int {
+		amount := 1
+		if state.GetTop() >= 2 {
+			amount = int(state.ToNumber(2))
+		}
+		state.Push(luhelp2.ToLua(state, session.PlayerSearchDeck(state.ToString(1), amount)))
+		return 1
+	}))
+
+	d.Function("player_shuffle_used_into_deck", "Shuffles the used pile into the deck and triggers the ``on_shuffle`` callbacks.", "")
+	l.SetGlobal("player_shuffle_used_into_deck", l.NewFunction(func(state *lua.LState) int {
+		session.PlayerShuffleUsedIntoDeck()
+		return 0
+	}))
+
+	d.Function("player_give_temporary_card", "Gives the player a card that is added to the pile (default ``PILE_HAND``) and removed at the end of the fight. Cards added to the deck are put at the bottom and cards for a full hand are added to ``PILE_USED`` instead. Returns the guid of the card.", "guid", "type_id : type_id", "(optional) pile : string")
+	l.SetGlobal("player_give_temporary_card", l.NewFunction(func(state *lua.LState) int {
+		pile := PileHand
+		if state.GetTop() >= 2 {
+			pile = Pile(state.ToString(2))
+		}
+		state.Push(lua.LString(session.GiveTemporaryCard(state.ToString(1), pile)))
+		return 1
+	}))
+
 	d.Function("player_give_action_points", "Gives the player more action points for this turn.", "", "points : number")
 	l.SetGlobal("player_give_action_points", l.NewFunction(func(state *lua.LState) int {
 		session.PlayerGiveActionPoints(int(state.ToNumber(1)))
@@ -639,9 +795,9 @@ fun = require "fun"
 	d.Function("gen_face", "Generates a random face.", "string", "(optional) category : number")
 	l.SetGlobal("gen_face", l.NewFunction(func(state *lua.LState) int {
 		if state.GetTop() == 1 {
-			state.Push(lua.LString(faces.Global.Gen(int(state.ToNumber(1)))))
+			state.Push(lua.LString(faces.Global.GenWith(session.rnd, int(state.ToNumber(1)))))
 		} else {
-			state.Push(lua.LString(faces.Global.GenRand()))
+			state.Push(lua.LString(faces.Global.GenRandWith(session.rnd)))
 		}
 		return 1
 	}))
@@ -675,6 +831,20 @@ fun = require "fun"
 	return l, d
 }
 
+// doScript runs a lua script. In contrast to DoString the chunk is named after the file, so that errors
+// point to the file and line they happened at.
+func doScript(l *lua.LState, file string, src []byte) error {
+	fn, err := l.Load(bytes.NewReader(src), filepath.ToSlash(file))
+	if err != nil {
+		return err
+	}
+
+	l.Push(fn)
+	return luhelp2.CallLimited(l, func() error {
+		return l.PCall(0, lua.MultRet, nil)
+	})
+}
+
 // removeAnsiReset removes the first ansi reset code from a string.
 func removeAnsiReset(s string) string {
 	return strings.Replace(s, "\x1b[0m", "", 1)
@@ -2,8 +2,16 @@ package game
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"github.com/BigJk/end_of_eden/internal/fs"
+	"github.com/BigJk/end_of_eden/internal/git"
+	"github.com/samber/lo"
+	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
 )
 
 type Mod struct {
@@ -12,6 +20,22 @@ type Mod struct {
 	Description string `json:"description"`
 	Version     string `json:"version"`
 	URL         string `json:"url"`
+
+	// GameVersion is the version constraint of the game, e.g. ">=0.2.0 <0.3.0".
+	GameVersion string `json:"game_version,omitempty"`
+
+	// Dependencies maps the folder names of mods that are needed to a version constraint. An empty
+	// constraint accepts any version. Dependencies are always loaded before the mod.
+	Dependencies map[string]string `json:"dependencies,omitempty"`
+
+	// Conflicts contains the folder names of mods that can't be active together with the mod. If a
+	// conflicting mod is active, the mod that declares the conflict is not loaded.
+	Conflicts []string `json:"conflicts,omitempty"`
+
+	// LoadAfter and LoadBefore contain folder names of mods that, if active, need to be loaded
+	// before or after the mod.
+	LoadAfter  []string `json:"load_after,omitempty"`
+	LoadBefore []string `json:"load_before,omitempty"`
 }
 
 func ModDescription(folder string) (Mod, error) {
@@ -27,3 +51,238 @@ func ModDescription(folder string) (Mod, error) {
 
 	return mod, nil
 }
+
+// MountModArchives mounts the mod archives (.zip or .eoemod) in the mods folder as read-only folders, so
+// that they can be used like regular mod folders. A mod in 'mods/my_mod.zip' is available as 'mods/my_mod'.
+// If mods are given, only the archives of these mods are mounted.
+func MountModArchives(mods ...string) error {
+	_, err := fs.MountArchives("./mods", mods...)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// ModError is a problem with a mod that was found while resolving the load order.
+type ModError struct {
+	Mod     string
+	Message string
+
+	// Skipped is true if the mod won't be loaded because of the problem.
+	Skipped bool
+}
+
+func (e ModError) Error() string {
+	return fmt.Sprintf("mod '%s': %s", e.Mod, e.Message)
+}
+
+// ResolveModOrder reads the meta.json of the active mods in the folder and returns the order they should be
+// loaded in. The order of the active mods is kept as long as it doesn't violate a dependency, load_after or
+// load_before constraint. Mods that are missing, have missing dependencies, conflicts or need another game
+// version are not part of the returned order and reported as errors.
+func ResolveModOrder(folder string, active []string) ([]string, []ModError) {
+	var errs []ModError
+	skip := func(mod string, format string, args ...any) {
+		errs = append(errs, ModError{Mod: mod, Message: fmt.Sprintf(format, args...), Skipped: true})
+	}
+
+	mods := map[string]Mod{}
+	for _, key := range active {
+		mod, err := ModDescription(filepath.Join(folder, key))
+		if err != nil {
+			skip(key, "can't read meta.json: %s", err)
+			continue
+		}
+		mods[key] = mod
+	}
+
+	// Check constraints until nothing changes, as skipping a mod can break the dependencies of others.
+	for changed := true; changed; {
+		changed = false
+		for _, key := range active {
+			mod, ok := mods[key]
+			if !ok {
+				continue
+			}
+
+			if msg := checkModConstraints(mod, mods); len(msg) > 0 {
+				skip(key, "%s", msg)
+				delete(mods, key)
+				changed = true
+			}
+		}
+	}
+
+	order, cycle := sortMods(lo.Filter(active, func(key string, index int) bool {
+		_, ok := mods[key]
+		return ok
+	}), mods)
+	if len(cycle) > 0 {
+		errs = append(errs, ModError{Mod: cycle[0], Message: fmt.Sprintf("load order cycle between %s", strings.Join(cycle, ", "))})
+	}
+
+	return order, errs
+}
+
+// checkModConstraints returns why the mod can't be loaded together with the other mods or an empty string.
+func checkModConstraints(mod Mod, mods map[string]Mod) string {
+	if len(mod.GameVersion) > 0 {
+		if ok, err := versionMatches(git.Tag, mod.GameVersion); err != nil {
+			return fmt.Sprintf("invalid game_version: %s", err)
+		} else if !ok {
+			return fmt.Sprintf("needs game version %s but game is %s", mod.GameVersion, git.Tag)
+		}
+	}
+
+	deps := lo.Keys(mod.Dependencies)
+	sort.Strings(deps)
+	for _, dep := range deps {
+		other, ok := mods[dep]
+		if !ok {
+			return fmt.Sprintf("needs mod '%s' which is not active", dep)
+		}
+		if ok, err := versionMatches(other.Version, mod.Dependencies[dep]); err != nil {
+			return fmt.Sprintf("invalid version constraint for '%s': %s", dep, err)
+		} else if !ok {
+			return fmt.Sprintf("needs mod '%s' %s but version %s is active", dep, mod.Dependencies[dep], other.Version)
+		}
+	}
+
+	for _, conflict := range mod.Conflicts {
+		if _, ok := mods[conflict]; ok {
+			return fmt.Sprintf("conflicts with mod '%s'", conflict)
+		}
+	}
+
+	return ""
+}
+
+// sortMods sorts the mods topologically based on their dependencies and load_after and load_before. If
+// multiple mods could be loaded next, the one that comes first in the given order is picked. Mods that
+// are part of a cycle are appended in the given order and returned as cycle.
+func sortMods(keys []string, mods map[string]Mod) ([]string, []string) {
+	after := map[string][]string{}
+	addEdge := func(first string, then string) {
+		if _, ok := mods[first]; !ok {
+			return
+		}
+		if _, ok := mods[then]; !ok {
+			return
+		}
+		after[then] = lo.Uniq(append(after[then], first))
+	}
+
+	for _, key := range keys {
+		for dep := range mods[key].Dependencies {
+			addEdge(dep, key)
+		}
+		for _, other := range mods[key].LoadAfter {
+			addEdge(other, key)
+		}
+		for _, other := range mods[key].LoadBefore {
+			addEdge(key, other)
+		}
+	}
+
+	var order []string
+	loaded := map[string]bool{}
+	for len(order) < len(keys) {
+		next, ok := lo.Find(keys, func(key string) bool {
+			return !loaded[key] && lo.EveryBy(after[key], func(first string) bool {
+				return loaded[first]
+			})
+		})
+		if !ok {
+			cycle := lo.Filter(keys, func(key string, index int) bool {
+				return !loaded[key]
+			})
+			return append(order, cycle...), cycle
+		}
+
+		loaded[next] = true
+		order = append(order, next)
+	}
+
+	return order, nil
+}
+
+// versionMatches checks if the version satisfies the constraint. A constraint is a list of space separated
+// comparisons like ">=0.2.0 <0.3.0". A version without operator has to match exactly. Development builds
+// that have no version tag match every constraint.
+func versionMatches(version string, constraint string) (bool, error) {
+	if version == "dev" || len(strings.TrimSpace(constraint)) == 0 {
+		return true, nil
+	}
+
+	v, err := parseVersion(version)
+	if err != nil {
+		return false, err
+	}
+
+	for _, part := range strings.Fields(constraint) {
+		op := strings.TrimRight(part, "v0123456789.")
+		c, err := parseVersion(strings.TrimPrefix(part, op))
+		if err != nil {
+			return false, err
+		}
+
+		cmp := compareVersions(v, c)
+		var ok bool
+		switch op {
+		case "", "=", "==":
+			ok = cmp == 0
+		case ">":
+			ok = cmp > 0
+		case ">=":
+			ok = cmp >= 0
+		case "<":
+			ok = cmp < 0
+		case "<=":
+			ok = cmp <= 0
+		default:
+			return false, fmt.Errorf("unknown operator '%s'", op)
+		}
+
+		if !ok {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// parseVersion parses versions like "v1.2.3" or "0.2". Missing parts are zero and pre-release or build
+// suffixes are ignored.
+func parseVersion(version string) ([3]int, error) {
+	var parsed [3]int
+
+	version = strings.TrimPrefix(strings.TrimSpace(version), "v")
+	version = strings.SplitN(strings.SplitN(version, "-", 2)[0], "+", 2)[0]
+
+	parts := strings.Split(version, ".")
+	if len(parts) > 3 {
+		return parsed, fmt.Errorf("invalid version '%s'", version)
+	}
+
+	for i := range parts {
+		n, err := strconv.Atoi(parts[i])
+		if err != nil {
+			return parsed, fmt.Errorf("invalid version '%s'", version)
+		}
+		parsed[i] = n
+	}
+
+	return parsed, nil
+}
+
+func compareVersions(a [3]int, b [3]int) int {
+	for i := range a {
+		if a[i] != b[i] {
+			if a[i] < b[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
@@ -0,0 +1,84 @@
+package game
+
+import (
+	"encoding/json"
+	"github.com/BigJk/end_of_eden/internal/git"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResolveModOrder(t *testing.T) {
+	dir := t.TempDir()
+	write := func(key string, mod Mod) {
+		data, err := json.Marshal(mod)
+		assert.NoError(t, err)
+		assert.NoError(t, os.MkdirAll(filepath.Join(dir, key), 0755))
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, key, "meta.json"), data, 0644))
+	}
+
+	write("base", Mod{Name: "Base", Version: "1.2.0"})
+	write("addon", Mod{Name: "Addon", Version: "0.1.0", Dependencies: map[string]string{"base": ">=1.0.0 <2.0.0"}})
+	write("patch", Mod{Name: "Patch", Version: "0.1.0", LoadAfter: []string{"addon"}, LoadBefore: []string{"late"}})
+	write("late", Mod{Name: "Late", Version: "0.1.0"})
+	write("old", Mod{Name: "Old", Version: "0.1.0", Dependencies: map[string]string{"base": "<1.0.0"}})
+	write("rival", Mod{Name: "Rival", Version: "0.1.0", Conflicts: []string{"base"}})
+	write("future", Mod{Name: "Future", Version: "0.1.0", GameVersion: ">=9.0.0"})
+	write("cycle_a", Mod{Name: "A", Version: "0.1.0", LoadAfter: []string{"cycle_b"}})
+	write("cycle_b", Mod{Name: "B", Version: "0.1.0", LoadAfter: []string{"cycle_a"}})
+
+	t.Run("Order", func(t *testing.T) {
+		order, errs := ResolveModOrder(dir, []string{"late", "patch", "addon", "base"})
+		assert.Empty(t, errs)
+		assert.Equal(t, []string{"base", "addon", "patch", "late"}, order)
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		tag := git.Tag
+		git.Tag = "v0.3.0"
+		defer func() { git.Tag = tag }()
+
+		order, errs := ResolveModOrder(dir, []string{"base", "missing", "old", "rival", "future", "addon"})
+		assert.Equal(t, []string{"base", "addon"}, order)
+		assert.Len(t, errs, 4)
+		for _, err := range errs {
+			assert.True(t, err.Skipped, err.Error())
+		}
+	})
+
+	t.Run("MissingDependency", func(t *testing.T) {
+		order, errs := ResolveModOrder(dir, []string{"addon", "late"})
+		assert.Equal(t, []string{"late"}, order)
+		assert.Equal(t, []ModError{{Mod: "addon", Message: "needs mod 'base' which is not active", Skipped: true}}, errs)
+	})
+
+	t.Run("Cycle", func(t *testing.T) {
+		order, errs := ResolveModOrder(dir, []string{"cycle_a", "base", "cycle_b"})
+		assert.Equal(t, []string{"base", "cycle_a", "cycle_b"}, order)
+		assert.Len(t, errs, 1)
+		assert.False(t, errs[0].Skipped)
+	})
+}
+
+func TestVersionMatches(t *testing.T) {
+	for _, c := range []struct {
+		version    string
+		constraint string
+		ok         bool
+	}{
+		{"v0.2.1", ">=0.2.0 <0.3.0", true},
+		{"0.3.0", ">=0.2.0 <0.3.0", false},
+		{"1.0", "1.0.0", true},
+		{"1.0.1", "<=1.0.0", false},
+		{"dev", ">=5.0.0", true},
+		{"1.0.0", "", true},
+	} {
+		ok, err := versionMatches(c.version, c.constraint)
+		assert.NoError(t, err)
+		assert.Equal(t, c.ok, ok, "%s %s", c.version, c.constraint)
+	}
+
+	_, err := versionMatches("1.0.0", "~>1.0")
+	assert.Error(t, err)
+}
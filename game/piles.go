@@ -0,0 +1,216 @@
+package game
+
+import (
+	"fmt"
+	"github.com/samber/lo"
+	"slices"
+)
+
+// Pile is one of the card piles of the fight state.
+type Pile string
+
+const (
+	PileDeck      = Pile("DECK")
+	PileHand      = Pile("HAND")
+	PileUsed      = Pile("USED")
+	PileExhausted = Pile("EXHAUSTED")
+)
+
+// pile returns a pointer to the given pile of the fight state.
+func (s *Session) pile(pile Pile) (*[]string, error) {
+	switch pile {
+	case PileDeck:
+		return &s.currentFight.Deck, nil
+	case PileHand:
+		return &s.currentFight.Hand, nil
+	case PileUsed:
+		return &s.currentFight.Used, nil
+	case PileExhausted:
+		return &s.currentFight.Exhausted, nil
+	}
+	return nil, fmt.Errorf("unknown pile: %s", pile)
+}
+
+// GetPile returns the cards of the given pile. The first card of the deck is the top card.
+func (s *Session) GetPile(pile Pile) []string {
+	cards, err := s.pile(pile)
+	if err != nil {
+		return nil
+	}
+	return slices.Clone(*cards)
+}
+
+// GetCardPile returns the pile the card is in. It returns false if the card is in no pile.
+func (s *Session) GetCardPile(guid string) (Pile, bool) {
+	for _, pile := range []Pile{PileDeck, PileHand, PileUsed, PileExhausted} {
+		cards, _ := s.pile(pile)
+		if lo.Contains(*cards, guid) {
+			return pile, true
+		}
+	}
+	return "", false
+}
+
+// takeCard removes the card from the pile it is in and returns the pile.
+func (s *Session) takeCard(guid string) (Pile, bool) {
+	pile, ok := s.GetCardPile(guid)
+	if !ok {
+		return "", false
+	}
+
+	cards, _ := s.pile(pile)
+	*cards = lo.Without(*cards, guid)
+	return pile, true
+}
+
+// triggerCardCallback triggers the callback on all artifacts, status effects and enemies. The context of
+// the card is passed as second argument, like in OnActorDidCast.
+func (s *Session) triggerCardCallback(callback string, guid string) {
+	_, instance := s.GetCard(guid)
+	TriggerCallbackSimple(s, callback, TriggerAll, EmptyContext, CreateContext("type_id", instance.TypeID, "guid", guid, "owner", instance.Owner, "level", instance.Level))
+}
+
+// PlayerDiscardCard moves a card from the hand or the deck to the used pile and triggers the OnDiscard
+// callbacks. Returns false if the card is in neither of these piles.
+func (s *Session) PlayerDiscardCard(guid string) bool {
+	pile, ok := s.GetCardPile(guid)
+	if !ok || pile != PileHand && pile != PileDeck {
+		return false
+	}
+
+	s.takeCard(guid)
+	s.currentFight.Used = append(s.currentFight.Used, guid)
+	s.triggerCardCallback(CallbackOnDiscard, guid)
+	return true
+}
+
+// PlayerExhaustCard moves a card from any pile to the exhausted pile and triggers the OnExhaust
+// callbacks. Returns false if the card is in no pile or already exhausted.
+func (s *Session) PlayerExhaustCard(guid string) bool {
+	pile, ok := s.GetCardPile(guid)
+	if !ok || pile == PileExhausted {
+		return false
+	}
+
+	s.takeCard(guid)
+	s.exhaustCard(guid)
+	return true
+}
+
+// exhaustCard adds a card that is in no pile to the exhausted pile and triggers the OnExhaust callbacks.
+func (s *Session) exhaustCard(guid string) {
+	s.currentFight.Exhausted = append(s.currentFight.Exhausted, guid)
+	s.triggerCardCallback(CallbackOnExhaust, guid)
+}
+
+// PlayerPutCardOnDeck moves a card from any pile on the top or the bottom of the deck.
+func (s *Session) PlayerPutCardOnDeck(guid string, bottom bool) bool {
+	if _, ok := s.takeCard(guid); !ok {
+		return false
+	}
+
+	if bottom {
+		s.currentFight.Deck = append(s.currentFight.Deck, guid)
+	} else {
+		s.currentFight.Deck = append([]string{guid}, s.currentFight.Deck...)
+	}
+	return true
+}
+
+// PlayerScry returns the top cards of the deck without drawing them. Together with PlayerDiscardCard and
+// PlayerPutCardOnDeck this can be used to look at and re-order the next draws.
+func (s *Session) PlayerScry(amount int) []string {
+	return slices.Clone(lo.Subset(s.currentFight.Deck, 0, uint(lo.Max([]int{amount, 0}))))
+}
+
+// PlayerSearchDeck moves up to amount cards with the given tag from the deck to the hand and returns
+// them. The OnDraw callbacks are triggered for each card. Like with PlayerDrawCard, cards stay in the deck
+// if the hand is full.
+func (s *Session) PlayerSearchDeck(tag string, amount int) []string {
+	found := lo.Filter(s.currentFight.Deck, func(guid string, index int) bool {
+		card, _ := s.GetCard(guid)
+		return card != nil && lo.Contains(card.Tags, tag)
+	})
+	found = lo.Subset(found, 0, uint(lo.Max([]int{amount, 0})))
+
+	moved := []string{}
+	handSize := s.GetRule(RuleHandSize)
+	for _, guid := range found {
+		if s.isHandFull(handSize) {
+			break
+		}
+
+		s.takeCard(guid)
+		s.drawCard(guid)
+		moved = append(moved, guid)
+	}
+
+	return moved
+}
+
+// PlayerShuffleUsedIntoDeck shuffles the used pile into the deck and triggers the OnShuffle callbacks.
+func (s *Session) PlayerShuffleUsedIntoDeck() {
+	if len(s.currentFight.Used) == 0 {
+		return
+	}
+
+	amount := len(s.currentFight.Used)
+	s.currentFight.Deck = shuffle(s.rnd, append(s.currentFight.Deck, s.currentFight.Used...))
+	s.currentFight.Used = []string{}
+
+	// Trigger OnShuffle callbacks
+	TriggerCallbackSimple(s, CallbackOnShuffle, TriggerAll, CreateContext("amount", amount))
+}
+
+// isHandFull returns true if the hand reached the given hand size. The size is passed in, so the
+// OnRuleCalc callbacks only run once when drawing multiple cards.
+func (s *Session) isHandFull(handSize int) bool {
+	return handSize > 0 && len(s.currentFight.Hand) >= handSize
+}
+
+// drawCard adds a card that is in no pile to the hand and triggers the OnDraw callbacks.
+func (s *Session) drawCard(guid string) {
+	s.currentFight.Hand = append(s.currentFight.Hand, guid)
+
+	if _, instance := s.GetCard(guid); !instance.IsNone() {
+		s.events.publish(GameEventCardDrawn{GUID: guid, TypeID: instance.TypeID})
+	}
+	s.triggerCardCallback(CallbackOnDraw, guid)
+}
+
+// GiveTemporaryCard gives the player a card that is added to the given pile and removed again at the end
+// of the fight. Cards added to the deck are put at the bottom and cards for a full hand are added to the
+// used pile instead. Returns the guid of the card.
+func (s *Session) GiveTemporaryCard(typeId string, pile Pile) string {
+	if pile == PileHand && s.isHandFull(s.GetRule(RuleHandSize)) {
+		pile = PileUsed
+	}
+
+	cards, err := s.pile(pile)
+	if err != nil {
+		s.log.Println("Error giving temporary card:", err)
+		return ""
+	}
+
+	guid := s.GiveCard(typeId, PlayerActorID)
+	if guid == "" {
+		return ""
+	}
+
+	instance := s.instances[guid].(CardInstance)
+	instance.Temporary = true
+	s.instances[guid] = instance
+
+	*cards = append(*cards, guid)
+	return guid
+}
+
+// removeTemporaryCards removes all temporary cards of the player.
+func (s *Session) removeTemporaryCards() {
+	for _, guid := range s.GetCards(PlayerActorID) {
+		if _, instance := s.GetCard(guid); instance.Temporary {
+			s.takeCard(guid)
+			s.RemoveCard(guid)
+		}
+	}
+}
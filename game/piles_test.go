@@ -0,0 +1,160 @@
+package game
+
+import (
+	"github.com/stretchr/testify/assert"
+	lua "github.com/yuin/gopher-lua"
+	"io"
+	"log"
+	"testing"
+)
+
+func TestPiles(t *testing.T) {
+	session := NewSession(WithLogging(log.New(io.Discard, "", 0)))
+	defer session.Close()
+
+	assert.NoError(t, session.luaState.DoString(`
+pile_events = {}
+
+register_card("STRIKE", {
+	name = "Strike",
+	description = "Strike",
+	color = "#cccccc",
+	tags = { "ATK" },
+	callbacks = {}
+})
+
+register_card("DEFEND", {
+	name = "Defend",
+	description = "Defend",
+	color = "#cccccc",
+	tags = { "DEF" },
+	callbacks = {}
+})
+
+register_artifact("PILE_WATCHER", {
+	name = "Pile Watcher",
+	description = "Records pile changes.",
+	price = 0,
+	order = 0,
+	callbacks = {
+		on_draw = function(ctx, card)
+			table.insert(pile_events, "draw:" .. card.type_id)
+			return nil
+		end,
+		on_discard = function(ctx, card)
+			table.insert(pile_events, "discard:" .. card.type_id)
+			return nil
+		end,
+		on_exhaust = function(ctx, card)
+			table.insert(pile_events, "exhaust:" .. card.type_id)
+			return nil
+		end,
+		on_shuffle = function(ctx)
+			table.insert(pile_events, "shuffle:" .. ctx.amount)
+			return nil
+		end
+	}
+})
+`))
+	session.GiveArtifact("PILE_WATCHER", PlayerActorID)
+
+	strike := session.GiveCard("STRIKE", PlayerActorID)
+	defendA := session.GiveCard("DEFEND", PlayerActorID)
+	defendB := session.GiveCard("DEFEND", PlayerActorID)
+
+	events := func() []string {
+		var res []string
+		session.luaState.GetGlobal("pile_events").(*lua.LTable).ForEach(func(_ lua.LValue, value lua.LValue) {
+			res = append(res, value.String())
+		})
+		assert.NoError(t, session.luaState.DoString(`pile_events = {}`))
+		return res
+	}
+
+	session.currentFight = FightState{Deck: []string{strike, defendA, defendB}, Hand: []string{}, Used: []string{}, Exhausted: []string{}}
+
+	t.Run("Scry", func(t *testing.T) {
+		assert.Equal(t, []string{strike, defendA}, session.PlayerScry(2))
+		assert.Len(t, session.PlayerScry(10), 3)
+		assert.Empty(t, session.PlayerScry(-1))
+	})
+
+	t.Run("Move", func(t *testing.T) {
+		assert.True(t, session.PlayerPutCardOnDeck(strike, true))
+		assert.Equal(t, []string{defendA, defendB, strike}, session.GetPile(PileDeck))
+
+		assert.True(t, session.PlayerPutCardOnDeck(strike, false))
+		assert.Equal(t, []string{strike, defendA, defendB}, session.GetPile(PileDeck))
+
+		assert.True(t, session.PlayerDiscardCard(strike))
+		assert.False(t, session.PlayerDiscardCard(strike))
+		assert.True(t, session.PlayerExhaustCard(strike))
+		assert.False(t, session.PlayerExhaustCard(strike))
+
+		pile, ok := session.GetCardPile(strike)
+		assert.True(t, ok)
+		assert.Equal(t, PileExhausted, pile)
+		assert.Equal(t, []string{"discard:STRIKE", "exhaust:STRIKE"}, events())
+	})
+
+	t.Run("Search", func(t *testing.T) {
+		assert.Equal(t, []string{defendA}, session.PlayerSearchDeck("DEF", 1))
+		assert.Empty(t, session.PlayerSearchDeck("ATK", 1))
+		assert.Equal(t, []string{defendA}, session.GetPile(PileHand))
+		assert.Equal(t, []string{"draw:DEFEND"}, events())
+	})
+
+	t.Run("Shuffle", func(t *testing.T) {
+		assert.True(t, session.PlayerDiscardCard(defendA))
+		assert.True(t, session.PlayerDiscardCard(defendB))
+		assert.Empty(t, session.GetPile(PileDeck))
+
+		// Drawing from the empty deck shuffles the used pile in.
+		session.PlayerDrawCard(1)
+		assert.Len(t, session.GetPile(PileHand), 1)
+		assert.Len(t, session.GetPile(PileDeck), 1)
+		assert.Empty(t, session.GetPile(PileUsed))
+		assert.Equal(t, []string{"discard:DEFEND", "discard:DEFEND", "shuffle:2", "draw:DEFEND"}, events())
+	})
+
+	t.Run("Temporary", func(t *testing.T) {
+		guid := session.GiveTemporaryCard("STRIKE", PileHand)
+		assert.Contains(t, session.GetPile(PileHand), guid)
+		assert.Empty(t, session.GiveTemporaryCard("STRIKE", "UNKNOWN"))
+
+		session.CleanUpFight()
+		assert.NotContains(t, session.GetCards(PlayerActorID), guid)
+		assert.ElementsMatch(t, []string{strike, defendA, defendB}, session.GetPile(PileDeck))
+	})
+
+	t.Run("Lua", func(t *testing.T) {
+		assert.NoError(t, session.luaState.DoString(`
+local top = player_scry(1)[1]
+player_put_card_on_deck(top, true)
+found = player_search_deck("ATK")
+temporary = player_give_temporary_card("DEFEND", PILE_USED)
+`))
+		found := session.luaState.GetGlobal("found").(*lua.LTable)
+		assert.Equal(t, lua.LString(strike), found.RawGetInt(1))
+		assert.Equal(t, []string{session.luaState.GetGlobal("temporary").String()}, session.GetPile(PileUsed))
+	})
+
+	t.Run("HandSize", func(t *testing.T) {
+		events()
+		assert.NoError(t, session.SetRule(RuleHandSize, 2))
+		defer session.SetRule(RuleHandSize, 0)
+
+		// Only one card fits into the hand, the other one stays in the deck.
+		assert.Len(t, session.PlayerSearchDeck("DEF", 2), 1)
+		assert.Len(t, session.GetPile(PileHand), 2)
+		assert.Len(t, session.GetPile(PileDeck), 1)
+		assert.Empty(t, session.PlayerSearchDeck("DEF", 1))
+
+		// Temporary cards for a full hand go to the used pile.
+		guid := session.GiveTemporaryCard("STRIKE", PileHand)
+		pile, _ := session.GetCardPile(guid)
+		assert.Equal(t, PileUsed, pile)
+		assert.Len(t, session.GetPile(PileHand), 2)
+		assert.Equal(t, []string{"draw:DEFEND"}, events())
+	})
+}
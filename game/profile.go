@@ -0,0 +1,76 @@
+package game
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/BigJk/end_of_eden/internal/fs"
+	"os"
+)
+
+// Profile contains the progress of the player that is kept across runs.
+type Profile struct {
+	// UnlockedDifficulty is the highest difficulty level that can be chosen for a new run.
+	UnlockedDifficulty int `json:"unlocked_difficulty"`
+
+	// Victories is the number of won runs.
+	Victories int `json:"victories"`
+}
+
+// LoadProfile loads the profile from the given file. If the file doesn't exist an empty profile is returned.
+func LoadProfile(file string) (Profile, error) {
+	data, err := fs.ReadFile(file)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return Profile{}, nil
+		}
+		return Profile{}, err
+	}
+
+	var profile Profile
+	if err := json.Unmarshal(data, &profile); err != nil {
+		return Profile{}, err
+	}
+	return profile, nil
+}
+
+// Save writes the profile to the given file.
+func (p Profile) Save(file string) error {
+	data, err := json.MarshalIndent(p, "", "\t")
+	if err != nil {
+		return err
+	}
+	return fs.WriteFile(file, data)
+}
+
+// GetProfile returns the profile of the session. It's empty if the session has no profile file.
+func (s *Session) GetProfile() Profile {
+	return s.profile
+}
+
+// winRun counts the victory in the profile and unlocks the next difficulty level, if the run was on the
+// highest unlocked level.
+func (s *Session) winRun() {
+	s.log.Println("Run won on difficulty", s.difficulty)
+
+	if s.profileFile == "" {
+		return
+	}
+
+	// Reload the profile, as other sessions could have changed it in the meantime.
+	profile, err := LoadProfile(s.profileFile)
+	if err != nil {
+		s.log.Println("Error loading profile:", err)
+		return
+	}
+
+	profile.Victories += 1
+	if s.difficulty >= profile.UnlockedDifficulty && s.difficulty < s.GetMaxDifficulty() {
+		profile.UnlockedDifficulty = s.difficulty + 1
+		s.log.Println("Unlocked difficulty", profile.UnlockedDifficulty)
+	}
+
+	if err := profile.Save(s.profileFile); err != nil {
+		s.log.Println("Error saving profile:", err)
+	}
+	s.profile = profile
+}
@@ -0,0 +1,58 @@
+package game
+
+import (
+	"math/rand"
+	"time"
+)
+
+// countingSource wraps a rand.Source64 and counts how many values were drawn from it. Together with the
+// seed this is enough to restore the exact position in the random sequence after a save is loaded.
+type countingSource struct {
+	src   rand.Source64
+	seed  int64
+	draws uint64
+}
+
+func newCountingSource(seed int64) *countingSource {
+	return &countingSource{
+		src:  rand.NewSource(seed).(rand.Source64),
+		seed: seed,
+	}
+}
+
+func (c *countingSource) Int63() int64 {
+	c.draws += 1
+	return c.src.Int63()
+}
+
+func (c *countingSource) Uint64() uint64 {
+	c.draws += 1
+	return c.src.Uint64()
+}
+
+func (c *countingSource) Seed(seed int64) {
+	c.src.Seed(seed)
+	c.seed = seed
+	c.draws = 0
+}
+
+// skip advances the source by n draws.
+func (c *countingSource) skip(n uint64) {
+	for i := uint64(0); i < n; i++ {
+		c.Uint64()
+	}
+}
+
+// newSeed returns a seed based on the current time. Used if no explicit seed is given.
+func newSeed() int64 {
+	return time.Now().UnixNano()
+}
+
+// shuffle shuffles the collection in place with the given random source and returns it.
+func shuffle[T any](rnd *rand.Rand, collection []T) []T {
+	rnd.Shuffle(len(collection), func(i, j int) {
+		collection[i], collection[j] = collection[j], collection[i]
+	})
+
+	return collection
+}
@@ -0,0 +1,206 @@
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/BigJk/end_of_eden/internal/fs"
+	"github.com/BigJk/end_of_eden/internal/git"
+	"reflect"
+)
+
+// ReplayVersion is the version of the replay format. Replays with a higher version can't be played.
+const ReplayVersion = 1
+
+// ReplayActionType represents the type of player action that was recorded.
+type ReplayActionType string
+
+const (
+	ReplayActionCastHand        = ReplayActionType("CAST_HAND")
+	ReplayActionFinishTurn      = ReplayActionType("FINISH_TURN")
+	ReplayActionFinishEvent     = ReplayActionType("FINISH_EVENT")
+	ReplayActionBuyCard         = ReplayActionType("BUY_CARD")
+	ReplayActionBuyArtifact     = ReplayActionType("BUY_ARTIFACT")
+	ReplayActionBuyUpgradeCard  = ReplayActionType("BUY_UPGRADE_CARD")
+	ReplayActionBuyRemoveCard   = ReplayActionType("BUY_REMOVE_CARD")
+	ReplayActionLeaveMerchant   = ReplayActionType("LEAVE_MERCHANT")
+	ReplayActionLetTellerDecide = ReplayActionType("LET_TELLER_DECIDE")
+)
+
+// ReplayAction represents a single player action that was applied to a session.
+type ReplayAction struct {
+	Type   ReplayActionType `json:"type"`
+	Index  int              `json:"index,omitempty"`
+	Target string           `json:"target,omitempty"`
+	ID     string           `json:"id,omitempty"`
+}
+
+// ReplayResult is a summary of the session state after all actions of a replay were applied. It is
+// used to check if a replay still results in the same state.
+type ReplayResult struct {
+	State         GameState  `json:"state"`
+	StagesCleared int        `json:"stages_cleared"`
+	EventID       string     `json:"event_id"`
+	PlayerHP      int        `json:"player_hp"`
+	PlayerMaxHP   int        `json:"player_max_hp"`
+	PlayerGold    int        `json:"player_gold"`
+	PlayerCards   []string   `json:"player_cards"`
+	Fight         FightState `json:"fight"`
+}
+
+// Replay contains everything that is needed to re-create a session: the seed, the loaded mods, the
+// difficulty and all the player actions in order. As sessions are deterministic for a given seed, applying the actions
+// to a new session with the same seed and mods results in the same state.
+type Replay struct {
+	Version     int            `json:"version"`
+	GameVersion string         `json:"game_version"`
+	Seed        int64          `json:"seed"`
+	Mods        []string       `json:"mods"`
+	Difficulty  int            `json:"difficulty,omitempty"`
+	Actions     []ReplayAction `json:"actions"`
+	Result      ReplayResult   `json:"result"`
+}
+
+// LoadReplay loads a replay from the given file.
+func LoadReplay(file string) (Replay, error) {
+	data, err := fs.ReadFile(file)
+	if err != nil {
+		return Replay{}, err
+	}
+
+	var replay Replay
+	if err := json.Unmarshal(data, &replay); err != nil {
+		return Replay{}, err
+	}
+
+	if replay.Version > ReplayVersion {
+		return Replay{}, fmt.Errorf("replay version %d is newer than the supported version %d", replay.Version, ReplayVersion)
+	}
+
+	return replay, nil
+}
+
+// Save writes the replay to the given file.
+func (r Replay) Save(file string) error {
+	data, err := json.MarshalIndent(r, "", "\t")
+	if err != nil {
+		return err
+	}
+	return fs.WriteFile(file, data)
+}
+
+// Play creates a new session with the seed, mods and difficulty of the replay and applies all recorded actions.
+// Additional options are passed to the session.
+func (r Replay) Play(options ...func(s *Session)) *Session {
+	session := NewSession(append([]func(s *Session){WithSeed(r.Seed), WithMods(r.Mods), WithDifficulty(r.Difficulty)}, options...)...)
+	for i := range r.Actions {
+		session.ApplyReplayAction(r.Actions[i])
+	}
+	return session
+}
+
+// Check compares the result of the replay with the state of the given session.
+func (r Replay) Check(session *Session) error {
+	if res := session.ReplayResult(); !reflect.DeepEqual(r.Result, res) {
+		expected, _ := json.MarshalIndent(r.Result, "", "\t")
+		got, _ := json.MarshalIndent(res, "", "\t")
+		return fmt.Errorf("replay result differs:\nexpected: %s\ngot: %s", expected, got)
+	}
+	return nil
+}
+
+// ToReplay creates a replay from all the recorded player actions of the session.
+func (s *Session) ToReplay() Replay {
+	return Replay{
+		Version:     ReplayVersion,
+		GameVersion: git.Tag,
+		Seed:        s.GetSeed(),
+		Mods:        s.loadedMods,
+		Difficulty:  s.difficulty,
+		Actions:     s.replayActions,
+		Result:      s.ReplayResult(),
+	}
+}
+
+// ReplayResult returns the summary of the current session state that is stored in a replay.
+func (s *Session) ReplayResult() ReplayResult {
+	player := s.GetPlayer()
+	return ReplayResult{
+		State:         s.state,
+		StagesCleared: s.stagesCleared,
+		EventID:       s.currentEvent,
+		PlayerHP:      player.HP,
+		PlayerMaxHP:   player.MaxHP,
+		PlayerGold:    player.Gold,
+		PlayerCards:   s.GetCards(PlayerActorID),
+		Fight:         s.currentFight,
+	}
+}
+
+// ApplyReplayAction applies a recorded player action to the session.
+func (s *Session) ApplyReplayAction(action ReplayAction) {
+	switch action.Type {
+	case ReplayActionCastHand:
+		_ = s.PlayerCastHand(action.Index, action.Target)
+	case ReplayActionFinishTurn:
+		s.FinishPlayerTurn()
+	case ReplayActionFinishEvent:
+		s.FinishEvent(action.Index)
+	case ReplayActionBuyCard:
+		s.PlayerBuyCard(action.ID)
+	case ReplayActionBuyArtifact:
+		s.PlayerBuyArtifact(action.ID)
+	case ReplayActionBuyUpgradeCard:
+		s.BuyUpgradeCard(action.ID)
+	case ReplayActionBuyRemoveCard:
+		s.BuyRemoveCard(action.ID)
+	case ReplayActionLeaveMerchant:
+		s.LeaveMerchant()
+	case ReplayActionLetTellerDecide:
+		s.LetTellerDecide()
+	default:
+		s.log.Println("Unknown replay action:", action.Type)
+	}
+}
+
+// recordAction records a player action for the replay. Actions that are triggered while another action
+// is executed, for example by lua, are not recorded as they will be triggered again on replay. The returned
+// function has to be deferred with a pointer to the bool or error result of the action, or nil if it has
+// none. Actions that return false or an error, or that panic, are not recorded.
+func (s *Session) recordAction(action ReplayAction) func(result any) {
+	depth := s.actionDepth
+	s.actionDepth += 1
+	return func(result any) {
+		s.actionDepth -= 1
+
+		if r := recover(); r != nil {
+			panic(r)
+		}
+
+		switch result := result.(type) {
+		case *bool:
+			if !*result {
+				return
+			}
+		case *error:
+			if *result != nil {
+				return
+			}
+		}
+
+		if depth == 0 {
+			s.replayActions = append(s.replayActions, action)
+		}
+	}
+}
+
+// writeReplay writes the replay to the replay file if one was set. Sessions without any recorded actions
+// are skipped, so an existing replay is not overwritten by a session that was never played.
+func (s *Session) writeReplay() {
+	if len(s.replayFile) == 0 || len(s.replayActions) == 0 {
+		return
+	}
+
+	if err := s.ToReplay().Save(s.replayFile); err != nil {
+		s.log.Println("Error writing replay:", err)
+	}
+}
@@ -11,7 +11,7 @@ import (
 	"strings"
 )
 
-// ResourcesManager can load Artifacts, Cards, Events, Enemy and StoryTeller data from lua.
+// ResourcesManager can load Artifacts, Cards, Events, Enemy, StoryTeller and Difficulty data from lua.
 // The manager will walk the ./scripts directory and evaluate all found .lua files.
 type ResourcesManager struct {
 	Artifacts     map[string]*Artifact
@@ -20,12 +20,16 @@ type ResourcesManager struct {
 	Enemies       map[string]*Enemy
 	StatusEffects map[string]*StatusEffect
 	StoryTeller   map[string]*StoryTeller
+	Difficulties  map[string]*Difficulty
 
 	luaState   *lua.LState
 	luaDocs    *ludoc.Docs
 	log        *log.Logger
 	registered *lua.LTable
 	mapper     *luhelp2.Mapper
+
+	// reloadingBaseGame marks content that is registered while a base game script is reloaded.
+	reloadingBaseGame bool
 }
 
 func NewResourcesManager(state *lua.LState, docs *ludoc.Docs, logger *log.Logger) *ResourcesManager {
@@ -38,13 +42,14 @@ func NewResourcesManager(state *lua.LState, docs *ludoc.Docs, logger *log.Logger
 		Enemies:       map[string]*Enemy{},
 		StatusEffects: map[string]*StatusEffect{},
 		StoryTeller:   map[string]*StoryTeller{},
+		Difficulties:  map[string]*Difficulty{},
 
 		registered: state.NewTable(),
 		mapper:     luhelp2.NewMapper(state),
 	}
 
 	// Create global variable to access registered values in lua
-	lo.ForEach([]string{"artifact", "card", "enemy", "event", "status_effect", "story_teller"}, func(t string, _ int) {
+	lo.ForEach([]string{"artifact", "card", "enemy", "event", "status_effect", "story_teller", "difficulty"}, func(t string, _ int) {
 		man.registered.RawSetString(t, state.NewTable())
 	})
 	man.luaState.SetGlobal("registered", man.registered)
@@ -56,12 +61,14 @@ func NewResourcesManager(state *lua.LState, docs *ludoc.Docs, logger *log.Logger
 	man.luaState.SetGlobal("register_event", man.luaState.NewFunction(man.luaRegisterEvent))
 	man.luaState.SetGlobal("register_status_effect", man.luaState.NewFunction(man.luaRegisterStatusEffect))
 	man.luaState.SetGlobal("register_story_teller", man.luaState.NewFunction(man.luaRegisterStoryTeller))
+	man.luaState.SetGlobal("register_difficulty", man.luaState.NewFunction(man.luaRegisterDifficulty))
 	man.luaState.SetGlobal("delete_event", man.luaState.NewFunction(man.luaDeleteEvent))
 	man.luaState.SetGlobal("delete_card", man.luaState.NewFunction(man.luaDeleteCard))
 	man.luaState.SetGlobal("delete_enemy", man.luaState.NewFunction(man.luaDeleteEnemy))
 	man.luaState.SetGlobal("delete_event", man.luaState.NewFunction(man.luaDeleteEvent))
 	man.luaState.SetGlobal("delete_status_effect", man.luaState.NewFunction(man.luaDeleteStatusEffect))
 	man.luaState.SetGlobal("delete_story_teller", man.luaState.NewFunction(man.luaDeleteStoryTeller))
+	man.luaState.SetGlobal("delete_difficulty", man.luaState.NewFunction(man.luaDeleteDifficulty))
 	man.luaState.SetGlobal("delete_base_game", man.luaState.NewFunction(man.luaDeleteBaseGame))
 	man.defineDocs(docs)
 
@@ -83,7 +90,7 @@ func NewResourcesManager(state *lua.LState, docs *ludoc.Docs, logger *log.Logger
 				return nil
 			}
 
-			if err := man.luaState.DoString(string(luaBytes)); err != nil {
+			if err := doScript(man.luaState, path, luaBytes); err != nil {
 				// TODO: error handling
 				panic(err)
 			}
@@ -95,6 +102,29 @@ func NewResourcesManager(state *lua.LState, docs *ludoc.Docs, logger *log.Logger
 	return man
 }
 
+// ReloadScript re-runs a lua script against the registered content. Definitions that the script registers
+// replace the existing ones with the same id, so instances keep working as they only reference the type id.
+func (man *ResourcesManager) ReloadScript(file string, baseGame bool) error {
+	luaBytes, err := fs.ReadFile(file)
+	if err != nil {
+		return err
+	}
+
+	if strings.HasPrefix(string(luaBytes), "---@meta") {
+		return nil
+	}
+
+	man.reloadingBaseGame = baseGame
+	defer func() {
+		man.reloadingBaseGame = false
+	}()
+
+	if !baseGame {
+		return doModScript(man.luaState, file, luaBytes)
+	}
+	return doScript(man.luaState, file, luaBytes)
+}
+
 // MarkBaseGame marks all currently registered resources as base game resources.
 func (man *ResourcesManager) MarkBaseGame() {
 	for _, v := range man.Artifacts {
@@ -115,9 +145,14 @@ func (man *ResourcesManager) MarkBaseGame() {
 	for _, v := range man.StoryTeller {
 		v.BaseGame = true
 	}
+	for _, v := range man.Difficulties {
+		v.BaseGame = true
+	}
 }
 
 func (man *ResourcesManager) luaRegisterArtifact(l *lua.LState) int {
+	validateRegister(l, "artifact", artifactSchema)
+
 	def := Artifact{
 		Callbacks: map[string]luhelp2.OwnedCallback{},
 	}
@@ -129,6 +164,8 @@ func (man *ResourcesManager) luaRegisterArtifact(l *lua.LState) int {
 
 	// Set id after evaluating the table to avoid ID overwrite
 	def.ID = l.ToString(1)
+	def.BaseGame = man.reloadingBaseGame
+	man.profileCallbacks("artifact", def.ID, def.Callbacks)
 	man.log.Println("Registered artifact:", def.ID, def.Name)
 
 	man.Artifacts[def.ID] = &def
@@ -140,6 +177,8 @@ func (man *ResourcesManager) luaRegisterArtifact(l *lua.LState) int {
 }
 
 func (man *ResourcesManager) luaRegisterCard(l *lua.LState) int {
+	validateRegister(l, "card", cardSchema)
+
 	def := Card{
 		Callbacks: map[string]luhelp2.OwnedCallback{},
 	}
@@ -151,6 +190,9 @@ func (man *ResourcesManager) luaRegisterCard(l *lua.LState) int {
 
 	// Set id after evaluating the table to avoid ID overwrite
 	def.ID = l.ToString(1)
+	def.BaseGame = man.reloadingBaseGame
+	man.profileCallbacks("card", def.ID, def.Callbacks)
+	def.State = man.profile("card", def.ID, "State", def.State)
 	man.log.Println("Registered card:", def.ID, def.Name)
 
 	man.Cards[def.ID] = &def
@@ -162,6 +204,8 @@ func (man *ResourcesManager) luaRegisterCard(l *lua.LState) int {
 }
 
 func (man *ResourcesManager) luaRegisterEnemy(l *lua.LState) int {
+	validateRegister(l, "enemy", enemySchema)
+
 	def := Enemy{
 		Callbacks: map[string]luhelp2.OwnedCallback{},
 	}
@@ -173,6 +217,9 @@ func (man *ResourcesManager) luaRegisterEnemy(l *lua.LState) int {
 
 	// Set id after evaluating the table to avoid ID overwrite
 	def.ID = l.ToString(1)
+	def.BaseGame = man.reloadingBaseGame
+	man.profileCallbacks("enemy", def.ID, def.Callbacks)
+	def.Intend = man.profile("enemy", def.ID, "Intend", def.Intend)
 	man.log.Println("Registered enemy:", def.ID, def.Name)
 
 	man.Enemies[def.ID] = &def
@@ -184,6 +231,8 @@ func (man *ResourcesManager) luaRegisterEnemy(l *lua.LState) int {
 }
 
 func (man *ResourcesManager) luaRegisterEvent(l *lua.LState) int {
+	validateRegister(l, "event", eventSchema)
+
 	def := Event{}
 
 	if err := man.mapper.Map(l.ToTable(2), &def); err != nil {
@@ -193,6 +242,13 @@ func (man *ResourcesManager) luaRegisterEvent(l *lua.LState) int {
 
 	// Set id after evaluating the table to avoid ID overwrite
 	def.ID = l.ToString(1)
+	def.BaseGame = man.reloadingBaseGame
+	def.OnEnter = man.profile("event", def.ID, "OnEnter", def.OnEnter)
+	def.OnEnd = man.profile("event", def.ID, "OnEnd", def.OnEnd)
+	for i := range def.Choices {
+		def.Choices[i].Callback = man.profile("event", def.ID, fmt.Sprintf("Choice%d", i+1), def.Choices[i].Callback)
+		def.Choices[i].DescriptionFn = man.profile("event", def.ID, fmt.Sprintf("Choice%dDescription", i+1), def.Choices[i].DescriptionFn)
+	}
 	man.log.Println("Registered event:", def.ID, def.Name)
 
 	man.Events[def.ID] = &def
@@ -204,6 +260,8 @@ func (man *ResourcesManager) luaRegisterEvent(l *lua.LState) int {
 }
 
 func (man *ResourcesManager) luaRegisterStatusEffect(l *lua.LState) int {
+	validateRegister(l, "status_effect", statusEffectSchema)
+
 	def := StatusEffect{
 		Callbacks: map[string]luhelp2.OwnedCallback{},
 	}
@@ -215,6 +273,9 @@ func (man *ResourcesManager) luaRegisterStatusEffect(l *lua.LState) int {
 
 	// Set id after evaluating the table to avoid ID overwrite
 	def.ID = l.ToString(1)
+	def.BaseGame = man.reloadingBaseGame
+	man.profileCallbacks("status_effect", def.ID, def.Callbacks)
+	def.State = man.profile("status_effect", def.ID, "State", def.State)
 	man.log.Println("Registered status_effect:", def.ID, def.Name)
 
 	man.StatusEffects[def.ID] = &def
@@ -226,6 +287,8 @@ func (man *ResourcesManager) luaRegisterStatusEffect(l *lua.LState) int {
 }
 
 func (man *ResourcesManager) luaRegisterStoryTeller(l *lua.LState) int {
+	validateRegister(l, "story_teller", storyTellerSchema)
+
 	def := StoryTeller{}
 
 	if err := man.mapper.Map(l.ToTable(2), &def); err != nil {
@@ -235,6 +298,9 @@ func (man *ResourcesManager) luaRegisterStoryTeller(l *lua.LState) int {
 
 	// Set id after evaluating the table to avoid ID overwrite
 	def.ID = l.ToString(1)
+	def.BaseGame = man.reloadingBaseGame
+	def.Active = man.profile("story_teller", def.ID, "Active", def.Active)
+	def.Decide = man.profile("story_teller", def.ID, "Decide", def.Decide)
 	man.log.Println("Registered story_teller:", def.ID)
 
 	man.StoryTeller[def.ID] = &def
@@ -245,6 +311,41 @@ func (man *ResourcesManager) luaRegisterStoryTeller(l *lua.LState) int {
 	return 0
 }
 
+func (man *ResourcesManager) luaRegisterDifficulty(l *lua.LState) int {
+	validateRegister(l, "difficulty", difficultySchema)
+
+	def := Difficulty{}
+
+	if err := man.mapper.Map(l.ToTable(2), &def); err != nil {
+		man.log.Println("Error while luaRegisterDifficulty:", err)
+		return 0
+	}
+
+	// Set id after evaluating the table to avoid ID overwrite
+	def.ID = l.ToString(1)
+	def.BaseGame = man.reloadingBaseGame
+	man.log.Println("Registered difficulty:", def.ID)
+
+	man.Difficulties[def.ID] = &def
+
+	table := l.ToTable(2)
+	l.SetTable(table, lua.LString("id"), lua.LString(def.ID))
+	man.registered.RawGetString("difficulty").(*lua.LTable).RawSetString(def.ID, table)
+	return 0
+}
+
+// profile wraps the callback with the profiler of the lua state, if profiling is enabled. Each callback is
+// measured under a key like 'card:MELEE_HIT:OnCast'.
+func (man *ResourcesManager) profile(kind string, id string, name string, cb luhelp2.OwnedCallback) luhelp2.OwnedCallback {
+	return luhelp2.Profile(man.luaState, kind+":"+id+":"+name, cb)
+}
+
+func (man *ResourcesManager) profileCallbacks(kind string, id string, callbacks map[string]luhelp2.OwnedCallback) {
+	for name := range callbacks {
+		callbacks[name] = man.profile(kind, id, name, callbacks[name])
+	}
+}
+
 func (man *ResourcesManager) luaDeleteEvent(l *lua.LState) int {
 	delete(man.Events, l.ToString(1))
 	man.registered.RawGetString("event").(*lua.LTable).RawSetString(l.ToString(1), lua.LNil)
@@ -283,6 +384,14 @@ func (man *ResourcesManager) luaDeleteStoryTeller(l *lua.LState) int {
 	return 0
 }
 
+func (man *ResourcesManager) luaDeleteDifficulty(l *lua.LState) int {
+	man.log.Println("Delete difficulty:", l.ToString(1))
+
+	delete(man.Difficulties, l.ToString(1))
+	man.registered.RawGetString("difficulty").(*lua.LTable).RawSetString(l.ToString(1), lua.LNil)
+	return 0
+}
+
 func (man *ResourcesManager) luaDeleteBaseGame(l *lua.LState) int {
 	if l.GetTop() == 1 {
 		t := l.ToString(1)
@@ -299,6 +408,8 @@ func (man *ResourcesManager) luaDeleteBaseGame(l *lua.LState) int {
 			man.StatusEffects = lo.PickBy(man.StatusEffects, func(k string, v *StatusEffect) bool { return !v.BaseGame })
 		case "story_teller":
 			man.StoryTeller = lo.PickBy(man.StoryTeller, func(k string, v *StoryTeller) bool { return !v.BaseGame })
+		case "difficulty":
+			man.Difficulties = lo.PickBy(man.Difficulties, func(k string, v *Difficulty) bool { return !v.BaseGame })
 		}
 		return 0
 	}
@@ -309,6 +420,7 @@ func (man *ResourcesManager) luaDeleteBaseGame(l *lua.LState) int {
 	man.Events = lo.PickBy(man.Events, func(k string, v *Event) bool { return !v.BaseGame })
 	man.StatusEffects = lo.PickBy(man.StatusEffects, func(k string, v *StatusEffect) bool { return !v.BaseGame })
 	man.StoryTeller = lo.PickBy(man.StoryTeller, func(k string, v *StoryTeller) bool { return !v.BaseGame })
+	man.Difficulties = lo.PickBy(man.Difficulties, func(k string, v *Difficulty) bool { return !v.BaseGame })
 
 	return 0
 }
@@ -465,11 +577,24 @@ func (man *ResourcesManager) defineDocs(docs *ludoc.Docs) {
     end
 })`), "", "id : type_id", "definition : story_teller")
 
+	docs.Function("register_difficulty", fmt.Sprintf("Registers a new difficulty level. A run on a level applies the modifiers of all levels up to it.\n\n```lua\n%s\n```", `register_difficulty("ASCENSION_2", {
+    level = 2,
+    name = "Ascension 2",
+    description = "Enemies have 10% more HP and start with 1 strength.",
+    enemy_hp = 0.1,
+    gold = -0.05,
+    merchant_price = 0.1,
+    enemy_status_effects = {
+        { id = "STRENGTH", stacks = 1 }
+    }
+})`), "", "id : type_id", "definition : difficulty")
+
 	docs.Function("delete_event", fmt.Sprintf("Deletes an event.\n\n```lua\n%s\n```", `delete_event("SOME_EVENT")`), "", "id : type_id")
 	docs.Function("delete_card", fmt.Sprintf("Deletes a card.\n\n```lua\n%s\n```", `delete_card("SOME_CARD")`), "", "id : type_id")
 	docs.Function("delete_enemy", fmt.Sprintf("Deletes an enemy.\n\n```lua\n%s\n```", `delete_enemy("SOME_ENEMY")`), "", "id : type_id")
 	docs.Function("delete_status_effect", fmt.Sprintf("Deletes a status effect.\n\n```lua\n%s\n```", `delete_status_effect("SOME_STATUS_EFFECT")`), "", "id : type_id")
 	docs.Function("delete_story_teller", fmt.Sprintf("Deletes a story teller.\n\n```lua\n%s\n```", `delete_story_teller("SOME_STORY_TELLER")`), "", "id : type_id")
+	docs.Function("delete_difficulty", fmt.Sprintf("Deletes a difficulty level.\n\n```lua\n%s\n```", `delete_difficulty("SOME_DIFFICULTY")`), "", "id : type_id")
 
 	docs.Function("delete_base_game", fmt.Sprintf("Deletes all base game content. Useful if you don't want to include base game content in your mod.\n\n```lua\n%s\n```", `delete_base_game() -- delete all base game content
 delete_base_game("artifact") -- deletes all artifacts
@@ -478,5 +603,6 @@ delete_base_game("enemy") -- deletes all enemies
 delete_base_game("event") -- deletes all events
 delete_base_game("status_effect") -- deletes all status effects
 delete_base_game("story_teller") -- deletes all story tellers
+delete_base_game("difficulty") -- deletes all difficulties
 `), "", "(optional) type : string")
 }
@@ -0,0 +1,126 @@
+package game
+
+import (
+	"fmt"
+	"github.com/samber/lo"
+	"sort"
+)
+
+const (
+	RulePointsPerRound = "points_per_round"
+	RuleDrawSize       = "draw_size"
+	RuleHandSize       = "hand_size"
+	RuleUpgradeCost    = "upgrade_cost"
+	RuleRemoveCost     = "remove_cost"
+	RuleBlockRetain    = "block_retain"
+)
+
+// Rules contains the core values of a run. They are part of the session state, so they can be changed by
+// content with set_rule and are saved with the session. Artifacts, status effects and enemies can modify
+// the values while they are active with the OnRuleCalc callback.
+type Rules struct {
+	// PointsPerRound is the amount of points the player gets per round.
+	PointsPerRound int
+
+	// DrawSize is the amount of cards the player draws per round.
+	DrawSize int
+
+	// HandSize is the maximum amount of cards in the hand of the player. Cards that would be drawn with a
+	// full hand stay in the deck. 0 means no limit.
+	HandSize int
+
+	// UpgradeCost is the cost for upgrading a card at the merchant.
+	UpgradeCost int
+
+	// RemoveCost is the cost for removing a card at the merchant.
+	RemoveCost int
+
+	// BlockRetain is the amount of block an actor keeps at the start of its turn. 0 means all block is lost
+	// and -1 means no block is lost.
+	BlockRetain int
+}
+
+// DefaultRules returns the rules a new run starts with.
+func DefaultRules() Rules {
+	return Rules{
+		PointsPerRound: PointsPerRound,
+		DrawSize:       DrawSize,
+		HandSize:       0,
+		UpgradeCost:    DefaultUpgradeCost,
+		RemoveCost:     DefaultRemoveCost,
+		BlockRetain:    0,
+	}
+}
+
+// ruleFields maps the rule names to the fields of Rules.
+var ruleFields = map[string]func(r *Rules) *int{
+	RulePointsPerRound: func(r *Rules) *int { return &r.PointsPerRound },
+	RuleDrawSize:       func(r *Rules) *int { return &r.DrawSize },
+	RuleHandSize:       func(r *Rules) *int { return &r.HandSize },
+	RuleUpgradeCost:    func(r *Rules) *int { return &r.UpgradeCost },
+	RuleRemoveCost:     func(r *Rules) *int { return &r.RemoveCost },
+	RuleBlockRetain:    func(r *Rules) *int { return &r.BlockRetain },
+}
+
+// RuleNames returns the names of all rules.
+func RuleNames() []string {
+	names := lo.Keys(ruleFields)
+	sort.Strings(names)
+	return names
+}
+
+// Get returns the value of the rule with the given name.
+func (r Rules) Get(name string) (int, error) {
+	field, ok := ruleFields[name]
+	if !ok {
+		return 0, fmt.Errorf("unknown rule: %s", name)
+	}
+	return *field(&r), nil
+}
+
+// Set changes the value of the rule with the given name.
+func (r *Rules) Set(name string, value int) error {
+	field, ok := ruleFields[name]
+	if !ok {
+		return fmt.Errorf("unknown rule: %s", name)
+	}
+	*field(r) = value
+	return nil
+}
+
+// GetRules returns the base rules of the session, without the modifications of OnRuleCalc callbacks.
+func (s *Session) GetRules() Rules {
+	return s.rules
+}
+
+// SetRules replaces the base rules of the session.
+func (s *Session) SetRules(rules Rules) {
+	s.rules = rules
+}
+
+// GetRule returns the value of the rule after all OnRuleCalc callbacks of artifacts, status effects and
+// enemies modified it. Unknown rules return 0.
+func (s *Session) GetRule(name string) int {
+	value, err := s.rules.Get(name)
+	if err != nil {
+		return 0
+	}
+
+	reducer := func(cur float64, val float64) float64 {
+		return val
+	}
+	return int(TriggerCallbackReduce[float64](
+		s,
+		CallbackOnRuleCalc,
+		TriggerAll,
+		reducer,
+		float64(value),
+		"value",
+		CreateContext("rule", name, "value", value),
+	))
+}
+
+// SetRule changes the base value of the rule with the given name.
+func (s *Session) SetRule(name string, value int) error {
+	return s.rules.Set(name, value)
+}
@@ -0,0 +1,90 @@
+package game
+
+import (
+	"bytes"
+	"encoding/gob"
+	"github.com/stretchr/testify/assert"
+	lua "github.com/yuin/gopher-lua"
+	"io"
+	"log"
+	"testing"
+)
+
+func TestRules(t *testing.T) {
+	session := NewSession(WithLogging(log.New(io.Discard, "", 0)))
+	defer session.Close()
+
+	assert.Equal(t, DefaultRules(), session.GetRules())
+	assert.Equal(t, DrawSize, session.GetRule(RuleDrawSize))
+	assert.Error(t, session.SetRule("unknown", 1))
+
+	t.Run("Lua", func(t *testing.T) {
+		assert.NoError(t, session.luaState.DoString(`
+set_rule(RULE_DRAW_SIZE, 4)
+set_rule(RULE_POINTS_PER_ROUND, 2)
+rule_draw_size = get_rule(RULE_DRAW_SIZE)
+`))
+		assert.Equal(t, 4, session.GetRules().DrawSize)
+		assert.Equal(t, 2, session.GetRules().PointsPerRound)
+		assert.Equal(t, lua.LNumber(4), session.luaState.GetGlobal("rule_draw_size"))
+	})
+
+	t.Run("OnRuleCalc", func(t *testing.T) {
+		assert.NoError(t, session.luaState.DoString(`
+register_artifact("CHEAP_UPGRADES", {
+	name = "Cheap Upgrades",
+	description = "Upgrades cost half.",
+	price = 0,
+	order = 0,
+	callbacks = {
+		on_rule_calc = function(ctx)
+			if ctx.rule == RULE_UPGRADE_COST then
+				return ctx.value / 2
+			end
+			return nil
+		end
+	}
+})
+`))
+
+		session.GiveArtifact("CHEAP_UPGRADES", PlayerActorID)
+		assert.Equal(t, DefaultUpgradeCost/2, session.GetRule(RuleUpgradeCost))
+		assert.Equal(t, DefaultRemoveCost, session.GetRule(RuleRemoveCost))
+
+		// The base rule is unchanged, so removing the artifact restores the cost.
+		assert.Equal(t, DefaultUpgradeCost, session.GetRules().UpgradeCost)
+	})
+
+	t.Run("HandSize", func(t *testing.T) {
+		session.currentFight = FightState{Deck: []string{"A", "B", "C", "D"}}
+		assert.NoError(t, session.SetRule(RuleHandSize, 2))
+
+		session.PlayerDrawCard(3)
+		assert.Equal(t, []string{"A", "B"}, session.currentFight.Hand)
+		assert.Equal(t, []string{"C", "D"}, session.currentFight.Deck)
+	})
+
+	t.Run("Save", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		assert.NoError(t, gob.NewEncoder(buf).Encode(session))
+
+		loaded := NewSession(WithLogging(log.New(io.Discard, "", 0)))
+		defer loaded.Close()
+		assert.NoError(t, gob.NewDecoder(buf).Decode(loaded))
+		assert.Equal(t, session.GetRules(), loaded.GetRules())
+
+		// Saves from before the rules existed get the default rules.
+		save := session.ToSavedState()
+		save.Rules = Rules{}
+		payload := &bytes.Buffer{}
+		assert.NoError(t, gob.NewEncoder(payload).Encode(save))
+		buf.Reset()
+		assert.NoError(t, gob.NewEncoder(buf).Encode(SaveEnvelope{Version: 1, Payload: payload.Bytes()}))
+		assert.NoError(t, loaded.GobDecode(buf.Bytes()))
+		assert.Equal(t, DefaultRules(), loaded.GetRules())
+
+		// Current saves keep their rules, even if all of them are zero.
+		loaded.LoadSavedState(save)
+		assert.Equal(t, Rules{}, loaded.GetRules())
+	})
+}
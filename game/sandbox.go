@@ -0,0 +1,111 @@
+package game
+
+import (
+	"bytes"
+	"github.com/BigJk/end_of_eden/internal/lua/luhelp"
+	"github.com/samber/lo"
+	lua "github.com/yuin/gopher-lua"
+	"path/filepath"
+	"time"
+)
+
+// DefaultLuaLimits are the limits of a single call into lua, e.g. a callback or a script that is loaded.
+// They are high enough to never be reached by sane content, but stop endless loops and huge strings.
+var DefaultLuaLimits = luhelp.Limits{
+	Instructions: 50_000_000,
+	Memory:       512 << 20,
+	StringSize:   64 << 20,
+	Timeout:      5 * time.Second,
+}
+
+// sandboxBlocked are the globals that scripts of mods can't access. They give access to the file
+// system, the host or allow to escape the sandbox environment.
+var sandboxBlocked = []string{
+	"io", "debug", "package", "channel", "module",
+	"load", "loadstring", "loadfile", "dofile",
+	"getfenv", "setfenv", "collectgarbage",
+}
+
+// sandboxOsFunctions are the functions of the os library that are safe to use.
+var sandboxOsFunctions = []string{"clock", "date", "difftime", "time"}
+
+const sandboxRegistryKey = "__eoe_sandbox"
+
+// sandboxEnv returns the environment that scripts of mods run in. It's created on first use. Reading a
+// global falls through to the real globals, except for the blocked ones. Writing a global writes to the
+// real globals, so content and functions defined by mods are visible to the base game and other mods.
+func sandboxEnv(l *lua.LState) *lua.LTable {
+	registry := l.Get(lua.RegistryIndex).(*lua.LTable)
+	if env, ok := l.GetField(registry, sandboxRegistryKey).(*lua.LTable); ok {
+		return env
+	}
+
+	globals := l.Get(lua.GlobalsIndex).(*lua.LTable)
+	env := l.NewTable()
+
+	env.RawSetString("_G", env)
+
+	osTable := l.NewTable()
+	if realOs, ok := globals.RawGetString("os").(*lua.LTable); ok {
+		for _, name := range sandboxOsFunctions {
+			osTable.RawSetString(name, realOs.RawGetString(name))
+		}
+	}
+	env.RawSetString("os", osTable)
+
+	// require only resolves the bundled libs from package.preload. The file loaders would run scripts outside
+	// the sandbox and package.loaded contains the blocked standard libraries.
+	env.RawSetString("require", l.NewFunction(func(state *lua.LState) int {
+		name := state.CheckString(1)
+		pkg, _ := globals.RawGetString("package").(*lua.LTable)
+		if pkg == nil || state.GetField(state.GetField(pkg, "preload"), name) == lua.LNil {
+			state.RaiseError("module '%s' is not available in mods", name)
+			return 0
+		}
+
+		state.Push(globals.RawGetString("require"))
+		state.Push(lua.LString(name))
+		state.Call(1, 1)
+		return 1
+	}))
+
+	meta := l.NewTable()
+	meta.RawSetString("__metatable", lua.LString("sandbox"))
+	meta.RawSetString("__index", l.NewFunction(func(state *lua.LState) int {
+		key := state.Get(2)
+		if name, ok := key.(lua.LString); ok && lo.Contains(sandboxBlocked, string(name)) {
+			state.Push(lua.LNil)
+			return 1
+		}
+		state.Push(globals.RawGet(key))
+		return 1
+	}))
+	meta.RawSetString("__newindex", l.NewFunction(func(state *lua.LState) int {
+		key := state.Get(2)
+		if name, ok := key.(lua.LString); ok && lo.Contains(sandboxBlocked, string(name)) {
+			state.RaiseError("'%s' is not available in mods", name)
+			return 0
+		}
+		globals.RawSet(key, state.Get(3))
+		return 0
+	}))
+	l.SetMetatable(env, meta)
+
+	l.SetField(registry, sandboxRegistryKey, env)
+	return env
+}
+
+// doModScript runs a lua script of a mod in the sandbox environment. All functions that are defined by the
+// script, including the callbacks of content it registers, keep the sandbox environment.
+func doModScript(l *lua.LState, file string, src []byte) error {
+	fn, err := l.Load(bytes.NewReader(src), filepath.ToSlash(file))
+	if err != nil {
+		return err
+	}
+	fn.Env = sandboxEnv(l)
+
+	l.Push(fn)
+	return luhelp.CallLimited(l, func() error {
+		return l.PCall(0, lua.MultRet, nil)
+	})
+}
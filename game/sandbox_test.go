@@ -0,0 +1,65 @@
+package game
+
+import (
+	"github.com/BigJk/end_of_eden/internal/lua/luhelp"
+	"github.com/stretchr/testify/assert"
+	lua "github.com/yuin/gopher-lua"
+	"io"
+	"log"
+	"testing"
+)
+
+func TestSandbox(t *testing.T) {
+	session := NewSession(WithLogging(log.New(io.Discard, "", 0)), WithLuaLimits(luhelp.Limits{Instructions: 100_000}))
+	defer session.Close()
+
+	t.Run("Blocked", func(t *testing.T) {
+		assert.NoError(t, session.luaState.DoString(`package.preload.sandbox_lib = function() return {} end`))
+		assert.NoError(t, doModScript(session.luaState, "mod.lua", []byte(`
+sandbox_io = io == nil
+sandbox_os = os.execute == nil and os.time ~= nil
+sandbox_g = _G.io == nil and _G.register_card ~= nil
+sandbox_load = loadstring == nil and dofile == nil and getfenv == nil
+sandbox_require = require("sandbox_lib") ~= nil
+`)))
+
+		for _, name := range []string{"sandbox_io", "sandbox_os", "sandbox_g", "sandbox_load", "sandbox_require"} {
+			assert.Equal(t, lua.LTrue, session.luaState.GetGlobal(name), name)
+		}
+
+		assert.Error(t, doModScript(session.luaState, "mod.lua", []byte(`require("os")`)))
+		assert.Error(t, doModScript(session.luaState, "mod.lua", []byte(`io = {}`)))
+		assert.Error(t, doModScript(session.luaState, "mod.lua", []byte(`setmetatable(_G, nil)`)))
+		assert.Error(t, doModScript(session.luaState, "mod.lua", []byte(`assert(getmetatable(_G) ~= "sandbox")`)))
+
+		// The real globals are untouched.
+		assert.NotEqual(t, lua.LNil, session.luaState.GetGlobal("io"))
+	})
+
+	t.Run("InstructionLimit", func(t *testing.T) {
+		err := doModScript(session.luaState, "mod.lua", []byte(`while true do end`))
+		assert.ErrorIs(t, err, luhelp.ErrInstructionLimit)
+
+		assert.NoError(t, doModScript(session.luaState, "mod.lua", []byte(`
+register_card("SANDBOX_LOOP", {
+	name = "Loop",
+	callbacks = {
+		on_cast = function(ctx)
+			while true do end
+		end
+	}
+})
+`)))
+
+		guid := session.GiveCard("SANDBOX_LOOP", PlayerActorID)
+		session.CastCard(guid, "")
+
+		select {
+		case err := <-session.LuaErrors():
+			assert.Equal(t, CallbackOnCast, err.Callback)
+			assert.ErrorIs(t, err.Err, luhelp.ErrInstructionLimit)
+		default:
+			t.Fatal("expected lua error")
+		}
+	})
+}
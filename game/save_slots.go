@@ -0,0 +1,300 @@
+package game
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"github.com/BigJk/end_of_eden/internal/fs"
+	"github.com/BigJk/end_of_eden/internal/git"
+	"github.com/samber/lo"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	saveSlotExt   = ".save"
+	replaySlotExt = ".replay"
+)
+
+// SaveSlotHeader contains the information about a save slot that is shown before loading it. It is stored
+// in front of the session data, so it can be read without decoding the whole session.
+type SaveSlotHeader struct {
+	Name          string
+	PlayerHP      int
+	PlayerMaxHP   int
+	PlayerGold    int
+	StagesCleared int
+	LoadedMods    []string
+	PlayTime      time.Duration
+	GameVersion   string
+	Timestamp     time.Time
+}
+
+// SaveSlot represents a single save slot.
+type SaveSlot struct {
+	ID     string
+	Header SaveSlotHeader
+
+	// Err is set if the header of the slot can't be read. Broken slots can't be loaded, only deleted.
+	Err error
+}
+
+// SaveSlots manages the save slots in a directory. Each slot is a single file that contains the header
+// followed by the gob encoded session.
+type SaveSlots struct {
+	dir string
+}
+
+// NewSaveSlots creates a new save slot manager for the given directory.
+func NewSaveSlots(dir string) *SaveSlots {
+	return &SaveSlots{dir: dir}
+}
+
+// List returns all save slots, the most recently saved slot first. Slots that can't be read, e.g. because
+// the game crashed while saving, are listed last with their error.
+func (s *SaveSlots) List() ([]SaveSlot, error) {
+	entries, err := fs.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var slots []SaveSlot
+	for _, e := range entries {
+		if !e.IsFile || filepath.Ext(e.Path) != saveSlotExt {
+			continue
+		}
+
+		id := strings.TrimSuffix(filepath.Base(e.Path), saveSlotExt)
+		header, err := s.Header(id)
+		if err != nil {
+			slots = append(slots, SaveSlot{ID: id, Header: SaveSlotHeader{Name: id}, Err: fmt.Errorf("can't read slot %s: %w", id, err)})
+			continue
+		}
+
+		slots = append(slots, SaveSlot{ID: id, Header: header})
+	}
+
+	sort.SliceStable(slots, func(i, j int) bool {
+		return slots[i].Header.Timestamp.After(slots[j].Header.Timestamp)
+	})
+
+	return slots, nil
+}
+
+// Create creates a new empty save slot with the given name.
+func (s *SaveSlots) Create(name string) (SaveSlot, error) {
+	id := time.Now().Format("20060102-150405")
+	for i := 1; s.exists(id); i++ {
+		id = fmt.Sprintf("%s-%d", time.Now().Format("20060102-150405"), i)
+	}
+
+	slot := SaveSlot{
+		ID: id,
+		Header: SaveSlotHeader{
+			Name:        name,
+			GameVersion: git.Tag,
+			Timestamp:   time.Now(),
+		},
+	}
+
+	return slot, s.write(id, slot.Header, nil)
+}
+
+// ImportLegacy imports the save file of the game versions before save slots into a new slot. The legacy
+// save and its replay are removed afterward, so they are only imported once. Returns false if there is no
+// legacy save.
+func (s *SaveSlots) ImportLegacy(file string, replay string) (SaveSlot, bool, error) {
+	data, err := fs.ReadFile(file)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return SaveSlot{}, false, nil
+		}
+		return SaveSlot{}, false, err
+	}
+
+	save, err := DecodeSavedState(data)
+	if err != nil {
+		return SaveSlot{}, false, fmt.Errorf("can't decode legacy save: %w", err)
+	}
+
+	slot, err := s.Create("Imported Run")
+	if err != nil {
+		return SaveSlot{}, false, err
+	}
+
+	if err := s.SaveState(slot.ID, save); err != nil {
+		return SaveSlot{}, false, err
+	}
+
+	if replayData, err := fs.ReadFile(replay); err == nil {
+		if err := fs.WriteFile(s.ReplayFile(slot.ID), replayData); err != nil {
+			return SaveSlot{}, false, err
+		}
+		_ = fs.Remove(replay)
+	}
+
+	if err := fs.Remove(file); err != nil {
+		return SaveSlot{}, false, err
+	}
+
+	slot.Header, err = s.Header(slot.ID)
+	return slot, true, err
+}
+
+// Header reads only the header of the save slot.
+func (s *SaveSlots) Header(id string) (SaveSlotHeader, error) {
+	header, _, err := s.read(id, false)
+	return header, err
+}
+
+// Save writes the session to the save slot. The name of the slot is kept.
+func (s *SaveSlots) Save(id string, session *Session) error {
+	return s.SaveState(id, session.ToSavedState())
+}
+
+// SaveState writes a saved state to the save slot. The name of the slot is kept.
+func (s *SaveSlots) SaveState(id string, save SavedState) error {
+	name := id
+	if header, err := s.Header(id); err == nil {
+		name = header.Name
+	}
+
+	payload, err := EncodeSavedState(save)
+	if err != nil {
+		return err
+	}
+
+	player := save.Actors[PlayerActorID]
+	return s.write(id, SaveSlotHeader{
+		Name:          name,
+		PlayerHP:      player.HP,
+		PlayerMaxHP:   player.MaxHP,
+		PlayerGold:    player.Gold,
+		StagesCleared: save.StagesCleared,
+		LoadedMods:    save.LoadedMods,
+		PlayTime:      save.PlayTime,
+		GameVersion:   git.Tag,
+		Timestamp:     time.Now(),
+	}, payload)
+}
+
+// Load loads the save slot into the given session.
+func (s *SaveSlots) Load(id string, session *Session) error {
+	payload, err := s.payload(id)
+	if err != nil {
+		return err
+	}
+
+	return session.GobDecode(payload)
+}
+
+// LoadState reads the saved state of the save slot without loading it into a session.
+func (s *SaveSlots) LoadState(id string) (SavedState, error) {
+	payload, err := s.payload(id)
+	if err != nil {
+		return SavedState{}, err
+	}
+
+	return DecodeSavedState(payload)
+}
+
+// Rename changes the name of the save slot.
+func (s *SaveSlots) Rename(id string, name string) error {
+	header, payload, err := s.read(id, true)
+	if err != nil {
+		return err
+	}
+
+	header.Name = name
+	return s.write(id, header, payload)
+}
+
+// Delete removes the save slot and its replay.
+func (s *SaveSlots) Delete(id string) error {
+	if err := fs.Remove(s.file(id)); err != nil {
+		return err
+	}
+
+	if s.exists(id + replaySlotExt) {
+		return fs.Remove(s.ReplayFile(id))
+	}
+
+	return nil
+}
+
+// ReplayFile returns the path of the replay file that belongs to the save slot.
+func (s *SaveSlots) ReplayFile(id string) string {
+	return filepath.Join(s.dir, id+replaySlotExt)
+}
+
+func (s *SaveSlots) file(id string) string {
+	return filepath.Join(s.dir, id+saveSlotExt)
+}
+
+func (s *SaveSlots) exists(name string) bool {
+	if filepath.Ext(name) == "" {
+		name += saveSlotExt
+	}
+
+	entries, err := fs.ReadDir(s.dir)
+	if err != nil {
+		return false
+	}
+
+	return lo.ContainsBy(entries, func(item fs.FileInfo) bool {
+		return filepath.Base(item.Path) == name
+	})
+}
+
+func (s *SaveSlots) payload(id string) ([]byte, error) {
+	_, payload, err := s.read(id, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(payload) == 0 {
+		return nil, errors.New("save slot is empty")
+	}
+
+	return payload, nil
+}
+
+func (s *SaveSlots) read(id string, withPayload bool) (SaveSlotHeader, []byte, error) {
+	data, err := fs.ReadFile(s.file(id))
+	if err != nil {
+		return SaveSlotHeader{}, nil, err
+	}
+
+	var header SaveSlotHeader
+	var payload []byte
+
+	dec := gob.NewDecoder(bytes.NewBuffer(data))
+	if err := dec.Decode(&header); err != nil {
+		return SaveSlotHeader{}, nil, err
+	}
+
+	if withPayload {
+		if err := dec.Decode(&payload); err != nil {
+			return SaveSlotHeader{}, nil, err
+		}
+	}
+
+	return header, payload, nil
+}
+
+func (s *SaveSlots) write(id string, header SaveSlotHeader, payload []byte) error {
+	buf := &bytes.Buffer{}
+	enc := gob.NewEncoder(buf)
+	if err := enc.Encode(header); err != nil {
+		return err
+	}
+	if err := enc.Encode(payload); err != nil {
+		return err
+	}
+
+	return fs.WriteFile(s.file(id), buf.Bytes())
+}
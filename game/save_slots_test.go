@@ -0,0 +1,126 @@
+package game
+
+import (
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSaveSlots(t *testing.T) {
+	slots := NewSaveSlots(t.TempDir())
+
+	slot, err := slots.Create("First Run")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// An empty slot can be listed but not loaded.
+	assert.Error(t, slots.Load(slot.ID, NewSession()))
+
+	session := NewSession(WithSeed(7), WithSaveSlot(slots, slot.ID))
+	session.UpdatePlayer(func(actor *Actor) bool {
+		actor.Gold = 123
+		return true
+	})
+	session.stagesCleared = 3
+	if !assert.NoError(t, slots.Save(slot.ID, session)) {
+		return
+	}
+
+	list, err := slots.List()
+	if !assert.NoError(t, err) || !assert.Len(t, list, 1) {
+		return
+	}
+	assert.Equal(t, "First Run", list[0].Header.Name)
+	assert.Equal(t, 123, list[0].Header.PlayerGold)
+	assert.Equal(t, 3, list[0].Header.StagesCleared)
+	assert.Equal(t, 80, list[0].Header.PlayerMaxHP)
+
+	if !assert.NoError(t, slots.Rename(slot.ID, "Renamed")) {
+		return
+	}
+
+	loaded := NewSession()
+	if !assert.NoError(t, slots.Load(slot.ID, loaded)) {
+		return
+	}
+	assert.Equal(t, 123, loaded.GetPlayer().Gold)
+	assert.Equal(t, 3, loaded.GetStagesCleared())
+
+	header, err := slots.Header(slot.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "Renamed", header.Name)
+
+	assert.NoError(t, slots.Delete(slot.ID))
+	list, err = slots.List()
+	assert.NoError(t, err)
+	assert.Len(t, list, 0)
+}
+
+func TestSaveSlotsBroken(t *testing.T) {
+	dir := t.TempDir()
+	slots := NewSaveSlots(dir)
+
+	slot, err := slots.Create("Good Run")
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "broken"+saveSlotExt), []byte("not a save"), 0644))
+
+	// A broken slot is listed last and doesn't hide the others.
+	list, err := slots.List()
+	if !assert.NoError(t, err) || !assert.Len(t, list, 2) {
+		return
+	}
+	assert.Equal(t, slot.ID, list[0].ID)
+	assert.NoError(t, list[0].Err)
+	assert.Equal(t, "broken", list[1].ID)
+	assert.Error(t, list[1].Err)
+
+	assert.NoError(t, slots.Delete("broken"))
+}
+
+func TestSaveSlotsImportLegacy(t *testing.T) {
+	dir := t.TempDir()
+	slots := NewSaveSlots(filepath.Join(dir, "saves"))
+	assert.NoError(t, os.Mkdir(filepath.Join(dir, "saves"), 0777))
+
+	legacy := filepath.Join(dir, "session.save")
+	replay := filepath.Join(dir, "session.replay")
+
+	_, ok, err := slots.ImportLegacy(legacy, replay)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	session := NewSession(WithSeed(7))
+	session.stagesCleared = 4
+	data, err := session.GobEncode()
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.NoError(t, os.WriteFile(legacy, data, 0644))
+	assert.NoError(t, os.WriteFile(replay, []byte("replay"), 0644))
+
+	slot, ok, err := slots.ImportLegacy(legacy, replay)
+	if !assert.NoError(t, err) || !assert.True(t, ok) {
+		return
+	}
+	assert.Equal(t, "Imported Run", slot.Header.Name)
+	assert.Equal(t, 4, slot.Header.StagesCleared)
+
+	replayData, err := os.ReadFile(slots.ReplayFile(slot.ID))
+	assert.NoError(t, err)
+	assert.Equal(t, "replay", string(replayData))
+
+	loaded := NewSession()
+	assert.NoError(t, slots.Load(slot.ID, loaded))
+	assert.Equal(t, 4, loaded.GetStagesCleared())
+
+	// The legacy save is only imported once.
+	assert.NoFileExists(t, legacy)
+	assert.NoFileExists(t, replay)
+	_, ok, err = slots.ImportLegacy(legacy, replay)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+}
@@ -1,11 +1,98 @@
 package game
 
-import "encoding/gob"
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"github.com/BigJk/end_of_eden/internal/git"
+	"github.com/samber/lo"
+	"path/filepath"
+	"strings"
+	"time"
+)
 
 func init() {
 	gob.Register(SavedState{})
 }
 
+// SaveVersion is the current version of the save format. If a change to SavedState, or any of the types
+// it contains, breaks older saves the version needs to be increased and a migration from the previous
+// version has to be added to saveMigrations.
+const SaveVersion = 2
+
+var (
+	ErrSaveTooNew      = errors.New("save was created by a newer version of the game")
+	ErrSaveMissingMods = errors.New("save uses mods that are not installed")
+)
+
+// SaveMigration upgrades a decoded SavedState from one version to the next. Saves are decoded by field
+// name, so removed fields are already dropped and new fields are zero when the migration runs.
+type SaveMigration func(save *SavedState) error
+
+// saveMigrations contains the migration for each version that upgrades the state to the next version.
+// Old saves are upgraded step by step until they reach the current SaveVersion.
+var saveMigrations = map[int]SaveMigration{
+	// Version 0 saves are plain SavedState payloads without envelope, the state itself is unchanged.
+	0: func(save *SavedState) error {
+		return nil
+	},
+	// Version 1 saves were created before the rules existed and can contain the BLOCK status effect, which
+	// was replaced by the block of the actors.
+	1: func(save *SavedState) error {
+		migrateDefaultRules(save)
+		return migrateBlockStatusEffect(save)
+	},
+}
+
+// migrateSavedState upgrades a saved state of the given version to the current SaveVersion.
+func migrateSavedState(save *SavedState, version int) error {
+	for ; version < SaveVersion; version++ {
+		migration, ok := saveMigrations[version]
+		if !ok {
+			return fmt.Errorf("no migration for save version %d", version)
+		}
+
+		if err := migration(save); err != nil {
+			return fmt.Errorf("migration of save version %d failed: %w", version, err)
+		}
+	}
+
+	return nil
+}
+
+// migrateDefaultRules sets the default rules for the state and the deltas of its checkpoints. The full
+// snapshots of the checkpoints are saved states on their own and are migrated when they are decoded.
+func migrateDefaultRules(save *SavedState) {
+	save.Rules = DefaultRules()
+	for i := range save.StateCheckpoints {
+		if save.StateCheckpoints[i].Delta != nil {
+			save.StateCheckpoints[i].Delta.Rules = DefaultRules()
+		}
+	}
+}
+
+// migrateBlockStatusEffect turns the stacks of BLOCK status effects into block of their owner.
+func migrateBlockStatusEffect(save *SavedState) error {
+	for guid, instance := range save.Instances {
+		effect, ok := instance.(StatusEffectInstance)
+		if !ok || effect.TypeID != "BLOCK" {
+			continue
+		}
+
+		if actor, ok := save.Actors[effect.Owner]; ok {
+			actor.Block += effect.Stacks
+			if actor.StatusEffects != nil {
+				actor.StatusEffects.Remove(guid)
+			}
+			save.Actors[effect.Owner] = actor
+		}
+		delete(save.Instances, guid)
+	}
+
+	return nil
+}
+
 // SavedState represents a save file that don't contain any pointer so the lua
 // runtime or other pointer.
 type SavedState struct {
@@ -20,4 +107,79 @@ type SavedState struct {
 	StateCheckpoints []StateCheckpoint
 	CtxData          map[string]any
 	LoadedMods       []string
+	Seed             int64
+	RandomDraws      uint64
+	ReplayActions    []ReplayAction
+	PlayTime         time.Duration
+	Rules            Rules
+	Difficulty       int
+}
+
+// SaveEnvelope wraps the gob encoded SavedState with the information that is needed to check if it can
+// be loaded before decoding it.
+type SaveEnvelope struct {
+	Version     int
+	GameVersion string
+	LoadedMods  []string
+	Payload     []byte
+}
+
+// EncodeSavedState encodes the saved state wrapped in an envelope with the current save version.
+func EncodeSavedState(save SavedState) ([]byte, error) {
+	payload := &bytes.Buffer{}
+	if err := gob.NewEncoder(payload).Encode(save); err != nil {
+		return nil, err
+	}
+
+	buf := &bytes.Buffer{}
+	err := gob.NewEncoder(buf).Encode(SaveEnvelope{
+		Version:     SaveVersion,
+		GameVersion: git.Tag,
+		LoadedMods:  save.LoadedMods,
+		Payload:     payload.Bytes(),
+	})
+	return buf.Bytes(), err
+}
+
+// DecodeSavedState decodes a saved state that was encoded with EncodeSavedState. Saves of older versions
+// are migrated to the current version. Saves without envelope are treated as version 0.
+func DecodeSavedState(data []byte) (SavedState, error) {
+	var envelope SaveEnvelope
+	if err := gob.NewDecoder(bytes.NewBuffer(data)).Decode(&envelope); err != nil || len(envelope.Payload) == 0 {
+		envelope = SaveEnvelope{Version: 0, Payload: data}
+	}
+
+	if envelope.Version > SaveVersion {
+		return SavedState{}, fmt.Errorf("%w (save version %d, supported %d, game version %s)", ErrSaveTooNew, envelope.Version, SaveVersion, envelope.GameVersion)
+	}
+
+	var save SavedState
+	if err := gob.NewDecoder(bytes.NewBuffer(envelope.Payload)).Decode(&save); err != nil {
+		return SavedState{}, err
+	}
+
+	if err := migrateSavedState(&save, envelope.Version); err != nil {
+		return SavedState{}, err
+	}
+
+	return save, nil
+}
+
+// checkMods returns an error if any of the mods is not installed.
+func checkMods(mods []string) error {
+	// Archives that can't be mounted are reported as missing below.
+	if len(mods) > 0 {
+		_ = MountModArchives(mods...)
+	}
+
+	missing := lo.Filter(mods, func(item string, index int) bool {
+		_, err := ModDescription(filepath.Join("./mods", item))
+		return err != nil
+	})
+
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: %s", ErrSaveMissingMods, strings.Join(missing, ", "))
+	}
+
+	return nil
 }
@@ -0,0 +1,353 @@
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/samber/lo"
+	"reflect"
+	"time"
+)
+
+// jsonTypeTags maps the type tags of the json save format to the types that can be stored in the
+// polymorphic fields of a save, like the instances or the events of a checkpoint.
+var jsonTypeTags = map[string]reflect.Type{
+	"card":                   reflect.TypeOf(CardInstance{}),
+	"artifact":               reflect.TypeOf(ArtifactInstance{}),
+	"status_effect":          reflect.TypeOf(StatusEffectInstance{}),
+	"event_death":            reflect.TypeOf(StateEventDeathData{}),
+	"event_damage":           reflect.TypeOf(StateEventDamageData{}),
+	"event_heal":             reflect.TypeOf(StateEventHealData{}),
+	"event_money":            reflect.TypeOf(StateEventMoneyData{}),
+	"event_artifact_added":   reflect.TypeOf(StateEventArtifactAddedData{}),
+	"event_artifact_removed": reflect.TypeOf(StateEventArtifactRemovedData{}),
+	"event_card_added":       reflect.TypeOf(StateEventCardAddedData{}),
+	"event_card_removed":     reflect.TypeOf(StateEventCardRemovedData{}),
+	"event_cast_hand":        reflect.TypeOf(StateEventCastHandData{}),
+}
+
+// jsonSavedState is the json representation of SavedState.
+type jsonSavedState struct {
+	Version          int                        `json:"version"`
+	State            GameState                  `json:"state"`
+	Actors           map[string]Actor           `json:"actors"`
+	Instances        map[string]json.RawMessage `json:"instances"`
+	StagesCleared    int                        `json:"stages_cleared"`
+	CurrentEvent     string                     `json:"current_event"`
+	CurrentFight     FightState                 `json:"current_fight"`
+	Merchant         MerchantState              `json:"merchant"`
+	EventHistory     []string                   `json:"event_history"`
+	StateCheckpoints []StateCheckpoint          `json:"state_checkpoints"`
+	CtxData          map[string]any             `json:"ctx_data"`
+	LoadedMods       []string                   `json:"loaded_mods"`
+	Seed             int64                      `json:"seed"`
+	RandomDraws      uint64                     `json:"random_draws"`
+	ReplayActions    []ReplayAction             `json:"replay_actions"`
+	PlayTime         time.Duration              `json:"play_time"`
+	Rules            Rules                      `json:"rules"`
+	Difficulty       int                        `json:"difficulty"`
+}
+
+// jsonStateCheckpoint is the json representation of StateCheckpoint.
+type jsonStateCheckpoint struct {
+	State  *SavedState                    `json:"state,omitempty"`
+	Delta  *jsonStateDelta                `json:"delta,omitempty"`
+	Events map[StateEvent]json.RawMessage `json:"events"`
+}
+
+// jsonStateDelta is the json representation of StateDelta.
+type jsonStateDelta struct {
+	State            GameState                  `json:"state"`
+	StagesCleared    int                        `json:"stages_cleared"`
+	CurrentEvent     string                     `json:"current_event"`
+	CurrentFight     *FightState                `json:"current_fight,omitempty"`
+	Merchant         *MerchantState             `json:"merchant,omitempty"`
+	EventHistoryFrom int                        `json:"event_history_from,omitempty"`
+	EventHistory     []string                   `json:"event_history"`
+	Rules            Rules                      `json:"rules"`
+	CtxData          map[string]any             `json:"ctx_data,omitempty"`
+	Actors           map[string]Actor           `json:"actors"`
+	RemovedActors    []string                   `json:"removed_actors"`
+	Instances        map[string]json.RawMessage `json:"instances"`
+	RemovedInstances []string                   `json:"removed_instances"`
+}
+
+// MarshalJSON encodes the saved state in a human-readable form. Polymorphic values are tagged with their type.
+func (s SavedState) MarshalJSON() ([]byte, error) {
+	instances, err := marshalTaggedMap(s.Instances)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(jsonSavedState{
+		Version:          SaveVersion,
+		State:            s.State,
+		Actors:           s.Actors,
+		Instances:        instances,
+		StagesCleared:    s.StagesCleared,
+		CurrentEvent:     s.CurrentEvent,
+		CurrentFight:     s.CurrentFight,
+		Merchant:         s.Merchant,
+		EventHistory:     s.EventHistory,
+		StateCheckpoints: s.StateCheckpoints,
+		CtxData:          lo.MapValues(s.CtxData, func(value any, key string) any { return toJSONValue(value) }),
+		LoadedMods:       s.LoadedMods,
+		Seed:             s.Seed,
+		RandomDraws:      s.RandomDraws,
+		ReplayActions:    s.ReplayActions,
+		PlayTime:         s.PlayTime,
+		Rules:            s.Rules,
+		Difficulty:       s.Difficulty,
+	})
+}
+
+// UnmarshalJSON decodes a saved state that was encoded with MarshalJSON. Saves of older versions are migrated
+// to the current version like gob saves.
+func (s *SavedState) UnmarshalJSON(data []byte) error {
+	var saved jsonSavedState
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return err
+	}
+
+	if saved.Version > SaveVersion {
+		return fmt.Errorf("%w (save version %d, supported %d)", ErrSaveTooNew, saved.Version, SaveVersion)
+	}
+
+	instances, err := unmarshalTaggedMap(saved.Instances)
+	if err != nil {
+		return err
+	}
+
+	*s = SavedState{
+		State:            saved.State,
+		Actors:           saved.Actors,
+		Instances:        instances,
+		StagesCleared:    saved.StagesCleared,
+		CurrentEvent:     saved.CurrentEvent,
+		CurrentFight:     saved.CurrentFight,
+		Merchant:         saved.Merchant,
+		EventHistory:     saved.EventHistory,
+		StateCheckpoints: saved.StateCheckpoints,
+		CtxData:          lo.MapValues(saved.CtxData, func(value any, key string) any { return fromJSONValue(value) }),
+		LoadedMods:       saved.LoadedMods,
+		Seed:             saved.Seed,
+		RandomDraws:      saved.RandomDraws,
+		ReplayActions:    saved.ReplayActions,
+		PlayTime:         saved.PlayTime,
+		Rules:            saved.Rules,
+		Difficulty:       saved.Difficulty,
+	}
+
+	if s.Actors == nil {
+		s.Actors = map[string]Actor{}
+	}
+	if s.CtxData == nil {
+		s.CtxData = map[string]any{}
+	}
+
+	return migrateSavedState(s, saved.Version)
+}
+
+func (c StateCheckpoint) MarshalJSON() ([]byte, error) {
+	events, err := marshalTaggedMap(c.Events)
+	if err != nil {
+		return nil, err
+	}
+
+	checkpoint := jsonStateCheckpoint{Events: events}
+	if c.Session != nil {
+		state := c.Session.ToSavedState()
+		checkpoint.State = &state
+	}
+	if c.Delta != nil {
+		instances, err := marshalTaggedMap(c.Delta.Instances)
+		if err != nil {
+			return nil, err
+		}
+
+		checkpoint.Delta = &jsonStateDelta{
+			State:            c.Delta.State,
+			StagesCleared:    c.Delta.StagesCleared,
+			CurrentEvent:     c.Delta.CurrentEvent,
+			CurrentFight:     c.Delta.CurrentFight,
+			Merchant:         c.Delta.Merchant,
+			EventHistoryFrom: c.Delta.EventHistoryFrom,
+			EventHistory:     c.Delta.EventHistory,
+			Rules:            c.Delta.Rules,
+			CtxData:          lo.MapValues(c.Delta.CtxData, func(value any, key string) any { return toJSONValue(value) }),
+			Actors:           c.Delta.Actors,
+			RemovedActors:    c.Delta.RemovedActors,
+			Instances:        instances,
+			RemovedInstances: c.Delta.RemovedInstances,
+		}
+	}
+
+	return json.Marshal(checkpoint)
+}
+
+func (c *StateCheckpoint) UnmarshalJSON(data []byte) error {
+	var checkpoint jsonStateCheckpoint
+	if err := json.Unmarshal(data, &checkpoint); err != nil {
+		return err
+	}
+
+	events, err := unmarshalTaggedMap(checkpoint.Events)
+	if err != nil {
+		return err
+	}
+	*c = StateCheckpoint{Events: events}
+
+	// Like with gob, the checkpoint session only holds the game data until it is loaded into a session.
+	if checkpoint.State != nil {
+		c.Session = &Session{}
+		c.Session.applySavedState(*checkpoint.State)
+	}
+	if checkpoint.Delta != nil {
+		instances, err := unmarshalTaggedMap(checkpoint.Delta.Instances)
+		if err != nil {
+			return err
+		}
+
+		c.Delta = &StateDelta{
+			State:            checkpoint.Delta.State,
+			StagesCleared:    checkpoint.Delta.StagesCleared,
+			CurrentEvent:     checkpoint.Delta.CurrentEvent,
+			CurrentFight:     checkpoint.Delta.CurrentFight,
+			Merchant:         checkpoint.Delta.Merchant,
+			EventHistoryFrom: checkpoint.Delta.EventHistoryFrom,
+			EventHistory:     checkpoint.Delta.EventHistory,
+			Rules:            checkpoint.Delta.Rules,
+			Actors:           checkpoint.Delta.Actors,
+			RemovedActors:    checkpoint.Delta.RemovedActors,
+			Instances:        instances,
+			RemovedInstances: checkpoint.Delta.RemovedInstances,
+		}
+		if checkpoint.Delta.CtxData != nil {
+			c.Delta.CtxData = lo.MapValues(checkpoint.Delta.CtxData, func(value any, key string) any { return fromJSONValue(value) })
+		}
+	}
+
+	return nil
+}
+
+// ExportJSON exports the saved state of the session as indented json.
+func (s *Session) ExportJSON() ([]byte, error) {
+	return json.MarshalIndent(s.ToSavedState(), "", "\t")
+}
+
+// ImportJSON loads a saved state that was exported with ExportJSON.
+func (s *Session) ImportJSON(data []byte) error {
+	var saved SavedState
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return err
+	}
+
+	if err := checkMods(saved.LoadedMods); err != nil {
+		return err
+	}
+
+	s.LoadSavedState(saved)
+	return nil
+}
+
+// marshalTagged encodes the value as json object with an additional type field.
+func marshalTagged(value any) (json.RawMessage, error) {
+	tag, ok := lo.FindKeyBy(jsonTypeTags, func(key string, t reflect.Type) bool {
+		return t == reflect.TypeOf(value)
+	})
+	if !ok {
+		return nil, fmt.Errorf("type %T has no json type tag", value)
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields map[string]any
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	fields["type"] = tag
+
+	return json.Marshal(fields)
+}
+
+// unmarshalTagged decodes a json object that was encoded with marshalTagged.
+func unmarshalTagged(data json.RawMessage) (any, error) {
+	var tagged struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(data, &tagged); err != nil {
+		return nil, err
+	}
+
+	t, ok := jsonTypeTags[tagged.Type]
+	if !ok {
+		return nil, fmt.Errorf("unknown type tag '%s'", tagged.Type)
+	}
+
+	value := reflect.New(t)
+	if err := json.Unmarshal(data, value.Interface()); err != nil {
+		return nil, err
+	}
+
+	return value.Elem().Interface(), nil
+}
+
+func marshalTaggedMap[K comparable](m map[K]any) (map[K]json.RawMessage, error) {
+	res := map[K]json.RawMessage{}
+	for k, v := range m {
+		data, err := marshalTagged(v)
+		if err != nil {
+			return nil, fmt.Errorf("%v: %w", k, err)
+		}
+		res[k] = data
+	}
+	return res, nil
+}
+
+func unmarshalTaggedMap[K comparable](m map[K]json.RawMessage) (map[K]any, error) {
+	res := map[K]any{}
+	for k, v := range m {
+		value, err := unmarshalTagged(v)
+		if err != nil {
+			return nil, fmt.Errorf("%v: %w", k, err)
+		}
+		res[k] = value
+	}
+	return res, nil
+}
+
+// toJSONValue converts the lua tables in a stored value to maps that can be encoded as json.
+func toJSONValue(value any) any {
+	switch value := value.(type) {
+	case map[any]any:
+		res := map[string]any{}
+		for k, v := range value {
+			res[fmt.Sprint(k)] = toJSONValue(v)
+		}
+		return res
+	case []any:
+		return lo.Map(value, func(item any, index int) any {
+			return toJSONValue(item)
+		})
+	}
+	return value
+}
+
+// fromJSONValue converts decoded json objects back to the map type that lua tables are stored as.
+func fromJSONValue(value any) any {
+	switch value := value.(type) {
+	case map[string]any:
+		res := map[any]any{}
+		for k, v := range value {
+			res[k] = fromJSONValue(v)
+		}
+		return res
+	case []any:
+		return lo.Map(value, func(item any, index int) any {
+			return fromJSONValue(item)
+		})
+	}
+	return value
+}
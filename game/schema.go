@@ -0,0 +1,284 @@
+package game
+
+import (
+	"fmt"
+	"github.com/samber/lo"
+	lua "github.com/yuin/gopher-lua"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// schemaField describes the expected value of a field in a content definition.
+type schemaField struct {
+	// Type is the lua type of the value.
+	Type lua.LValueType
+
+	// Required fields have to be present.
+	Required bool
+
+	// Enum contains the allowed values of a string field.
+	Enum []string
+
+	// Fields is the schema of a table value with fixed keys.
+	Fields schema
+
+	// Elem is the schema of the elements if the value is an array.
+	Elem *schemaField
+}
+
+// schema describes the fields of a content definition table. Definitions are checked against their schema
+// on registration, so that typos and wrong types fail on load instead of being silently ignored.
+type schema map[string]schemaField
+
+func schemaString() schemaField   { return schemaField{Type: lua.LTString} }
+func schemaNumber() schemaField   { return schemaField{Type: lua.LTNumber} }
+func schemaBool() schemaField     { return schemaField{Type: lua.LTBool} }
+func schemaFunction() schemaField { return schemaField{Type: lua.LTFunction} }
+
+func schemaArray(elem schemaField) schemaField {
+	return schemaField{Type: lua.LTTable, Elem: &elem}
+}
+
+func schemaTable(fields schema) schemaField {
+	return schemaField{Type: lua.LTTable, Fields: fields}
+}
+
+func (f schemaField) required() schemaField {
+	f.Required = true
+	return f
+}
+
+// schemaCallbacks is the schema of the callbacks table. All callbacks are optional functions.
+func schemaCallbacks() schemaField {
+	fields := schema{}
+	for _, name := range Callbacks {
+		fields[toSnakeCase(name)] = schemaFunction()
+	}
+	return schemaTable(fields)
+}
+
+var (
+	artifactSchema = schema{
+		"id":          schemaString(),
+		"name":        schemaString(),
+		"description": schemaString(),
+		"tags":        schemaArray(schemaString()),
+		"order":       schemaNumber(),
+		"price":       schemaNumber(),
+		"callbacks":   schemaCallbacks(),
+		"test":        schemaFunction(),
+	}
+
+	cardSchema = schema{
+		"id":           schemaString(),
+		"name":         schemaString().required(),
+		"description":  schemaString(),
+		"tags":         schemaArray(schemaString()),
+		"state":        schemaFunction(),
+		"color":        schemaString(),
+		"point_cost":   schemaNumber(),
+		"max_level":    schemaNumber(),
+		"does_exhaust": schemaBool(),
+		"does_consume": schemaBool(),
+		"need_target":  schemaBool(),
+		"retain":       schemaBool(),
+		"innate":       schemaBool(),
+		"ethereal":     schemaBool(),
+		"unplayable":   schemaBool(),
+		"price":        schemaNumber(),
+		"callbacks":    schemaCallbacks(),
+		"test":         schemaFunction(),
+	}
+
+	enemySchema = schema{
+		"id":          schemaString(),
+		"name":        schemaString().required(),
+		"description": schemaString(),
+		"initial_hp":  schemaNumber(),
+		"max_hp":      schemaNumber(),
+		"gold":        schemaNumber(),
+		"look":        schemaString(),
+		"color":       schemaString(),
+		"intend":      schemaFunction(),
+		"callbacks":   schemaCallbacks(),
+		"test":        schemaFunction(),
+	}
+
+	eventSchema = schema{
+		"id":          schemaString(),
+		"name":        schemaString().required(),
+		"description": schemaString(),
+		"tags":        schemaArray(schemaString()),
+		"choices": schemaArray(schemaTable(schema{
+			"description":    schemaString(),
+			"description_fn": schemaFunction(),
+			"callback":       schemaFunction(),
+		})),
+		"on_enter": schemaFunction(),
+		"on_end":   schemaFunction(),
+		"test":     schemaFunction(),
+	}
+
+	statusEffectSchema = schema{
+		"id":          schemaString(),
+		"name":        schemaString().required(),
+		"description": schemaString(),
+		"state":       schemaFunction(),
+		"look":        schemaString(),
+		"foreground":  schemaString(),
+		"order":       schemaNumber(),
+		"can_stack":   schemaBool(),
+		"decay":       schemaField{Type: lua.LTString, Enum: []string{string(DecayAll), string(DecayOne), string(DecayNone)}},
+		"rounds":      schemaNumber(),
+		"callbacks":   schemaCallbacks(),
+		"test":        schemaFunction(),
+	}
+
+	storyTellerSchema = schema{
+		"id":     schemaString(),
+		"active": schemaFunction().required(),
+		"decide": schemaFunction().required(),
+	}
+
+	difficultySchema = schema{
+		"id":             schemaString(),
+		"level":          schemaNumber().required(),
+		"name":           schemaString().required(),
+		"description":    schemaString(),
+		"enemy_hp":       schemaNumber(),
+		"gold":           schemaNumber(),
+		"merchant_price": schemaNumber(),
+		"enemy_block":    schemaNumber(),
+		"enemy_status_effects": schemaArray(schemaTable(schema{
+			"id":     schemaString().required(),
+			"stacks": schemaNumber(),
+		})),
+	}
+)
+
+// validate checks the table against the schema and returns a problem for each field that doesn't match.
+// Each problem contains the path of the field, e.g. 'callbacks.on_cats'.
+func (sc schema) validate(tbl *lua.LTable, path string) []string {
+	var problems []string
+
+	tbl.ForEach(func(key lua.LValue, value lua.LValue) {
+		name, ok := key.(lua.LString)
+		if !ok {
+			problems = append(problems, fmt.Sprintf("key %s in '%s' is not a string", key.String(), strings.TrimSuffix(path, ".")))
+			return
+		}
+
+		field, ok := sc[string(name)]
+		if !ok {
+			if suggestion := sc.closest(string(name)); len(suggestion) > 0 {
+				problems = append(problems, fmt.Sprintf("field '%s%s' is unknown, did you mean '%s'?", path, name, suggestion))
+			} else {
+				problems = append(problems, fmt.Sprintf("field '%s%s' is unknown", path, name))
+			}
+			return
+		}
+
+		problems = append(problems, field.validate(value, path+string(name))...)
+	})
+
+	for _, name := range lo.Keys(sc) {
+		if sc[name].Required && tbl.RawGetString(name) == lua.LNil {
+			problems = append(problems, fmt.Sprintf("field '%s%s' is required", path, name))
+		}
+	}
+
+	sort.Strings(problems)
+	return problems
+}
+
+func (f schemaField) validate(value lua.LValue, path string) []string {
+	if value.Type() != f.Type {
+		return []string{fmt.Sprintf("field '%s' should be a %s but is a %s", path, f.Type, value.Type())}
+	}
+
+	if len(f.Enum) > 0 && !lo.Contains(f.Enum, value.String()) {
+		return []string{fmt.Sprintf("field '%s' should be one of %s but is '%s'", path, strings.Join(f.Enum, ", "), value.String())}
+	}
+
+	tbl, ok := value.(*lua.LTable)
+	if !ok {
+		return nil
+	}
+
+	switch {
+	case f.Elem != nil:
+		var problems []string
+		tbl.ForEach(func(key lua.LValue, value lua.LValue) {
+			if _, ok := key.(lua.LNumber); !ok {
+				problems = append(problems, fmt.Sprintf("field '%s' should be an array but has the key %s", path, key.String()))
+				return
+			}
+			problems = append(problems, f.Elem.validate(value, fmt.Sprintf("%s[%s]", path, key.String()))...)
+		})
+		return problems
+	case f.Fields != nil:
+		return f.Fields.validate(tbl, path+".")
+	}
+
+	return nil
+}
+
+// closest returns the known field that is most likely meant by a misspelled field name. If no field is
+// close enough an empty string is returned.
+func (sc schema) closest(name string) string {
+	fields := lo.Keys(sc)
+	sort.Strings(fields)
+
+	best := ""
+	bestDist := 3
+	for _, field := range fields {
+		if dist := levenshtein(name, field); dist < bestDist {
+			best = field
+			bestDist = dist
+		}
+	}
+	return best
+}
+
+// validateRegister validates the definition table of a register_* call. If the definition doesn't match
+// the schema a lua error is raised that contains the location of the call and all problems.
+func validateRegister(l *lua.LState, kind string, sc schema) {
+	problems := sc.validate(l.CheckTable(2), "")
+	if len(problems) == 0 {
+		return
+	}
+
+	l.RaiseError("invalid %s '%s':\n\t%s", kind, l.ToString(1), strings.Join(problems, "\n\t"))
+}
+
+var snakeRegex = regexp.MustCompile(`[A-Z]`)
+
+// toSnakeCase converts upper camel case to snake case, e.g. OnCast to on_cast.
+func toSnakeCase(s string) string {
+	return strings.TrimPrefix(snakeRegex.ReplaceAllStringFunc(s, func(s string) string {
+		return "_" + strings.ToLower(s)
+	}), "_")
+}
+
+func levenshtein(a string, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = lo.Min([]int{prev[j] + 1, cur[j-1] + 1, prev[j-1] + cost})
+		}
+		prev = cur
+	}
+
+	return prev[len(b)]
+}
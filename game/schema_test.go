@@ -0,0 +1,49 @@
+package game
+
+import (
+	"github.com/BigJk/end_of_eden/internal/lua/ludoc"
+	"github.com/stretchr/testify/assert"
+	lua "github.com/yuin/gopher-lua"
+	"io"
+	"log"
+	"testing"
+)
+
+var TestSchemaLua = `
+
+register_card("MELEE_HIT",
+    {
+        name = "Melee Hit",
+        need_targt = true,
+        point_cost = "1",
+        tags = { "ATK", 2 },
+        callbacks = {
+            on_cats = function(ctx)
+                return nil
+            end,
+        }
+    }
+);
+
+`
+
+func TestSchema(t *testing.T) {
+	s := lua.NewState()
+	man := NewResourcesManager(s, ludoc.New(), log.New(io.Discard, "", 0))
+
+	err := doScript(s, "melee_hit.lua", []byte(TestSchemaLua))
+	if !assert.Error(t, err) {
+		return
+	}
+
+	assert.Contains(t, err.Error(), "melee_hit.lua:3: invalid card 'MELEE_HIT'")
+	assert.Contains(t, err.Error(), "field 'need_targt' is unknown, did you mean 'need_target'?")
+	assert.Contains(t, err.Error(), "field 'point_cost' should be a number but is a string")
+	assert.Contains(t, err.Error(), "field 'tags[2]' should be a string but is a number")
+	assert.Contains(t, err.Error(), "field 'callbacks.on_cats' is unknown, did you mean 'on_cast'?")
+	assert.NotContains(t, man.Cards, "MELEE_HIT")
+
+	// Valid definitions still register
+	assert.NoError(t, s.DoString(TestCardLua))
+	assert.Contains(t, man.Cards, "MELEE_HIT")
+}
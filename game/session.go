@@ -1,13 +1,14 @@
 package game
 
 import (
-	"bytes"
 	"context"
 	"encoding/gob"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/BigJk/end_of_eden/internal/fs"
 	"github.com/BigJk/end_of_eden/internal/lua/ludoc"
+	"github.com/BigJk/end_of_eden/internal/lua/luhelp"
 	"github.com/BigJk/end_of_eden/system/gen"
 	"github.com/BigJk/end_of_eden/system/gen/faces"
 	"github.com/BigJk/end_of_eden/system/localization"
@@ -28,9 +29,13 @@ import (
 	"runtime"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 )
 
+// recentLuaErrorsMax is the number of lua errors that are kept for RecentLuaErrors.
+const recentLuaErrorsMax = 50
+
 func init() {
 	gob.Register(FightState{})
 	gob.Register(MerchantState{})
@@ -45,8 +50,10 @@ const (
 	GameStateEvent    = GameState("EVENT")
 	GameStateRandom   = GameState("RANDOM")
 	GameStateGameOver = GameState("GAME_OVER")
+	GameStateVictory  = GameState("VICTORY")
 )
 
+// The default values of the rules. The values of a running session are in its Rules.
 const (
 	// DefaultUpgradeCost is the default cost for upgrading a card.
 	DefaultUpgradeCost = 65
@@ -54,10 +61,10 @@ const (
 	// DefaultRemoveCost is the default cost for removing a card.
 	DefaultRemoveCost = 50
 
-	// PointsPerRound is the amount of points the player gets per round.
+	// PointsPerRound is the default amount of points the player gets per round.
 	PointsPerRound = 3
 
-	// DrawSize is the amount of cards the player draws per round.
+	// DrawSize is the default amount of cards the player draws per round.
 	DrawSize = 3
 )
 
@@ -79,6 +86,15 @@ type FightState struct {
 	Exhausted     []string
 }
 
+// Clone returns a copy of the fight state that doesn't share the card slices with the original.
+func (f FightState) Clone() FightState {
+	f.Deck = slices.Clone(f.Deck)
+	f.Hand = slices.Clone(f.Hand)
+	f.Used = slices.Clone(f.Used)
+	f.Exhausted = slices.Clone(f.Exhausted)
+	return f
+}
+
 // MerchantState represents the current state of the merchant.
 type MerchantState struct {
 	Face      string
@@ -96,6 +112,21 @@ type LuaError struct {
 	Err      error
 }
 
+// MarshalJSON marshals the lua error with the error as message, as errors don't marshal to JSON on their own.
+func (e LuaError) MarshalJSON() ([]byte, error) {
+	msg := ""
+	if e.Err != nil {
+		msg = e.Err.Error()
+	}
+	return json.Marshal(struct {
+		File     string
+		Line     int
+		Callback string
+		Type     string
+		Err      string
+	}{e.File, e.Line, e.Callback, e.Type, msg})
+}
+
 // Session represents the state inside a game session.
 type Session struct {
 	log       *log.Logger
@@ -112,14 +143,40 @@ type Session struct {
 	merchant      MerchantState
 	eventHistory  []string
 	randomHistory []string
+	rules         Rules
+	difficulty    int
 	ctxData       map[string]any
 	hooks         map[Hook][]func()
-
-	loadedMods       []string
-	stateCheckpoints []StateCheckpoint
-	closer           []func() error
-	onLuaError       func(file string, line int, callback string, typeId string, err error)
-	luaErrors        chan LuaError
+	rng           *countingSource
+	rnd           *rand.Rand
+
+	loadedMods              []string
+	stateCheckpoints        []StateCheckpoint
+	checkpointsCompacted    int
+	checkpointsPruned       int
+	fightCheckpoint         int
+	undoFloor               int
+	checkpointSnapshotEvery int
+	closer                  []func() error
+	replayActions           []ReplayAction
+	replayFile              string
+	actionDepth             int
+	saveSlots               *SaveSlots
+	saveSlotID              string
+	playTime                time.Duration
+	playStart               time.Time
+	onLuaError              func(file string, line int, callback string, typeId string, err error)
+	luaErrors               chan LuaError
+	recentLuaErrors         []LuaError
+	recentLuaErrorsMtx      *sync.Mutex
+	events                  *EventBus
+	hotReload               *hotReloader
+	mtx                     *sync.RWMutex
+	luaLimits               luhelp.Limits
+	profiler                *luhelp.Profiler
+	profile                 Profile
+	profileFile             string
+	debugConfig             *DebugConfig
 
 	Logs []LogEntry
 }
@@ -137,13 +194,20 @@ func NewSession(options ...func(s *Session)) *Session {
 		hooks: map[Hook][]func(){
 			HookNextFightEnd: {},
 		},
-		stagesCleared: 0,
-		onLuaError:    nil,
-		luaErrors:     make(chan LuaError, 25),
-		eventHistory:  []string{},
-		randomHistory: []string{},
+		stagesCleared:      0,
+		onLuaError:         nil,
+		luaErrors:          make(chan LuaError, 25),
+		recentLuaErrorsMtx: &sync.Mutex{},
+		mtx:                &sync.RWMutex{},
+		events:             &EventBus{},
+		eventHistory:       []string{},
+		randomHistory:      []string{},
+		rules:              DefaultRules(),
+		playStart:          time.Now(),
+		luaLimits:          DefaultLuaLimits,
 	}
 	session.SetOnLuaError(nil)
+	session.setSeed(newSeed())
 
 	session.luaState, session.luaDocs = SessionAdapter(session)
 
@@ -154,6 +218,17 @@ func NewSession(options ...func(s *Session)) *Session {
 		options[i](session)
 	}
 
+	luhelp.SetLimits(session.luaState, session.luaLimits)
+	if session.profiler != nil {
+		luhelp.SetProfiler(session.luaState, session.profiler)
+	}
+
+	if session.hotReload != nil {
+		session.closer = append(session.closer, session.hotReload.start(session.log))
+		session.hotReload.add(session.log, "./assets/scripts")
+		session.hotReload.add(session.log, "./assets/locals")
+	}
+
 	session.resources = NewResourcesManager(session.luaState, session.luaDocs, session.log)
 	session.resources.MarkBaseGame()
 	session.loadMods(session.loadedMods)
@@ -163,24 +238,63 @@ func NewSession(options ...func(s *Session)) *Session {
 	session.UpdatePlayer(func(actor *Actor) bool {
 		actor.HP = 80
 		actor.MaxHP = 80
-		actor.Gold = 50 + rand.Intn(50)
+		actor.Gold = 50 + session.rnd.Intn(50)
 		return true
 	})
 
 	session.SetEvent("START")
 
+	// The debug server is started last, as it accesses the session as soon as it runs.
+	if session.debugConfig != nil {
+		session.closer = append(session.closer, ExposeDebug(*session.debugConfig, session, session.luaState, session.log))
+	}
+
 	return session
 }
 
 // WithDebugEnabled enables the lua debugging. With lua debugging a server will be started
-// on the given bind port. This exposes the /ws route to connect over websocket to. In essence,
+// on the given bind port of localhost. This exposes the /ws route to connect over websocket to. In essence,
 // it exposes REPL access to the internal lua state which is helpful to debug problems. You can use
-// the debug_r function to send data back to the websocket.
+// the debug_r function to send data back to the websocket. Read-only JSON endpoints under /api return the
+// current state, e.g. /api/fight, /api/logs or /api/errors. A random token is generated and written to the
+// session log, see DebugConfig on how to send it.
 //
 // Tip: Use https://github.com/websockets/wscat to connect and talk with it.
 func WithDebugEnabled(port int) func(s *Session) {
+	return WithDebugConfig(DebugConfig{Bind: fmt.Sprintf("127.0.0.1:%d", port)})
+}
+
+// WithDebugConfig enables the lua debugging like WithDebugEnabled, but with full control over the bind
+// address, token and read-only mode. The server is started once the session is set up.
+func WithDebugConfig(config DebugConfig) func(s *Session) {
+	return func(s *Session) {
+		s.debugConfig = &config
+	}
+}
+
+// WithHotReload watches the lua scripts and locals of the base game and the loaded mods. Changed files are
+// re-run against the live resources when ApplyHotReload is called, so definitions are replaced while running
+// actors and instances keep working by their type id.
+func WithHotReload() func(s *Session) {
 	return func(s *Session) {
-		s.closer = append(s.closer, ExposeDebug(port, s, s.luaState, s.log))
+		s.hotReload = &hotReloader{}
+	}
+}
+
+// WithLuaLimits sets the limits of a single call into lua, e.g. a callback. A call that exceeds a limit is
+// stopped and reported as lua error. Zero values disable the limit. By default DefaultLuaLimits is used.
+func WithLuaLimits(limits luhelp.Limits) func(s *Session) {
+	return func(s *Session) {
+		s.luaLimits = limits
+	}
+}
+
+// WithProfiling measures the time and allocations of all content callbacks. The report can be fetched with
+// Profiler, the profile_report lua function or the debug api and is written to the log when the session
+// is closed.
+func WithProfiling() func(s *Session) {
+	return func(s *Session) {
+		s.profiler = luhelp.NewProfiler()
 	}
 }
 
@@ -198,6 +312,60 @@ func WithMods(mods []string) func(s *Session) {
 	}
 }
 
+// WithDifficulty sets the difficulty level of the run. See Difficulty.
+func WithDifficulty(level int) func(s *Session) {
+	return func(s *Session) {
+		s.difficulty = level
+	}
+}
+
+// WithProfile sets the file of the player profile. Won runs are counted in the profile and unlock the
+// next difficulty level.
+func WithProfile(file string) func(s *Session) {
+	return func(s *Session) {
+		s.profileFile = file
+
+		profile, err := LoadProfile(file)
+		if err != nil {
+			s.log.Println("Error loading profile:", err)
+		}
+		s.profile = profile
+	}
+}
+
+// WithSeed sets the seed of the session. All randomness of the session, including the lua math.random,
+// is derived from this seed, so the same seed and the same inputs will always result in the same run.
+func WithSeed(seed int64) func(s *Session) {
+	return func(s *Session) {
+		s.setSeed(seed)
+	}
+}
+
+// WithCheckpointSnapshotEvery sets after how many checkpoints a full snapshot of the session is kept. All
+// checkpoints in between only store the changes to the previous one. Checkpoints are compacted on each
+// fight setup and when saving.
+func WithCheckpointSnapshotEvery(n int) func(s *Session) {
+	return func(s *Session) {
+		s.checkpointSnapshotEvery = n
+	}
+}
+
+// WithSaveSlot sets the save slot the session is saved to after each fight setup.
+func WithSaveSlot(slots *SaveSlots, id string) func(s *Session) {
+	return func(s *Session) {
+		s.saveSlots = slots
+		s.saveSlotID = id
+	}
+}
+
+// WithReplayFile sets the file the replay of the session is written to. The replay is written after each
+// fight setup, together with the save file, and when the session is closed.
+func WithReplayFile(file string) func(s *Session) {
+	return func(s *Session) {
+		s.replayFile = file
+	}
+}
+
 // WithOnLuaError sets the function that will be called when a lua error happens.
 func WithOnLuaError(fn func(file string, line int, callback string, typeId string, err error)) func(s *Session) {
 	return func(s *Session) {
@@ -214,6 +382,16 @@ func (s *Session) SetOnLuaError(fn func(file string, line int, callback string,
 	}
 }
 
+// GetPlayTime returns the total time played in this session, including the time from previous saves.
+func (s *Session) GetPlayTime() time.Duration {
+	return s.playTime + time.Since(s.playStart)
+}
+
+// GetSeed returns the seed of the session.
+func (s *Session) GetSeed() int64 {
+	return s.rng.seed
+}
+
 // LuaDocs returns the documentation of the lua state.
 func (s *Session) LuaDocs() *ludoc.Docs {
 	return s.luaDocs
@@ -221,6 +399,12 @@ func (s *Session) LuaDocs() *ludoc.Docs {
 
 // Close closes the internal lua state and everything else.
 func (s *Session) Close() {
+	s.writeReplay()
+	if s.profiler != nil {
+		sb := &strings.Builder{}
+		_ = s.profiler.WriteReport(sb, 50)
+		s.log.Printf("Lua profile:\n%s", sb.String())
+	}
 	for i := range s.closer {
 		if err := s.closer[i](); err != nil {
 			s.log.Println("Close error:", err)
@@ -229,15 +413,41 @@ func (s *Session) Close() {
 	s.luaState.Close()
 }
 
+// Profiler returns the profiler of the session or nil if profiling is disabled.
+func (s *Session) Profiler() *luhelp.Profiler {
+	return s.profiler
+}
+
+// Events returns the event bus of the session. Use Subscribe to subscribe to a single event type.
+func (s *Session) Events() *EventBus {
+	return s.events
+}
+
 // LuaErrors returns a channel that will receive all lua errors that happen during the session.
 // Only a single channel is used for all errors, so be wary when using this in multiple goroutines.
 func (s *Session) LuaErrors() chan LuaError {
 	return s.luaErrors
 }
 
-// ToSavedState creates a saved state of the session that can be serialized with Gob.
+// Mutex returns the lock of the session. The debug server reads the session from its own goroutines, so the
+// goroutine that drives the session has to hold the write lock while it changes the session.
+func (s *Session) Mutex() *sync.RWMutex {
+	return s.mtx
+}
+
+// RecentLuaErrors returns the last lua errors of the session, newest last. In contrast to LuaErrors the
+// errors are not consumed, so it's safe to call from other goroutines, e.g. the debug server.
+func (s *Session) RecentLuaErrors() []LuaError {
+	s.recentLuaErrorsMtx.Lock()
+	defer s.recentLuaErrorsMtx.Unlock()
+
+	return slices.Clone(s.recentLuaErrors)
+}
+
+// ToSavedState creates a saved state of the session that can be serialized with Gob. The saved state holds
+// a compacted copy of the checkpoints, so only the needed full snapshots end up in the save.
 func (s *Session) ToSavedState() SavedState {
-	return SavedState{
+	save := SavedState{
 		State:            s.state,
 		Actors:           s.actors,
 		Instances:        s.instances,
@@ -246,16 +456,50 @@ func (s *Session) ToSavedState() SavedState {
 		CurrentFight:     s.currentFight,
 		Merchant:         s.merchant,
 		EventHistory:     s.eventHistory,
-		StateCheckpoints: s.stateCheckpoints,
+		StateCheckpoints: s.compactedCheckpoints(),
 		CtxData:          s.ctxData,
 		LoadedMods:       s.loadedMods,
+		ReplayActions:    s.replayActions,
+		PlayTime:         s.GetPlayTime(),
+		Rules:            s.rules,
+		Difficulty:       s.difficulty,
 	}
+
+	// Checkpoints that were decoded but not yet loaded into a session have no random source.
+	if s.rng != nil {
+		save.Seed = s.rng.seed
+		save.RandomDraws = s.rng.draws
+	}
+
+	return save
 }
 
 // LoadSavedState applies a saved state to the session. This will overwrite all game related data, but
 // not the lua state, logging etc. This also means that for a save file to work the same lua scripts
 // should be loaded or the state could be corrupted.
 func (s *Session) LoadSavedState(save SavedState) {
+	s.applySavedState(save)
+
+	// Don't load mods from settings but from the saved list!
+	s.loadMods(s.loadedMods)
+
+	// Restore the random source to the exact position it had when saving.
+	s.setSeed(save.Seed)
+	s.rng.skip(save.RandomDraws)
+
+	// Checkpoints only contain the game data after decoding, so they need the runtime of this session.
+	for i := range s.stateCheckpoints {
+		if s.stateCheckpoints[i].Session != nil {
+			s.stateCheckpoints[i].Session.attachRuntime(s)
+		}
+	}
+	s.checkpointsCompacted = len(s.stateCheckpoints)
+	s.fightCheckpoint = len(s.stateCheckpoints)
+	s.undoFloor = len(s.stateCheckpoints)
+}
+
+// applySavedState applies only the game data of a saved state.
+func (s *Session) applySavedState(save SavedState) {
 	s.state = save.State
 	s.actors = lo.MapValues(save.Actors, func(item Actor, key string) Actor {
 		return item.Sanitize()
@@ -266,31 +510,50 @@ func (s *Session) LoadSavedState(save SavedState) {
 	s.currentFight = save.CurrentFight
 	s.merchant = save.Merchant
 	s.eventHistory = save.EventHistory
-	s.stateCheckpoints = lo.Map(save.StateCheckpoints, func(item StateCheckpoint, index int) StateCheckpoint {
-		item.Session = s
-		return item
-	})
+	s.stateCheckpoints = save.StateCheckpoints
 	s.ctxData = save.CtxData
 	s.loadedMods = save.LoadedMods
-
-	// Don't load mods from settings but from the saved list!
-	s.loadMods(s.loadedMods)
+	s.replayActions = save.ReplayActions
+	s.playTime = save.PlayTime
+	s.playStart = time.Now()
+	s.rules = save.Rules
+	s.difficulty = save.Difficulty
+}
+
+// attachRuntime lets the session share the lua state, resources, logging and random source of another
+// session, like the checkpoints created by PushState do.
+func (s *Session) attachRuntime(other *Session) {
+	s.log = other.log
+	s.luaState = other.luaState
+	s.luaDocs = other.luaDocs
+	s.resources = other.resources
+	s.hooks = other.hooks
+	s.rng = other.rng
+	s.rnd = other.rnd
+	s.onLuaError = other.onLuaError
+	s.luaErrors = other.luaErrors
 }
 
 func (s *Session) GobEncode() ([]byte, error) {
-	buf := &bytes.Buffer{}
-	enc := gob.NewEncoder(buf)
-	err := enc.Encode(s.ToSavedState())
-	return buf.Bytes(), err
+	return EncodeSavedState(s.ToSavedState())
 }
 
 func (s *Session) GobDecode(data []byte) error {
-	buf := bytes.NewBuffer(data)
-	dec := gob.NewDecoder(buf)
-	var saved SavedState
-	if err := dec.Decode(&saved); err != nil {
+	saved, err := DecodeSavedState(data)
+	if err != nil {
+		return err
+	}
+
+	// Sessions without lua state are checkpoints that are decoded as part of a save and only hold game data.
+	if s.luaState == nil {
+		s.applySavedState(saved)
+		return nil
+	}
+
+	if err := checkMods(saved.LoadedMods); err != nil {
 		return err
 	}
+
 	s.LoadSavedState(saved)
 	return nil
 }
@@ -310,31 +573,50 @@ func (s *Session) GetLoadedMods() []string {
 //
 
 func (s *Session) logLuaError(callback string, typeId string, err error) {
+	luaErr := LuaError{
+		Callback: callback,
+		Type:     typeId,
+		Err:      err,
+	}
+
 	_, file, no, ok := runtime.Caller(1)
 	if ok {
+		luaErr.File = file
+		luaErr.Line = no
 		s.log.Printf("%s:%d Error from Lua:%s type=%s %s\n", file, no, callback, typeId, err.Error())
-		s.onLuaError(file, no, callback, typeId, err)
-		s.luaErrors <- LuaError{
-			File:     file,
-			Line:     no,
-			Callback: callback,
-			Type:     typeId,
-			Err:      err,
-		}
 	} else {
 		s.log.Printf("Error from Lua:%s type=%s %s\n", callback, typeId, err.Error())
-		s.onLuaError("", 0, callback, typeId, err)
-		s.luaErrors <- LuaError{
-			File:     "",
-			Line:     0,
-			Callback: callback,
-			Type:     typeId,
-			Err:      err,
-		}
 	}
+
+	s.recentLuaErrorsMtx.Lock()
+	s.recentLuaErrors = append(s.recentLuaErrors, luaErr)
+	if len(s.recentLuaErrors) > recentLuaErrorsMax {
+		s.recentLuaErrors = s.recentLuaErrors[len(s.recentLuaErrors)-recentLuaErrorsMax:]
+	}
+	s.recentLuaErrorsMtx.Unlock()
+
+	s.onLuaError(luaErr.File, luaErr.Line, callback, typeId, err)
+	s.luaErrors <- luaErr
+}
+
+func (s *Session) setSeed(seed int64) {
+	s.rng = newCountingSource(seed)
+	s.rnd = rand.New(s.rng)
 }
 
 func (s *Session) loadMods(mods []string) {
+	// Only the archives of the active mods are mounted, as all other mods are never read.
+	if len(mods) > 0 {
+		if err := MountModArchives(mods...); err != nil {
+			s.logLuaError("ModLoader", "", err)
+		}
+	}
+
+	mods, errs := ResolveModOrder("./mods", mods)
+	for _, err := range errs {
+		s.logLuaError("ModLoader", err.Mod, err)
+	}
+
 	for i := range mods {
 		mod, err := ModDescription(filepath.Join("./mods", mods[i]))
 		if err != nil {
@@ -343,6 +625,11 @@ func (s *Session) loadMods(mods []string) {
 			log.Println("Loading mod:", mod.Name)
 		}
 
+		// Archives are read-only, so there is nothing to watch.
+		if s.hotReload != nil && !fs.IsMounted(filepath.Join("./mods", mods[i])) {
+			s.hotReload.add(s.log, filepath.Join("./mods", mods[i]))
+		}
+
 		_ = fs.Walk(filepath.Join("./mods", mods[i]), func(path string, isDir bool) error {
 			if strings.Contains(path, "__") {
 				return nil
@@ -356,15 +643,15 @@ func (s *Session) loadMods(mods []string) {
 			if !isDir && strings.HasSuffix(path, ".lua") {
 				luaBytes, err := fs.ReadFile(path)
 				if err != nil {
-					// TODO: error handling
-					panic(err)
+					s.logLuaError("ModLoader", path, err)
+					return nil
 				}
 
 				if strings.HasPrefix(string(luaBytes), "---@meta") {
 					return nil
 				}
 
-				if err := s.luaState.DoString(string(luaBytes)); err != nil {
+				if err := doModScript(s.luaState, path, luaBytes); err != nil {
 					s.logLuaError("ModLoader", "", err)
 				}
 			}
@@ -381,7 +668,7 @@ func (s *Session) loadMods(mods []string) {
 // MarkState creates a checkpoint of the session state that can be used to diff and see what happened
 // between two points in time.
 func (s *Session) MarkState() StateCheckpointMarker {
-	return StateCheckpointMarker{checkpoints: s.stateCheckpoints}
+	return StateCheckpointMarker{index: len(s.stateCheckpoints)}
 }
 
 // PushState pushes a new state to the session. New states are relevant information like damage done,
@@ -389,12 +676,16 @@ func (s *Session) MarkState() StateCheckpointMarker {
 func (s *Session) PushState(events map[StateEvent]any) {
 	savedState := *s
 
-	// Only have the current session have the state checkpoints
+	// Only have the current session have the state checkpoints and replay actions
 	savedState.stateCheckpoints = make([]StateCheckpoint, 0)
+	savedState.replayActions = nil
+	savedState.events = nil
 	savedState.actors = lo.MapValues(CopyMap(savedState.actors), func(actor Actor, key string) Actor {
 		return actor.Clone()
 	})
 	savedState.instances = CopyMap(savedState.instances)
+	savedState.ctxData = CopyMap(savedState.ctxData)
+	savedState.currentFight = savedState.currentFight.Clone()
 
 	s.stateCheckpoints = append(s.stateCheckpoints, StateCheckpoint{
 		Session: &savedState,
@@ -409,11 +700,11 @@ func (s *Session) GetFormerState(index int) *Session {
 	}
 
 	index = len(s.stateCheckpoints) + index
-	if index >= len(s.stateCheckpoints) {
+	if index < 0 || index >= len(s.stateCheckpoints) {
 		return nil
 	}
 
-	return s.stateCheckpoints[index].Session
+	return s.restoreCheckpoint(index).Session
 }
 
 //
@@ -427,7 +718,9 @@ func (s *Session) GetGameState() GameState {
 
 // SetGameState sets the game state and applies all needed setups for the new state to be valid.
 func (s *Session) SetGameState(state GameState) {
+	from := s.state
 	s.state = state
+	s.events.publish(GameEventStateChanged{From: from, To: state})
 
 	switch s.state {
 	case GameStateFight:
@@ -436,6 +729,10 @@ func (s *Session) SetGameState(state GameState) {
 		s.LetTellerDecide()
 	case GameStateMerchant:
 		s.SetupMerchant()
+	case GameStateVictory:
+		if from != GameStateVictory {
+			s.winRun()
+		}
 	}
 }
 
@@ -445,7 +742,9 @@ func (s *Session) SetEvent(id string) {
 	s.currentEvent = id
 	if _, ok := s.resources.Events[id]; ok {
 		s.eventHistory = append(s.eventHistory, id)
-		_, _ = s.resources.Events[id].OnEnter.Call(CreateContext("type_id", id))
+		if _, err := s.resources.Events[id].OnEnter.Call(CreateContext("type_id", id)); err != nil {
+			s.logLuaError("OnEnter", id, err)
+		}
 	}
 }
 
@@ -465,37 +764,49 @@ func (s *Session) GetEvent() *Event {
 
 // CleanUpFight resets the fight state.
 func (s *Session) CleanUpFight() {
-	s.currentFight.CurrentPoints = PointsPerRound
-	s.currentFight.Deck = lo.Shuffle(s.GetPlayer().Cards.ToSlice())
+	s.currentFight.CurrentPoints = s.GetRule(RulePointsPerRound)
+	s.removeTemporaryCards()
+	s.currentFight.Deck = shuffle(s.rnd, s.GetPlayer().Cards.ToSlice())
 	s.currentFight.Hand = []string{}
+
+	// Innate cards are moved to the top of the deck, so they are drawn into the opening hand.
+	sort.SliceStable(s.currentFight.Deck, func(i, j int) bool {
+		return s.isInnate(s.currentFight.Deck[i]) && !s.isInnate(s.currentFight.Deck[j])
+	})
+
 	s.currentFight.Exhausted = []string{}
 	s.currentFight.Used = []string{}
 	s.currentFight.Round = 0
+	s.UpdatePlayer(func(actor *Actor) bool {
+		actor.Block = 0
+		return true
+	})
 }
 
 // SetupFight setups the fight state, which means removing all leftover status effects, cleaning the state
 // drawing the initial hand size and trigger the first wave of OnPlayerTurn callbacks.
 //
-// Additionally, this will create a save file as this is a clean state to save.
+// Additionally, this will save the session to its save slot as this is a clean state to save.
 func (s *Session) SetupFight() {
+	s.fightCheckpoint = len(s.stateCheckpoints)
 	s.RemoveAllStatusEffects()
 	s.CleanUpFight()
-	s.PlayerDrawCard(DrawSize)
+	s.applyDifficultyStatusEffects()
+	s.PlayerDrawCard(lo.Max([]int{s.GetRule(RuleDrawSize), lo.CountBy(s.currentFight.Deck, s.isInnate)}))
 
 	// Trigger OnPlayerTurn callbacks
 	TriggerCallbackSimple(s, CallbackOnPlayerTurn, TriggerAll, nil)
+	s.markUndoBoundary()
 
-	// Save after each fight
-	{
-		save, err := s.GobEncode()
-		if err != nil {
-			s.log.Println("Error saving file:", save)
-		} else {
-			if err := fs.WriteFile("./session.save", save); err != nil {
-				s.log.Println("Error saving file:", save)
-			}
+	// Compact the checkpoints of the last fight and save
+	s.compactCheckpoints()
+	if s.saveSlots != nil {
+		if err := s.saveSlots.Save(s.saveSlotID, s); err != nil {
+			s.log.Println("Error saving file:", err)
 		}
 	}
+
+	s.writeReplay()
 }
 
 // GetFight returns the fight state. This will return a fight state even if no fight is active at the moment.
@@ -512,6 +823,8 @@ func (s *Session) GetStagesCleared() int {
 // evaluated, if the fight is over is checked and if not this will advance to the next round and draw cards
 // for the player.
 func (s *Session) FinishPlayerTurn() {
+	defer s.recordAction(ReplayAction{Type: ReplayActionFinishTurn})(nil)
+
 	// Enemies are allowed to act.
 	s.EnemyTurn()
 
@@ -519,6 +832,7 @@ func (s *Session) FinishPlayerTurn() {
 	var removeStatus []string
 
 	instanceKeys := lo.Keys(s.instances)
+	sort.Strings(instanceKeys)
 	for _, guid := range instanceKeys {
 		switch instance := s.instances[guid].(type) {
 		case StatusEffectInstance:
@@ -573,26 +887,45 @@ func (s *Session) FinishPlayerTurn() {
 	}
 
 	// Advance to new Round
-	s.currentFight.CurrentPoints = PointsPerRound
+	s.decayBlock(PlayerActorID)
+	s.currentFight.CurrentPoints = s.GetRule(RulePointsPerRound)
 	s.currentFight.Round += 1
-	s.currentFight.Used = append(s.currentFight.Used, s.currentFight.Hand...)
-	s.currentFight.Hand = []string{}
 
-	s.PlayerDrawCard(DrawSize)
+	// Retained cards stay in the hand and ethereal cards are exhausted. The rest goes to the used pile
+	// without triggering OnDiscard, as it is not discarded by a card or effect.
+	hand := []string{}
+	for _, guid := range s.currentFight.Hand {
+		card, _ := s.GetCard(guid)
+		switch {
+		case card != nil && card.Ethereal:
+			s.exhaustCard(guid)
+		case card != nil && card.Retain:
+			hand = append(hand, guid)
+		default:
+			s.currentFight.Used = append(s.currentFight.Used, guid)
+		}
+	}
+	s.currentFight.Hand = hand
+
+	s.PlayerDrawCard(s.GetRule(RuleDrawSize))
 
 	// Trigger OnPlayerTurn callbacks
 	TriggerCallbackSimple(s, CallbackOnPlayerTurn, TriggerAll, nil)
+	s.markUndoBoundary()
 }
 
 // EnemyTurn lets all enemies act. This will also trigger the OnTurn callbacks of all status effects and
 // artifacts. If a status effect or artifact returns true, the enemy turn will be skipped. This is used
 // for example by the "FEAR" status effect.
 func (s *Session) EnemyTurn() {
-	for k, v := range s.actors {
+	for _, k := range s.GetActors() {
+		v := s.actors[k]
 		if k == PlayerActorID || v.IsNone() {
 			continue
 		}
 
+		s.decayBlock(k)
+
 		if enemy, ok := s.resources.Enemies[v.TypeID]; ok {
 			skipTurn := false
 			s.TraverseArtifactsStatus(append(v.Artifacts.ToSlice(), v.StatusEffects.ToSlice()...),
@@ -626,9 +959,12 @@ func (s *Session) EnemyTurn() {
 	}
 }
 
-// FinishFight tries to finish the fight. This will return true if the fight is really over.
+// FinishFight tries to finish the fight. This will return true if the fight is really over. Once the fight
+// is over, the checkpoints before the fight are pruned to their events.
 func (s *Session) FinishFight() bool {
 	if s.GetOpponentCount(PlayerActorID) == 0 {
+		s.markUndoBoundary()
+		s.pruneCheckpoints(s.fightCheckpoint)
 		s.currentFight.Description = ""
 		s.stagesCleared += 1
 		s.CleanUpFight()
@@ -652,6 +988,8 @@ func (s *Session) FinishFight() bool {
 // FinishEvent finishes an event with the given choice. If the game state is not in the EVENT state this
 // does nothing.
 func (s *Session) FinishEvent(choice int) {
+	defer s.recordAction(ReplayAction{Type: ReplayActionFinishEvent, Index: choice})(nil)
+
 	if len(s.currentEvent) == 0 || s.state != GameStateEvent {
 		return
 	}
@@ -663,7 +1001,12 @@ func (s *Session) FinishEvent(choice int) {
 
 	// If choice was selected and valid we try to use the next game state from the choice.
 	if choice >= 0 && choice < len(event.Choices) {
-		nextState, _ := event.Choices[choice].Callback(CreateContext("type_id", event.ID, "choice", choice+1))
+		s.events.publish(GameEventChoice{EventID: event.ID, Choice: choice})
+
+		nextState, err := event.Choices[choice].Callback(CreateContext("type_id", event.ID, "choice", choice+1))
+		if err != nil {
+			s.logLuaError("Choice", event.ID, err)
+		}
 
 		// If the choice dictates a new state we take that
 		if nextState != nil {
@@ -672,12 +1015,17 @@ func (s *Session) FinishEvent(choice int) {
 			} else {
 				s.SetGameState(GameStateRandom)
 			}
-			_, _ = event.OnEnd.Call(CreateContext("type_id", event.ID, "choice", choice+1))
+			if _, err := event.OnEnd.Call(CreateContext("type_id", event.ID, "choice", choice+1)); err != nil {
+				s.logLuaError("OnEnd", event.ID, err)
+			}
 			return
 		}
 
 		// Otherwise we allow OnEnd to dictate the new state
-		nextState, _ = event.OnEnd.Call(CreateContext("type_id", event.ID, "choice", choice+1))
+		nextState, err = event.OnEnd.Call(CreateContext("type_id", event.ID, "choice", choice+1))
+		if err != nil {
+			s.logLuaError("OnEnd", event.ID, err)
+		}
 		if nextState != nil && len(nextState.(string)) > 0 {
 			s.SetGameState(GameState(nextState.(string)))
 		} else {
@@ -686,7 +1034,10 @@ func (s *Session) FinishEvent(choice int) {
 		return
 	}
 
-	nextState, _ := event.OnEnd.Call(CreateContext("type_id", event.ID, "choice", nil))
+	nextState, err := event.OnEnd.Call(CreateContext("type_id", event.ID, "choice", nil))
+	if err != nil {
+		s.logLuaError("OnEnd", event.ID, err)
+	}
 	if nextState != nil && len(nextState.(string)) > 0 {
 		s.SetGameState(GameState(nextState.(string)))
 	} else {
@@ -756,8 +1107,8 @@ func (s *Session) GetEventChoiceDescription(i int) string {
 func (s *Session) SetupMerchant() {
 	s.merchant.Artifacts = nil
 	s.merchant.Cards = nil
-	s.merchant.Face = faces.Global.GenRand()
-	s.merchant.Text = gen.GetRandom("merchant_lines")
+	s.merchant.Face = faces.Global.GenRandWith(s.rnd)
+	s.merchant.Text = gen.GetRandomWith(s.rnd, "merchant_lines")
 
 	for i := 0; i < 3; i++ {
 		s.AddMerchantArtifact()
@@ -767,6 +1118,8 @@ func (s *Session) SetupMerchant() {
 
 // LeaveMerchant finishes the merchant state and lets the storyteller decide what to do next.
 func (s *Session) LeaveMerchant() {
+	defer s.recordAction(ReplayAction{Type: ReplayActionLeaveMerchant})(nil)
+
 	s.SetGameState(GameStateRandom)
 }
 
@@ -792,6 +1145,9 @@ func (s *Session) GetRandomArtifact(maxGold int) string {
 	possible := lo.Filter(lo.Values(s.resources.Artifacts), func(item *Artifact, index int) bool {
 		return item.Price >= 0 && item.Price < maxGold
 	})
+	sort.Slice(possible, func(i, j int) bool {
+		return possible[i].ID < possible[j].ID
+	})
 
 	possibleNoDupes := lo.Filter(possible, func(item *Artifact, index int) bool {
 		return !lo.Contains(s.randomHistory, item.ID)
@@ -800,9 +1156,9 @@ func (s *Session) GetRandomArtifact(maxGold int) string {
 	if len(possible) > 0 {
 		var chosen string
 		if len(possibleNoDupes) > 0 {
-			chosen = lo.Shuffle(possibleNoDupes)[0].ID
+			chosen = shuffle(s.rnd, possibleNoDupes)[0].ID
 		} else {
-			chosen = lo.Shuffle(possible)[0].ID
+			chosen = shuffle(s.rnd, possible)[0].ID
 		}
 		s.PushRandomHistory(chosen)
 		return chosen
@@ -816,6 +1172,9 @@ func (s *Session) GetRandomCard(maxGold int) string {
 	possible := lo.Filter(lo.Values(s.resources.Cards), func(item *Card, index int) bool {
 		return item.Price >= 0 && item.Price < maxGold
 	})
+	sort.Slice(possible, func(i, j int) bool {
+		return possible[i].ID < possible[j].ID
+	})
 
 	possibleNoDupes := lo.Filter(possible, func(item *Card, index int) bool {
 		return !lo.Contains(s.randomHistory, item.ID)
@@ -824,9 +1183,9 @@ func (s *Session) GetRandomCard(maxGold int) string {
 	if len(possible) > 0 {
 		var chosen string
 		if len(possibleNoDupes) > 0 {
-			chosen = lo.Shuffle(possibleNoDupes)[0].ID
+			chosen = shuffle(s.rnd, possibleNoDupes)[0].ID
 		} else {
-			chosen = lo.Shuffle(possible)[0].ID
+			chosen = shuffle(s.rnd, possible)[0].ID
 		}
 		s.PushRandomHistory(chosen)
 		return chosen
@@ -850,19 +1209,22 @@ func (s *Session) AddMerchantCard() {
 }
 
 // PlayerBuyCard buys the card with the given type id. The card needs to be in the wares of the merchant.
-func (s *Session) PlayerBuyCard(t string) bool {
+func (s *Session) PlayerBuyCard(t string) (bought bool) {
+	defer s.recordAction(ReplayAction{Type: ReplayActionBuyCard, ID: t})(&bought)
+
 	if !lo.Contains(s.merchant.Cards, t) {
 		return false
 	}
 
 	card, _ := s.GetCard(t)
+	price := s.MerchantPrice(card.Price)
 
-	if s.GetPlayer().Gold < card.Price {
+	if s.GetPlayer().Gold < price {
 		return false
 	}
 
 	s.UpdatePlayer(func(actor *Actor) bool {
-		actor.Gold -= card.Price
+		actor.Gold -= price
 		return true
 	})
 
@@ -885,19 +1247,22 @@ func (s *Session) PlayerBuyCard(t string) bool {
 }
 
 // PlayerBuyArtifact buys the artifact with the given type id. The artifact needs to be in the wares of the merchant.
-func (s *Session) PlayerBuyArtifact(t string) bool {
+func (s *Session) PlayerBuyArtifact(t string) (bought bool) {
+	defer s.recordAction(ReplayAction{Type: ReplayActionBuyArtifact, ID: t})(&bought)
+
 	if !lo.Contains(s.merchant.Artifacts, t) {
 		return false
 	}
 
 	art, _ := s.GetArtifact(t)
+	price := s.MerchantPrice(art.Price)
 
-	if s.GetPlayer().Gold < art.Price {
+	if s.GetPlayer().Gold < price {
 		return false
 	}
 
 	s.UpdatePlayer(func(actor *Actor) bool {
-		actor.Gold -= art.Price
+		actor.Gold -= price
 		return true
 	})
 
@@ -943,7 +1308,12 @@ func (s *Session) ActiveTeller() *StoryTeller {
 		return nil
 	}
 
-	slices.SortFunc(teller, func(a, b *StoryTeller) bool {
+	// Sort by id first so that tellers with the same order are always picked in the same way.
+	sort.Slice(teller, func(i, j int) bool {
+		return teller[i].ID < teller[j].ID
+	})
+
+	slices.SortStableFunc(teller, func(a, b *StoryTeller) bool {
 		aOrder, _ := a.Active(CreateContext("type_id", a.ID))
 		bOrder, _ := b.Active(CreateContext("type_id", b.ID))
 
@@ -955,6 +1325,8 @@ func (s *Session) ActiveTeller() *StoryTeller {
 
 // LetTellerDecide lets the currently active storyteller decide what the next game state will be.
 func (s *Session) LetTellerDecide() {
+	defer s.recordAction(ReplayAction{Type: ReplayActionLetTellerDecide})(nil)
+
 	active := s.ActiveTeller()
 
 	if active == nil {
@@ -979,9 +1351,11 @@ func (s *Session) LetTellerDecide() {
 // Instances
 //
 
-// GetInstances returns all instances in the session.
+// GetInstances returns all instances in the session sorted by guid.
 func (s *Session) GetInstances() []string {
-	return lo.Keys(s.instances)
+	guids := lo.Keys(s.instances)
+	sort.Strings(guids)
+	return guids
 }
 
 // GetInstance returns an instance by guid. An instance is a CardInstance or ArtifactInstance.
@@ -1166,13 +1540,14 @@ func (s *Session) GiveStatusEffect(typeId string, owner string, stacks int) stri
 		if _, err := status.Callbacks[CallbackOnStatusStack].Call(CreateContext("type_id", typeId, "guid", same[0], "owner", owner, "stacks", instance.Stacks)); err != nil {
 			s.logLuaError(CallbackOnStatusStack, instance.TypeID, err)
 		}
+		s.events.publish(GameEventStatusStacked{GUID: instance.GUID, TypeID: typeId, Owner: owner, Stacks: instance.Stacks, Change: stacks})
 
 		return instance.GUID
 	}
 
 	instance := StatusEffectInstance{
 		TypeID:       typeId,
-		GUID:         NewGuid("STATUS"),
+		GUID:         s.NewGuid("STATUS"),
 		Owner:        owner,
 		RoundsLeft:   status.Rounds,
 		Stacks:       stacks,
@@ -1182,7 +1557,10 @@ func (s *Session) GiveStatusEffect(typeId string, owner string, stacks int) stri
 	s.actors[owner].StatusEffects.Add(instance.GUID)
 
 	// Call OnStatusAdd callback for the new instance
-	_, _ = status.Callbacks[CallbackOnStatusAdd].Call(CreateContext("type_id", typeId, "guid", instance.GUID))
+	if _, err := status.Callbacks[CallbackOnStatusAdd].Call(CreateContext("type_id", typeId, "guid", instance.GUID)); err != nil {
+		s.logLuaError(CallbackOnStatusAdd, typeId, err)
+	}
+	s.events.publish(GameEventStatusAdded{GUID: instance.GUID, TypeID: typeId, Owner: owner, Stacks: stacks})
 
 	return instance.GUID
 }
@@ -1201,6 +1579,7 @@ func (s *Session) RemoveStatusEffect(guid string) {
 		actor.StatusEffects.Remove(instance.GUID)
 	}
 	delete(s.instances, guid)
+	s.events.publish(GameEventStatusRemoved{GUID: guid, TypeID: instance.TypeID, Owner: instance.Owner})
 }
 
 // GetActorStatusEffects returns the guids of all the status effects a certain actor owns.
@@ -1224,6 +1603,7 @@ func (s *Session) AddStatusEffectStacks(guid string, stacks int) {
 		s.RemoveStatusEffect(guid)
 	} else {
 		s.instances[guid] = instance
+		s.events.publish(GameEventStatusStacked{GUID: guid, TypeID: instance.TypeID, Owner: instance.Owner, Stacks: instance.Stacks, Change: stacks})
 	}
 }
 
@@ -1234,11 +1614,13 @@ func (s *Session) SetStatusEffectStacks(guid string, stacks int) {
 		return
 	}
 
+	previous := instance.Stacks
 	instance.Stacks = stacks
 	if instance.Stacks <= 0 {
 		s.RemoveStatusEffect(guid)
 	} else {
 		s.instances[guid] = instance
+		s.events.publish(GameEventStatusStacked{GUID: guid, TypeID: instance.TypeID, Owner: instance.Owner, Stacks: instance.Stacks, Change: stacks - previous})
 	}
 }
 
@@ -1297,7 +1679,7 @@ func (s *Session) GiveArtifact(typeId string, owner string) string {
 
 	instance := ArtifactInstance{
 		TypeID: typeId,
-		GUID:   NewGuid("ARTIFACT"),
+		GUID:   s.NewGuid("ARTIFACT"),
 		Owner:  owner,
 	}
 	s.instances[instance.GUID] = instance
@@ -1315,6 +1697,7 @@ func (s *Session) GiveArtifact(typeId string, owner string) string {
 			GUID:   instance.GUID,
 		},
 	})
+	s.events.publish(GameEventArtifactGained{GUID: instance.GUID, TypeID: typeId, Owner: owner})
 
 	return instance.GUID
 }
@@ -1370,7 +1753,7 @@ func (s *Session) GiveCard(typeId string, owner string) string {
 
 	instance := CardInstance{
 		TypeID: typeId,
-		GUID:   NewGuid("CARD"),
+		GUID:   s.NewGuid("CARD"),
 		Owner:  owner,
 	}
 	s.instances[instance.GUID] = instance
@@ -1415,8 +1798,8 @@ func (s *Session) CastCard(guid string, target string) bool {
 			}
 
 			TriggerCallbackSimple(s, CallbackOnActorDidCast, TriggerAll, EmptyContext, CreateContext("type_id", card.ID, "guid", guid, "caster", instance.Owner, "target", target, "level", instance.Level, "tags", card.Tags))
-			return true
 		}
+		s.events.publish(GameEventCardCast{GUID: guid, TypeID: card.ID, Caster: instance.Owner, Target: target})
 	}
 	return true
 }
@@ -1451,9 +1834,12 @@ func (s *Session) GetCardState(guid string) string {
 }
 
 // PlayerCastHand casts a card from the players hand.
-func (s *Session) PlayerCastHand(i int, target string) error {
-	if i >= len(s.currentFight.Hand) {
-		return errors.New("hand empty")
+func (s *Session) PlayerCastHand(i int, target string) (err error) {
+	replayActions := len(s.replayActions)
+	defer s.recordAction(ReplayAction{Type: ReplayActionCastHand, Index: i, Target: target})(&err)
+
+	if i < 0 || i >= len(s.currentFight.Hand) {
+		return errors.New("no card at this index")
 	}
 
 	cardId := s.currentFight.Hand[i]
@@ -1465,10 +1851,24 @@ func (s *Session) PlayerCastHand(i int, target string) error {
 			return errors.New("card is not castable")
 		}
 
+		if card.Unplayable {
+			return errors.New("card is unplayable")
+		}
+
 		if s.currentFight.CurrentPoints < card.PointCost {
 			return errors.New("not enough points")
 		}
 
+		// Mark the state before the cast, so it can be undone.
+		s.PushState(map[StateEvent]any{
+			StateEventCastHand: StateEventCastHandData{
+				Index:         i,
+				Target:        target,
+				RandomDraws:   s.rng.draws,
+				ReplayActions: replayActions,
+			},
+		})
+
 		s.currentFight.CurrentPoints -= card.PointCost
 	} else {
 		return errors.New("card not exists")
@@ -1483,7 +1883,7 @@ func (s *Session) PlayerCastHand(i int, target string) error {
 	didCast := s.CastCard(cardId, target)
 	if didCast {
 		if card.DoesExhaust {
-			s.currentFight.Exhausted = append(s.currentFight.Exhausted, cardId)
+			s.exhaustCard(cardId)
 		} else if card.DoesConsume {
 			s.RemoveCard(cardId)
 		} else {
@@ -1496,13 +1896,87 @@ func (s *Session) PlayerCastHand(i int, target string) error {
 	return nil
 }
 
+// CanUndo returns true if there is a cast of the player that can be undone.
+func (s *Session) CanUndo() bool {
+	_, ok := s.lastCastHandCheckpoint()
+	return ok
+}
+
+// Undo restores the session to the state right before the last PlayerCastHand. This includes the
+// instances, the data stored from lua, the random history and the position of the random source, so casting
+// the same card again will have the same outcome. The cast is also removed from the recorded replay.
+func (s *Session) Undo() error {
+	index, ok := s.lastCastHandCheckpoint()
+	if !ok {
+		return errors.New("nothing to undo")
+	}
+
+	checkpoint := s.restoreCheckpoint(index)
+	data := checkpoint.Events[StateEventCastHand].(StateEventCastHandData)
+	before := checkpoint.Session
+	from := s.state
+
+	s.state = before.state
+	s.actors = lo.MapValues(before.actors, func(actor Actor, key string) Actor {
+		return actor.Clone()
+	})
+	s.instances = CopyMap(before.instances)
+	s.stagesCleared = before.stagesCleared
+	s.currentEvent = before.currentEvent
+	s.currentFight = before.currentFight.Clone()
+	s.merchant = before.merchant
+	s.eventHistory = slices.Clone(before.eventHistory)
+	s.randomHistory = slices.Clone(before.randomHistory)
+	s.rules = before.rules
+	s.ctxData = deepCopyValue(before.ctxData).(map[string]any)
+
+	s.rnd.Seed(s.rng.seed)
+	s.rng.skip(data.RandomDraws)
+
+	s.stateCheckpoints = s.stateCheckpoints[:index]
+	s.checkpointsCompacted = lo.Min([]int{s.checkpointsCompacted, index})
+	s.checkpointsPruned = lo.Min([]int{s.checkpointsPruned, index})
+	if data.ReplayActions <= len(s.replayActions) {
+		s.replayActions = s.replayActions[:data.ReplayActions]
+	}
+
+	if from != s.state {
+		s.events.publish(GameEventStateChanged{From: from, To: s.state})
+	}
+	s.Log(LogTypeWarning, "Undid the last card")
+
+	return nil
+}
+
+// markUndoBoundary prevents Undo from going back beyond the current checkpoint. This is called at the start
+// of each player turn and at the end of a fight, so only casts of the current turn can be undone.
+func (s *Session) markUndoBoundary() {
+	s.undoFloor = len(s.stateCheckpoints)
+}
+
+// lastCastHandCheckpoint returns the index of the checkpoint that was pushed before the last cast of the
+// current turn.
+func (s *Session) lastCastHandCheckpoint() (int, bool) {
+	for i := len(s.stateCheckpoints) - 1; i >= s.undoFloor; i-- {
+		if _, ok := s.stateCheckpoints[i].Events[StateEventCastHand]; ok {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
 // PlayerDrawCard draws a card from the deck.
 func (s *Session) PlayerDrawCard(amount int) {
+	handSize := s.GetRule(RuleHandSize)
 	for i := 0; i < amount; i++ {
+		// Cards stay in the deck if the hand is full
+		if s.isHandFull(handSize) {
+			break
+		}
+
 		// Shuffle used back in
-		if len(s.currentFight.Deck) == 0 && len(s.currentFight.Used) > 0 {
-			s.currentFight.Deck = lo.Shuffle(s.currentFight.Used)
-			s.currentFight.Used = []string{}
+		if len(s.currentFight.Deck) == 0 {
+			s.PlayerShuffleUsedIntoDeck()
 		}
 
 		// If nothing left don't draw
@@ -1510,28 +1984,38 @@ func (s *Session) PlayerDrawCard(amount int) {
 			break
 		}
 
-		s.currentFight.Hand = append(s.currentFight.Hand, s.currentFight.Deck[0])
+		drawn := s.currentFight.Deck[0]
 		s.currentFight.Deck = lo.Drop(s.currentFight.Deck, 1)
+		s.drawCard(drawn)
 	}
 }
 
+// isInnate returns true if the card instance belongs to an innate card.
+func (s *Session) isInnate(guid string) bool {
+	card, _ := s.GetCard(guid)
+	return card != nil && card.Innate
+}
+
 // PlayerGiveActionPoints gives the player action points.
 func (s *Session) PlayerGiveActionPoints(amount int) {
 	s.currentFight.CurrentPoints += amount
 }
 
 // BuyUpgradeCard upgrades a card by its GUID.
-func (s *Session) BuyUpgradeCard(guid string) bool {
+func (s *Session) BuyUpgradeCard(guid string) (bought bool) {
+	defer s.recordAction(ReplayAction{Type: ReplayActionBuyUpgradeCard, ID: guid})(&bought)
+
 	card, instance := s.GetCard(guid)
 	if instance.IsNone() || card.MaxLevel == 0 || instance.Level == card.MaxLevel {
 		return false
 	}
 
-	if s.GetPlayer().Gold < DefaultUpgradeCost {
+	cost := s.GetRule(RuleUpgradeCost)
+	if s.GetPlayer().Gold < cost {
 		return false
 	}
 	s.UpdatePlayer(func(actor *Actor) bool {
-		actor.Gold -= DefaultUpgradeCost
+		actor.Gold -= cost
 		return true
 	})
 
@@ -1541,17 +2025,20 @@ func (s *Session) BuyUpgradeCard(guid string) bool {
 }
 
 // BuyRemoveCard removes a card by its GUID.
-func (s *Session) BuyRemoveCard(guid string) bool {
+func (s *Session) BuyRemoveCard(guid string) (bought bool) {
+	defer s.recordAction(ReplayAction{Type: ReplayActionBuyRemoveCard, ID: guid})(&bought)
+
 	_, instance := s.GetCard(guid)
 	if instance.IsNone() {
 		return false
 	}
 
-	if s.GetPlayer().Gold < DefaultRemoveCost {
+	cost := s.GetRule(RuleRemoveCost)
+	if s.GetPlayer().Gold < cost {
 		return false
 	}
 	s.UpdatePlayer(func(actor *Actor) bool {
-		actor.Gold -= DefaultUpgradeCost
+		actor.Gold -= cost
 		return true
 	})
 
@@ -1585,7 +2072,7 @@ func (s *Session) UpgradeRandomCard(owner string) bool {
 		return false
 	}
 
-	return s.UpgradeCard(lo.Shuffle(upgradeable)[0])
+	return s.UpgradeCard(shuffle(s.rnd, upgradeable)[0])
 }
 
 //
@@ -1593,6 +2080,7 @@ func (s *Session) UpgradeRandomCard(owner string) bool {
 //
 
 // DealDamage deals damage to a target. If flat is true it will not trigger any callbacks which modify the damage.
+// The block of the target absorbs the damage first. Returns the damage that reached the hp of the target.
 func (s *Session) DealDamage(source string, target string, damage int, flat bool) int {
 	if _, ok := s.actors[source]; !ok {
 		return 0
@@ -1619,12 +2107,17 @@ func (s *Session) DealDamage(source string, target string, damage int, flat bool
 		)
 	}
 
+	// Block absorbs the damage before it reaches the hp.
+	blocked := s.absorbBlock(source, target, damage)
+	damage -= blocked
+
+	blockedText := lo.Ternary(blocked > 0, fmt.Sprintf(" (%d blocked)", blocked), "")
 	if source == PlayerActorID {
-		s.Log(LogTypeSuccess, fmt.Sprintf("You hit the enemy for %d damage", damage))
+		s.Log(LogTypeSuccess, fmt.Sprintf("You hit the enemy for %d damage%s", damage, blockedText))
 	} else if target == PlayerActorID {
-		s.Log(LogTypeDanger, fmt.Sprintf("You took %d damage", damage))
+		s.Log(LogTypeDanger, fmt.Sprintf("You took %d damage%s", damage, blockedText))
 	} else {
-		s.Log(LogTypeSuccess, fmt.Sprintf("%s took %d damage", val.Name, damage))
+		s.Log(LogTypeSuccess, fmt.Sprintf("%s took %d damage%s", val.Name, damage, blockedText))
 	}
 
 	// Negative damage aka heal is not allowed!
@@ -1649,6 +2142,10 @@ func (s *Session) DealDamage(source string, target string, damage int, flat bool
 				Damage: damage,
 			},
 		})
+		// The damage and death are published before anything that is caused by the death.
+		s.events.publish(GameEventDamage{Source: source, Target: target, Damage: damage, HPLeft: 0, Blocked: blocked})
+		s.events.publish(GameEventActorDeath{Source: source, Target: target, Damage: damage})
+
 		s.Log(LogTypeSuccess, fmt.Sprintf("%s died and dropped %d gold!", val.Name, val.Gold))
 		s.GivePlayerGold(val.Gold)
 
@@ -1668,7 +2165,9 @@ func (s *Session) DealDamage(source string, target string, damage int, flat bool
 			actor.HP = hpLeft
 			return true
 		})
+		s.events.publish(GameEventDamage{Source: source, Target: target, Damage: damage, HPLeft: hpLeft, Blocked: blocked})
 		if target == PlayerActorID && s.GetPlayer().HP == 0 {
+			s.events.publish(GameEventActorDeath{Source: source, Target: target, Damage: damage})
 			s.SetGameState(GameStateGameOver)
 		}
 	}
@@ -1677,12 +2176,13 @@ func (s *Session) DealDamage(source string, target string, damage int, flat bool
 }
 
 // SimulateDealDamage will simulate damage to a target. If flat is true it will not trigger any callbacks which modify the damage.
+// Like DealDamage it returns the damage that would reach the hp, so the block of the target is subtracted.
 func (s *Session) SimulateDealDamage(source string, target string, damage int, flat bool) int {
 	if _, ok := s.actors[source]; !ok {
 		return 0
 	}
 
-	_, ok := s.actors[target]
+	val, ok := s.actors[target]
 	if !ok {
 		return 0
 	}
@@ -1703,6 +2203,11 @@ func (s *Session) SimulateDealDamage(source string, target string, damage int, f
 		)
 	}
 
+	// Block absorbs the damage before it reaches the hp.
+	if damage > 0 {
+		damage -= lo.Min([]int{val.Block, damage})
+	}
+
 	// Negative damage aka heal is not allowed!
 	if damage < 0 {
 		return 0
@@ -1767,6 +2272,7 @@ func (s *Session) Heal(source string, target string, heal int, flat bool) int {
 			actor.HP = lo.Clamp(val.HP+heal, 0, val.MaxHP)
 			return true
 		})
+		s.events.publish(GameEventHeal{Source: source, Target: target, Heal: heal})
 
 		return heal
 	}
@@ -1787,9 +2293,11 @@ func (s *Session) UpdatePlayer(update func(actor *Actor) bool) {
 	s.UpdateActor(PlayerActorID, update)
 }
 
-// GetActors returns all actors.
+// GetActors returns all actors sorted by guid.
 func (s *Session) GetActors() []string {
-	return lo.Keys(s.actors)
+	guids := lo.Keys(s.actors)
+	sort.Strings(guids)
+	return guids
 }
 
 // GetActor returns an actor.
@@ -1803,8 +2311,16 @@ func (s *Session) GetActor(id string) Actor {
 // UpdateActor updates an actor. If the update function returns true the actor will be updated.
 func (s *Session) UpdateActor(id string, update func(actor *Actor) bool) {
 	actor := s.GetActor(id)
+	gold := actor.Gold
+	block := actor.Block
 	if update(&actor) {
 		s.actors[id] = actor
+		if actor.Gold != gold {
+			s.events.publish(GameEventGoldChanged{Target: id, Change: actor.Gold - gold, Gold: actor.Gold})
+		}
+		if actor.Block != block {
+			s.events.publish(GameEventBlockChanged{Target: id, Change: actor.Block - block, Block: actor.Block})
+		}
 	}
 }
 
@@ -1845,13 +2361,13 @@ func (s *Session) AddActor(actor Actor) {
 // AddActorFromEnemy adds an actor to the session from an enemy base.
 func (s *Session) AddActorFromEnemy(id string) string {
 	if base, ok := s.resources.Enemies[id]; ok {
-		actor := NewActor(NewGuid(id))
+		actor := NewActor(s.NewGuid(id))
 
 		actor.TypeID = id
 		actor.Name = base.Name
 		actor.Description = base.Description
-		actor.HP = base.InitialHP
-		actor.MaxHP = base.MaxHP
+		actor.HP = s.applyDifficulty(base.InitialHP, func(d *Difficulty) float64 { return d.EnemyHP })
+		actor.MaxHP = s.applyDifficulty(base.MaxHP, func(d *Difficulty) float64 { return d.EnemyHP })
 
 		// Its important we add the actor before any callbacks so that it's instance is available
 		// to add cards etc. to!
@@ -1978,6 +2494,7 @@ func (s *Session) GetEnemy(typeId string) *Enemy {
 
 // GivePlayerGold gives the player the given amount of gold.
 func (s *Session) GivePlayerGold(amount int) {
+	amount = s.applyDifficulty(amount, func(d *Difficulty) float64 { return d.Gold })
 	if amount <= 0 {
 		return
 	}
@@ -3,12 +3,18 @@ package game
 import (
 	"bytes"
 	"encoding/gob"
+	"encoding/json"
+	"github.com/BigJk/end_of_eden/internal/lua/luhelp"
 	"github.com/samber/lo"
 	"github.com/stretchr/testify/assert"
 	lua "github.com/yuin/gopher-lua"
+	"golang.org/x/exp/slices"
 	"io"
 	"log"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 )
 
 func TestSessionLua(t *testing.T) {
@@ -243,8 +249,669 @@ func TestSessionSave(t *testing.T) {
 	assert.Equal(t, sessionIn.stagesCleared, sessionNew.stagesCleared)
 	assert.Equal(t, sessionIn.merchant, sessionNew.merchant)
 
-	lo.ForEach(sessionIn.stateCheckpoints, func(item StateCheckpoint, i int) {
-		assert.Equal(t, item.Events, sessionNew.stateCheckpoints[i].Events)
-		assert.Equal(t, item.Session.actors, sessionNew.stateCheckpoints[i].Session.actors)
+	// Checkpoints are compacted on save, so they need to be compared in their restored form.
+	checkpointsNew := StateCheckpointMarker{}.Diff(sessionNew)
+	lo.ForEach(StateCheckpointMarker{}.Diff(sessionIn), func(item StateCheckpoint, i int) {
+		assert.Equal(t, item.Events, checkpointsNew[i].Events)
+		assert.Equal(t, item.Session.actors, checkpointsNew[i].Session.actors)
 	})
 }
+
+func TestSessionSeed(t *testing.T) {
+	run := func(session *Session) {
+		if err := session.luaState.DoString(`
+register_card("DEBUG_SEED_CARD",
+    {
+        name = "Seed Card",
+        description = "",
+        color = "#cccccc",
+        price = 10,
+        callbacks = {
+            on_cast = function(ctx)
+                store("roll", math.random(1000))
+                return nil
+            end,
+        }
+    }
+);
+`); err != nil {
+			t.Fatal(err)
+		}
+
+		for i := 0; i < 10; i++ {
+			session.GiveCard("DEBUG_SEED_CARD", PlayerActorID)
+		}
+
+		session.CleanUpFight()
+		session.PlayerDrawCard(DrawSize)
+		session.CastCard(session.GetFight().Hand[0], "")
+		session.GiveCard(session.GetRandomCard(100), PlayerActorID)
+	}
+
+	sessionA := NewSession(WithSeed(1337))
+	sessionB := NewSession(WithSeed(1337))
+	run(sessionA)
+	run(sessionB)
+
+	assert.Equal(t, int64(1337), sessionA.GetSeed())
+	assert.Equal(t, sessionA.GetPlayer().Gold, sessionB.GetPlayer().Gold)
+	assert.Equal(t, sessionA.GetInstances(), sessionB.GetInstances())
+	assert.Equal(t, sessionA.GetFight(), sessionB.GetFight())
+	assert.Equal(t, sessionA.Fetch("roll"), sessionB.Fetch("roll"))
+
+	// A loaded save should continue with the same random sequence.
+	save, err := sessionA.GobEncode()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	sessionC := NewSession()
+	if !assert.NoError(t, sessionC.GobDecode(save)) {
+		return
+	}
+
+	assert.Equal(t, sessionA.NewGuid(), sessionC.NewGuid())
+
+	// Empty intervals raise an error like the original math.random instead of panicking.
+	assert.ErrorContains(t, sessionC.luaState.DoString(`math.random(0)`), "interval is empty")
+	assert.ErrorContains(t, sessionC.luaState.DoString(`math.random(5, 1)`), "interval is empty")
+	assert.NoError(t, sessionC.luaState.DoString(`assert(math.random(3, 3) == 3)`))
+}
+
+func TestSessionReplay(t *testing.T) {
+	session := NewSession(WithSeed(42))
+	session.FinishPlayerTurn()
+	session.LetTellerDecide()
+	assert.Error(t, session.PlayerCastHand(0, ""))
+	assert.False(t, session.PlayerBuyCard("NOT_FOR_SALE"))
+	session.LeaveMerchant()
+
+	// LeaveMerchant lets the teller decide internally, which should not be recorded. Failed actions are
+	// not recorded either.
+	replay := session.ToReplay()
+	assert.Equal(t, int64(42), replay.Seed)
+	assert.Equal(t, []ReplayActionType{
+		ReplayActionFinishTurn,
+		ReplayActionLetTellerDecide,
+		ReplayActionLeaveMerchant,
+	}, lo.Map(replay.Actions, func(item ReplayAction, index int) ReplayActionType {
+		return item.Type
+	}))
+
+	file := filepath.Join(t.TempDir(), "session.replay")
+	if !assert.NoError(t, replay.Save(file)) {
+		return
+	}
+
+	loaded, err := LoadReplay(file)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	played := loaded.Play()
+	assert.NoError(t, loaded.Check(played))
+	assert.Equal(t, replay.Actions, played.ToReplay().Actions)
+
+	loaded.Result.PlayerGold += 1
+	assert.Error(t, loaded.Check(played))
+
+	// Actions that panic are not recorded.
+	assert.Panics(t, func() {
+		defer session.recordAction(ReplayAction{Type: ReplayActionFinishTurn})(nil)
+		panic("test")
+	})
+	assert.Equal(t, replay.Actions, session.ToReplay().Actions)
+	assert.Equal(t, 0, session.actionDepth)
+}
+
+func TestSessionSaveVersion(t *testing.T) {
+	sessionIn := NewSession()
+	sessionIn.stagesCleared = 5
+
+	t.Run("Legacy", func(t *testing.T) {
+		// Saves without envelope are version 0 and need to be migrated.
+		migrated := false
+		migration := saveMigrations[0]
+		saveMigrations[0] = func(save *SavedState) error {
+			migrated = true
+			return migration(save)
+		}
+		defer func() {
+			saveMigrations[0] = migration
+		}()
+
+		buf := &bytes.Buffer{}
+		if !assert.NoError(t, gob.NewEncoder(buf).Encode(sessionIn.ToSavedState())) {
+			return
+		}
+
+		sessionNew := NewSession()
+		if !assert.NoError(t, sessionNew.GobDecode(buf.Bytes())) {
+			return
+		}
+		assert.True(t, migrated)
+		assert.Equal(t, 5, sessionNew.GetStagesCleared())
+	})
+
+	t.Run("BlockStatusEffect", func(t *testing.T) {
+		// Version 1 saves turn the stacks of the removed BLOCK status effect into block.
+		state := sessionIn.ToSavedState()
+		state.Instances["OLD_BLOCK"] = StatusEffectInstance{GUID: "OLD_BLOCK", TypeID: "BLOCK", Owner: PlayerActorID, Stacks: 3}
+		state.Actors[PlayerActorID].StatusEffects.Add("OLD_BLOCK")
+
+		payload := &bytes.Buffer{}
+		if !assert.NoError(t, gob.NewEncoder(payload).Encode(state)) {
+			return
+		}
+		buf := &bytes.Buffer{}
+		if !assert.NoError(t, gob.NewEncoder(buf).Encode(SaveEnvelope{Version: 1, Payload: payload.Bytes()})) {
+			return
+		}
+
+		sessionNew := NewSession()
+		if !assert.NoError(t, sessionNew.GobDecode(buf.Bytes())) {
+			return
+		}
+		assert.Equal(t, sessionIn.GetPlayer().Block+3, sessionNew.GetPlayer().Block)
+		assert.NotContains(t, sessionNew.GetActorStatusEffects(PlayerActorID), "OLD_BLOCK")
+		assert.NotContains(t, sessionNew.instances, "OLD_BLOCK")
+	})
+
+	t.Run("TooNew", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		if !assert.NoError(t, gob.NewEncoder(buf).Encode(SaveEnvelope{
+			Version:     SaveVersion + 1,
+			GameVersion: "v99",
+			Payload:     []byte{1},
+		})) {
+			return
+		}
+
+		err := NewSession().GobDecode(buf.Bytes())
+		assert.ErrorIs(t, err, ErrSaveTooNew)
+		assert.Contains(t, err.Error(), "v99")
+	})
+
+	t.Run("MissingMods", func(t *testing.T) {
+		sessionIn.loadedMods = []string{"not_installed_mod"}
+		defer func() {
+			sessionIn.loadedMods = nil
+		}()
+
+		save, err := sessionIn.GobEncode()
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		err = NewSession().GobDecode(save)
+		assert.ErrorIs(t, err, ErrSaveMissingMods)
+		assert.Contains(t, err.Error(), "not_installed_mod")
+	})
+}
+
+func TestSessionJSON(t *testing.T) {
+	sessionIn := NewSession()
+	if err := sessionIn.luaState.DoString(`
+register_card("DEBUG_JSON_CARD", { name = "Json Card", description = "", color = "#cccccc", price = 10, callbacks = {} })
+store("table", { a = 1, b = { "x", "y" } })
+`); err != nil {
+		t.Fatal(err)
+	}
+
+	sessionIn.GiveCard("DEBUG_JSON_CARD", PlayerActorID)
+	sessionIn.GiveStatusEffect("NOT_EXISTING", PlayerActorID, 1)
+	sessionIn.instances["ARTIFACT"] = ArtifactInstance{TypeID: "A", GUID: "ARTIFACT", Owner: PlayerActorID}
+	sessionIn.PushState(map[StateEvent]any{
+		StateEventMoney: StateEventMoneyData{
+			Target: PlayerActorID,
+			Money:  10,
+		},
+	})
+
+	data, err := sessionIn.ExportJSON()
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Contains(t, string(data), `"type": "card"`)
+	assert.Contains(t, string(data), `"type": "event_money"`)
+
+	sessionNew := NewSession()
+	if !assert.NoError(t, sessionNew.ImportJSON(data)) {
+		return
+	}
+
+	assert.Equal(t, sessionIn.instances, sessionNew.instances)
+	assert.Equal(t, sessionIn.GetPlayer().Cards.ToSlice(), sessionNew.GetPlayer().Cards.ToSlice())
+	assert.Equal(t, sessionIn.Fetch("table"), sessionNew.Fetch("table"))
+	assert.Equal(t, sessionIn.stateCheckpoints[0].Events, sessionNew.stateCheckpoints[0].Events)
+	assert.Equal(t, sessionIn.stateCheckpoints[0].Session.GetPlayer().Gold, sessionNew.stateCheckpoints[0].Session.GetPlayer().Gold)
+
+	// Unknown type tags are rejected.
+	assert.Error(t, sessionNew.ImportJSON([]byte(`{"version": 1, "instances": {"X": {"type": "unknown"}}}`)))
+
+	// Older json saves are migrated like gob saves.
+	assert.NoError(t, sessionNew.ImportJSON([]byte(`{
+	"version": 1,
+	"actors": {"PLAYER": {"GUID": "PLAYER", "Block": 1, "StatusEffects": ["OLD_BLOCK"]}},
+	"instances": {"OLD_BLOCK": {"type": "status_effect", "GUID": "OLD_BLOCK", "TypeID": "BLOCK", "Owner": "PLAYER", "Stacks": 2}}
+}`)))
+	assert.Equal(t, 3, sessionNew.GetPlayer().Block)
+	assert.Empty(t, sessionNew.GetActorStatusEffects(PlayerActorID))
+	assert.Empty(t, sessionNew.instances)
+}
+
+func TestSessionCheckpointCompaction(t *testing.T) {
+	session := NewSession(WithCheckpointSnapshotEvery(3))
+	start := session.MarkState()
+
+	for i := 0; i < 10; i++ {
+		session.UpdatePlayer(func(actor *Actor) bool {
+			actor.Gold = i
+			return true
+		})
+
+		switch i {
+		case 2:
+			session.AddActor(NewActor("ENEMY"))
+		case 4:
+			session.eventHistory = append(session.eventHistory, "EVENT")
+		case 5:
+			session.RemoveActor("ENEMY")
+		}
+
+		session.PushState(map[StateEvent]any{
+			StateEventMoney: StateEventMoneyData{
+				Target: PlayerActorID,
+				Money:  i,
+			},
+		})
+	}
+
+	expected := lo.Map(start.Diff(session), func(item StateCheckpoint, index int) map[string]Actor {
+		return item.Session.actors
+	})
+
+	// Saving compacts a copy of the checkpoints, the checkpoints of the session stay untouched.
+	saved := session.ToSavedState()
+	assert.Len(t, lo.Filter(saved.StateCheckpoints, func(item StateCheckpoint, index int) bool { return item.Session != nil }), 4)
+	assert.True(t, lo.EveryBy(session.stateCheckpoints, func(item StateCheckpoint) bool { return item.Session != nil }))
+
+	session.compactCheckpoints()
+
+	// Only every third checkpoint keeps a full snapshot.
+	assert.Equal(t, []bool{true, false, false, true, false, false, true, false, false, true}, lo.Map(session.stateCheckpoints, func(item StateCheckpoint, index int) bool {
+		return item.Session != nil
+	}))
+
+	// Deltas only store the new entries of the event history.
+	assert.Equal(t, []string{"EVENT"}, session.stateCheckpoints[4].Delta.EventHistory)
+	assert.Empty(t, session.stateCheckpoints[5].Delta.EventHistory)
+	assert.Nil(t, session.stateCheckpoints[5].Delta.CurrentFight)
+
+	restored := start.Diff(session)
+	if !assert.Len(t, restored, 10) {
+		return
+	}
+	for i := range restored {
+		assert.Equal(t, expected[i], restored[i].Session.actors)
+		assert.Equal(t, i, restored[i].Session.GetPlayer().Gold)
+		assert.Equal(t, i >= 4, lo.Contains(restored[i].Session.GetEventHistory(), "EVENT"))
+	}
+
+	assert.Len(t, start.DiffEvent(session, StateEventMoney), 10)
+	assert.Equal(t, 8, session.GetFormerState(-2).GetPlayer().Gold)
+
+	// Compacted checkpoints survive a save.
+	save, err := session.GobEncode()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	sessionNew := NewSession()
+	if !assert.NoError(t, sessionNew.GobDecode(save)) {
+		return
+	}
+	assert.Equal(t, expected[4], start.Diff(sessionNew)[4].Session.actors)
+}
+
+func TestSessionCheckpointPruning(t *testing.T) {
+	session := NewSession()
+	start := session.MarkState()
+
+	pushMoney := func(money int) {
+		session.UpdatePlayer(func(actor *Actor) bool {
+			actor.Gold = money
+			return true
+		})
+		session.PushState(map[StateEvent]any{
+			StateEventMoney: StateEventMoneyData{Target: PlayerActorID, Money: money},
+		})
+	}
+
+	for i := 1; i <= 3; i++ {
+		pushMoney(i)
+	}
+
+	session.AddActor(NewActor("ENEMY"))
+	session.SetupFight()
+	fightStart := session.fightCheckpoint
+	pushMoney(4)
+
+	session.RemoveActor("ENEMY")
+	session.FinishFight()
+
+	// Only the first and the last checkpoint before the fight keep their state, the checkpoints of the
+	// fight are untouched.
+	for i := range session.stateCheckpoints {
+		pruned := session.stateCheckpoints[i].Session == nil && session.stateCheckpoints[i].Delta == nil
+		assert.Equal(t, i > 0 && i < fightStart-1, pruned, i)
+	}
+
+	// The events of pruned checkpoints are still available.
+	assert.Len(t, start.DiffEvent(session, StateEventMoney), 4)
+
+	// Pruned checkpoints have the state of the last kept checkpoint before them.
+	restored := start.Diff(session)
+	for i := range restored {
+		assert.NotNil(t, restored[i].Session, i)
+	}
+	assert.Equal(t, 1, restored[1].Session.GetPlayer().Gold)
+	assert.Equal(t, 3, restored[fightStart-1].Session.GetPlayer().Gold)
+	assert.Equal(t, 4, restored[len(restored)-1].Session.GetPlayer().Gold)
+}
+
+func TestSessionEvents(t *testing.T) {
+	session := NewSession()
+
+	if err := session.luaState.DoString(`
+register_status_effect(
+    "DEBUG_STATUS",
+    {
+        name = "Status",
+        description = "",
+        look = "S",
+        foreground = "#ffffff",
+        can_stack = true,
+        callbacks = {}
+    }
+);
+`); err != nil {
+		t.Fatal(err)
+	}
+
+	enemy := NewActor("ENEMY")
+	enemy.HP = 10
+	enemy.MaxHP = 10
+	enemy.Gold = 5
+	session.AddActor(enemy)
+
+	gold := session.GetPlayer().Gold
+
+	var damage []GameEventDamage
+	var all []GameEvent
+	unsubscribe := Subscribe(session, func(event GameEventDamage) {
+		damage = append(damage, event)
+	})
+	session.Events().SubscribeAll(func(event GameEvent) {
+		all = append(all, event)
+	})
+
+	session.DealDamage(PlayerActorID, "ENEMY", 3, true)
+	assert.Equal(t, []GameEventDamage{{Source: PlayerActorID, Target: "ENEMY", Damage: 3, HPLeft: 7}}, damage)
+
+	guid := session.GiveStatusEffect("DEBUG_STATUS", "ENEMY", 1)
+	session.GiveStatusEffect("DEBUG_STATUS", "ENEMY", 2)
+	session.RemoveStatusEffect(guid)
+
+	session.DealDamage(PlayerActorID, "ENEMY", 10, true)
+
+	// Unsubscribed handlers are not called anymore.
+	unsubscribe()
+	session.DealDamage(PlayerActorID, PlayerActorID, 1, true)
+	assert.Len(t, damage, 2)
+
+	assert.Equal(t, []GameEvent{
+		GameEventDamage{Source: PlayerActorID, Target: "ENEMY", Damage: 3, HPLeft: 7},
+		GameEventStatusAdded{GUID: guid, TypeID: "DEBUG_STATUS", Owner: "ENEMY", Stacks: 1},
+		GameEventStatusStacked{GUID: guid, TypeID: "DEBUG_STATUS", Owner: "ENEMY", Stacks: 3, Change: 2},
+		GameEventStatusRemoved{GUID: guid, TypeID: "DEBUG_STATUS", Owner: "ENEMY"},
+		// The gold drop is caused by the death, so it is published after it.
+		GameEventDamage{Source: PlayerActorID, Target: "ENEMY", Damage: 10, HPLeft: 0},
+		GameEventActorDeath{Source: PlayerActorID, Target: "ENEMY", Damage: 10},
+		GameEventGoldChanged{Target: PlayerActorID, Change: 5, Gold: gold + 5},
+		GameEventDamage{Source: PlayerActorID, Target: PlayerActorID, Damage: 1, HPLeft: session.GetPlayer().HP},
+	}, all)
+}
+
+func TestSessionUndo(t *testing.T) {
+	session := NewSession(WithSeed(42))
+
+	if err := session.luaState.DoString(`
+register_card(
+    "DEBUG_UNDO",
+    {
+        name = "Undo",
+        description = "",
+        point_cost = 1,
+        callbacks = {
+            on_cast = function(ctx)
+                deal_damage(ctx.caster, ctx.target, 3)
+                store("casts", (fetch("casts") or 0) + 1)
+                return nil
+            end,
+        }
+    }
+);
+`); err != nil {
+		t.Fatal(err)
+	}
+
+	enemy := NewActor("ENEMY")
+	enemy.HP = 10
+	enemy.MaxHP = 10
+	session.AddActor(enemy)
+
+	session.state = GameStateFight
+	session.currentFight.CurrentPoints = 3
+	session.currentFight.Hand = []string{
+		session.GiveCard("DEBUG_UNDO", PlayerActorID),
+		session.GiveCard("DEBUG_UNDO", PlayerActorID),
+	}
+
+	assert.False(t, session.CanUndo())
+	assert.Error(t, session.Undo())
+
+	if !assert.NoError(t, session.PlayerCastHand(0, "ENEMY")) {
+		return
+	}
+
+	fight := session.GetFight().Clone()
+	instances := CopyMap(session.instances)
+	draws := session.rng.draws
+
+	if !assert.NoError(t, session.PlayerCastHand(0, "ENEMY")) {
+		return
+	}
+	session.rnd.Int63()
+
+	assert.Equal(t, 4, session.GetActor("ENEMY").HP)
+	assert.Equal(t, float64(2), session.Fetch("casts"))
+	assert.True(t, session.CanUndo())
+
+	// Undo restores the state before the second cast.
+	if !assert.NoError(t, session.Undo()) {
+		return
+	}
+
+	assert.Equal(t, fight, session.GetFight())
+	assert.Equal(t, instances, session.instances)
+	assert.Equal(t, draws, session.rng.draws)
+	assert.Equal(t, 7, session.GetActor("ENEMY").HP)
+	assert.Equal(t, float64(1), session.Fetch("casts"))
+	assert.Len(t, session.replayActions, 1)
+
+	// The first cast can still be undone after the second.
+	assert.NoError(t, session.Undo())
+	assert.Equal(t, 10, session.GetActor("ENEMY").HP)
+	assert.Nil(t, session.Fetch("casts"))
+	assert.False(t, session.CanUndo())
+
+	// Casts of a finished turn can't be undone.
+	session.currentFight.Hand = []string{session.GiveCard("DEBUG_UNDO", PlayerActorID)}
+	assert.NoError(t, session.PlayerCastHand(0, "ENEMY"))
+	session.FinishPlayerTurn()
+	assert.False(t, session.CanUndo())
+	assert.Error(t, session.Undo())
+	assert.Equal(t, 7, session.GetActor("ENEMY").HP)
+
+	// Casts of a finished fight can't be undone.
+	session.currentFight.CurrentPoints = 3
+	session.currentFight.Hand = []string{
+		session.GiveCard("DEBUG_UNDO", PlayerActorID),
+		session.GiveCard("DEBUG_UNDO", PlayerActorID),
+		session.GiveCard("DEBUG_UNDO", PlayerActorID),
+	}
+	for i := 0; i < 3 && session.GetOpponentCount(PlayerActorID) > 0; i++ {
+		assert.NoError(t, session.PlayerCastHand(0, "ENEMY"))
+	}
+	assert.Equal(t, 0, session.GetOpponentCount(PlayerActorID))
+	assert.Equal(t, 1, session.GetStagesCleared())
+	assert.False(t, session.CanUndo())
+	assert.Error(t, session.Undo())
+}
+
+func TestSessionUndoRandom(t *testing.T) {
+	session := NewSession(WithSeed(42))
+
+	if err := session.luaState.DoString(`
+register_card(
+    "DEBUG_UNDO_RANDOM",
+    {
+        name = "Undo Random",
+        description = "",
+        point_cost = 1,
+        callbacks = {
+            on_cast = function(ctx)
+                local cards = fetch("cards") or {}
+                table.insert(cards, random_card(1000))
+                store("cards", cards)
+                return nil
+            end,
+        }
+    }
+);
+`); err != nil {
+		t.Fatal(err)
+	}
+
+	// The enemy keeps the fight going, so the cast can be undone.
+	session.AddActor(NewActor("ENEMY"))
+	session.state = GameStateFight
+	session.currentFight.CurrentPoints = 3
+	session.currentFight.Hand = []string{session.GiveCard("DEBUG_UNDO_RANDOM", PlayerActorID)}
+	session.Store("cards", []any{"START"})
+
+	if !assert.NoError(t, session.PlayerCastHand(0, "")) {
+		return
+	}
+	cards := session.Fetch("cards")
+	history := slices.Clone(session.randomHistory)
+
+	// Casting again after the undo picks the same random card, as the random history is restored too.
+	assert.NoError(t, session.Undo())
+	assert.Empty(t, session.randomHistory)
+	assert.Equal(t, []any{"START"}, session.Fetch("cards"))
+
+	if !assert.NoError(t, session.PlayerCastHand(0, "")) {
+		return
+	}
+	assert.Equal(t, cards, session.Fetch("cards"))
+	assert.Equal(t, history, session.randomHistory)
+}
+
+func TestSessionHotReload(t *testing.T) {
+	session := NewSession(WithLogging(log.New(io.Discard, "", 0)), WithHotReload())
+	defer session.Close()
+
+	dir := t.TempDir()
+	file := filepath.Join(dir, "card.lua")
+	write := func(name string) {
+		assert.NoError(t, os.WriteFile(file, []byte(`register_card("HOT", { name = "`+name+`", callbacks = {} })`), 0644))
+	}
+
+	session.hotReload.add(session.log, dir)
+	write("Before")
+
+	// Wait for the watcher to pick up the new file
+	assert.Eventually(t, func() bool {
+		session.ApplyHotReload()
+		return session.resources.Cards["HOT"] != nil
+	}, time.Second*5, time.Millisecond*50)
+
+	guid := session.GiveCard("HOT", PlayerActorID)
+	write("After")
+
+	assert.Eventually(t, func() bool {
+		session.ApplyHotReload()
+		card, _ := session.GetCard(guid)
+		return card != nil && card.Name == "After"
+	}, time.Second*5, time.Millisecond*50)
+	assert.False(t, session.resources.Cards["HOT"].BaseGame)
+}
+
+func TestSessionProfiling(t *testing.T) {
+	session := NewSession(WithLogging(log.New(io.Discard, "", 0)), WithProfiling())
+	defer session.Close()
+
+	assert.NoError(t, session.luaState.DoString(`
+register_card("PROFILED_CARD", {
+	name = "Profiled",
+	state = function(ctx) return "state" end,
+	callbacks = {
+		on_cast = function(ctx) return nil end
+	}
+})
+`))
+
+	guid := session.GiveCard("PROFILED_CARD", PlayerActorID)
+	session.CastCard(guid, "")
+	session.CastCard(guid, "")
+	session.GetCardState(guid)
+
+	calls := lo.SliceToMap(session.Profiler().Entries(), func(e luhelp.ProfileEntry) (string, int) {
+		return e.Key, e.Calls
+	})
+	assert.Equal(t, 2, calls["card:PROFILED_CARD:OnCast"])
+	assert.Equal(t, 1, calls["card:PROFILED_CARD:State"])
+
+	assert.NoError(t, session.luaState.DoString(`report = profile_report()`))
+	assert.Contains(t, lua.LVAsString(session.luaState.GetGlobal("report")), "card:PROFILED_CARD:OnCast")
+}
+
+func TestSessionRecentLuaErrors(t *testing.T) {
+	session := NewSession(WithLogging(log.New(io.Discard, "", 0)))
+	defer session.Close()
+
+	assert.NoError(t, session.luaState.DoString(`
+register_card("ERROR_CARD", {
+	name = "Error",
+	callbacks = {
+		on_cast = function(ctx) error("broken card") end
+	}
+})
+`))
+
+	guid := session.GiveCard("ERROR_CARD", PlayerActorID)
+	for i := 0; i < recentLuaErrorsMax+10; i++ {
+		session.CastCard(guid, "")
+		<-session.LuaErrors()
+	}
+
+	recent := session.RecentLuaErrors()
+	assert.Len(t, recent, recentLuaErrorsMax)
+	assert.Equal(t, CallbackOnCast, recent[0].Callback)
+
+	data, err := json.Marshal(recent[0])
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "broken card")
+}
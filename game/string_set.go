@@ -3,6 +3,7 @@ package game
 import (
 	"bytes"
 	"encoding/gob"
+	"encoding/json"
 	"github.com/samber/lo"
 	"sort"
 )
@@ -87,3 +88,34 @@ func CopyMap[K comparable, V any](m map[K]V) map[K]V {
 	}
 	return result
 }
+
+// deepCopyValue copies the maps and slices that lua tables are converted to, so the copy can be changed
+// without changing the original value.
+func deepCopyValue(value any) any {
+	switch value := value.(type) {
+	case map[any]any:
+		return lo.MapEntries(value, func(key any, value any) (any, any) { return key, deepCopyValue(value) })
+	case map[string]any:
+		return lo.MapValues(value, func(value any, key string) any { return deepCopyValue(value) })
+	case []any:
+		return lo.Map(value, func(item any, index int) any { return deepCopyValue(item) })
+	}
+	return value
+}
+
+func (s *StringSet) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.ToSlice())
+}
+
+func (s *StringSet) UnmarshalJSON(data []byte) error {
+	*s = StringSet{values: map[string]struct{}{}}
+
+	var keys []string
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return err
+	}
+
+	s.Append(keys...)
+
+	return nil
+}
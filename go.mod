@@ -25,6 +25,7 @@ require (
 	github.com/charmbracelet/wish v1.1.0
 	github.com/faiface/beep v1.1.0
 	github.com/fatih/structs v1.1.0
+	github.com/fsnotify/fsnotify v1.6.0
 	github.com/gobeam/stringy v0.0.6
 	github.com/hajimehoshi/ebiten/v2 v2.6.3
 	github.com/labstack/echo/v4 v4.11.3
@@ -67,7 +68,6 @@ require (
 	github.com/dop251/goja v0.0.0-20230122112309-96b1610dd4f7 // indirect
 	github.com/ebitengine/purego v0.5.0 // indirect
 	github.com/fatih/color v1.13.0 // indirect
-	github.com/fsnotify/fsnotify v1.6.0 // indirect
 	github.com/go-logfmt/logfmt v0.6.0 // indirect
 	github.com/go-sourcemap/sourcemap v2.1.3+incompatible // indirect
 	github.com/golang-jwt/jwt v3.2.2+incompatible // indirect
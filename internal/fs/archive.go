@@ -0,0 +1,306 @@
+package fs
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ArchiveExtensions are the file extensions of zip archives that can be mounted.
+var ArchiveExtensions = []string{".zip", ".eoemod"}
+
+// ErrReadOnly is returned when trying to write or remove a file inside a mounted archive.
+var ErrReadOnly = errors.New("file is inside a mounted archive and read-only")
+
+// MaxArchiveFileSize is the maximum uncompressed size of a single file inside a mounted archive and
+// MaxArchiveSize the maximum uncompressed size of all files of an archive together.
+var (
+	MaxArchiveFileSize int64 = 64 << 20
+	MaxArchiveSize     int64 = 512 << 20
+)
+
+// archive is a zip file that is mounted as read-only directory. Only the directory of the zip file is read
+// when mounting, the files are decompressed when they are read.
+type archive struct {
+	closer io.Closer
+	files  map[string]*zip.File
+	dirs   map[string]bool
+}
+
+var archiveMtx = sync.RWMutex{}
+var archives = map[string]*archive{}
+
+// IsArchive checks if the file has one of the archive extensions.
+func IsArchive(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	for i := range ArchiveExtensions {
+		if ext == ArchiveExtensions[i] {
+			return true
+		}
+	}
+	return false
+}
+
+// Mount reads the directory of the zip archive and mounts its content as read-only directory at the mount
+// point. If all files of the archive are inside a single top level folder, the content of that folder is
+// mounted. An archive that is already mounted at the mount point is replaced. Archives that are bigger than
+// MaxArchiveSize or contain files bigger than MaxArchiveFileSize when uncompressed are rejected.
+func Mount(file string, mountPoint string) error {
+	reader, closer, err := openArchive(file)
+	if err != nil {
+		return fmt.Errorf("can't open archive %s: %w", file, err)
+	}
+
+	mountPoint = filepath.Clean(mountPoint)
+	prefix := archivePrefix(reader.File)
+	mounted := &archive{
+		closer: closer,
+		files:  map[string]*zip.File{},
+		dirs:   map[string]bool{mountPoint: true},
+	}
+
+	var size uint64
+	for _, f := range reader.File {
+		name := strings.TrimPrefix(filepath.ToSlash(f.Name), prefix)
+		path := filepath.Join(mountPoint, filepath.FromSlash(name))
+
+		// Ignore entries that would end up outside the mount point (e.g. '../../file').
+		if len(name) == 0 || !strings.HasPrefix(path, mountPoint+string(filepath.Separator)) {
+			continue
+		}
+
+		for dir := filepath.Dir(path); dir != mountPoint; dir = filepath.Dir(dir) {
+			mounted.dirs[dir] = true
+		}
+
+		if f.FileInfo().IsDir() {
+			mounted.dirs[path] = true
+			continue
+		}
+
+		size += f.UncompressedSize64
+		if f.UncompressedSize64 > uint64(MaxArchiveFileSize) || size > uint64(MaxArchiveSize) {
+			_ = closer.Close()
+			return fmt.Errorf("archive %s is too large", file)
+		}
+		mounted.files[path] = f
+	}
+
+	archiveMtx.Lock()
+	defer archiveMtx.Unlock()
+
+	if old, ok := archives[mountPoint]; ok {
+		_ = old.closer.Close()
+	}
+	archives[mountPoint] = mounted
+	return nil
+}
+
+// MountArchives mounts the archives in the folder. Each archive is mounted next to it under its name
+// without extension, e.g. './mods/my_mod.zip' is mounted at './mods/my_mod'. If names are given, only the
+// archives with these names (without extension) are mounted. Returns the mount points.
+func MountArchives(folder string, names ...string) ([]string, error) {
+	entries, err := ReadDir(folder)
+	if err != nil {
+		return nil, err
+	}
+
+	var mountPoints []string
+	var errs []error
+	for _, e := range entries {
+		if e.IsDir() || !IsArchive(e.Path) {
+			continue
+		}
+
+		mountPoint := strings.TrimSuffix(e.Path, filepath.Ext(e.Path))
+		if len(names) > 0 && !slices.Contains(names, filepath.Base(mountPoint)) {
+			continue
+		}
+
+		if err := Mount(e.Path, mountPoint); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		mountPoints = append(mountPoints, mountPoint)
+	}
+
+	return mountPoints, errors.Join(errs...)
+}
+
+// Unmount removes the archive mounted at the mount point.
+func Unmount(mountPoint string) {
+	archiveMtx.Lock()
+	defer archiveMtx.Unlock()
+
+	mountPoint = filepath.Clean(mountPoint)
+	if a, ok := archives[mountPoint]; ok {
+		_ = a.closer.Close()
+		delete(archives, mountPoint)
+	}
+}
+
+// IsMounted checks if the path is inside a mounted archive.
+func IsMounted(path string) bool {
+	_, ok := findArchive(path)
+	return ok
+}
+
+// archivePrefix returns the top level folder if all files are inside it, otherwise an empty string.
+func archivePrefix(files []*zip.File) string {
+	prefix := ""
+	for i, f := range files {
+		name := filepath.ToSlash(f.Name)
+		first, _, ok := strings.Cut(name, "/")
+		if !ok {
+			return ""
+		}
+		if i == 0 {
+			prefix = first
+		} else if first != prefix {
+			return ""
+		}
+	}
+	if len(prefix) == 0 {
+		return ""
+	}
+	return prefix + "/"
+}
+
+// findArchive returns the archive that contains the path.
+func findArchive(path string) (*archive, bool) {
+	archiveMtx.RLock()
+	defer archiveMtx.RUnlock()
+
+	path = filepath.Clean(path)
+	for mountPoint, a := range archives {
+		if path == mountPoint || strings.HasPrefix(path, mountPoint+string(filepath.Separator)) {
+			return a, true
+		}
+	}
+	return nil, false
+}
+
+// archiveMountsIn returns the mount points that are direct children of the folder.
+func archiveMountsIn(folder string) []FileInfo {
+	archiveMtx.RLock()
+	defer archiveMtx.RUnlock()
+
+	folder = filepath.Clean(folder)
+	var fis []FileInfo
+	for mountPoint := range archives {
+		if filepath.Dir(mountPoint) == folder {
+			fis = append(fis, FileInfo{Path: mountPoint})
+		}
+	}
+	sort.Slice(fis, func(i, j int) bool { return fis[i].Path < fis[j].Path })
+	return fis
+}
+
+// appendArchiveMounts adds the mount points in the folder to the entries of the folder. Mount points
+// replace real entries with the same path.
+func appendArchiveMounts(folder string, fis []FileInfo) []FileInfo {
+	mounts := archiveMountsIn(folder)
+	if len(mounts) == 0 {
+		return fis
+	}
+
+	var res []FileInfo
+	for _, fi := range fis {
+		if !IsMounted(fi.Path) {
+			res = append(res, fi)
+		}
+	}
+	return append(res, mounts...)
+}
+
+func (a *archive) readFile(path string) ([]byte, error) {
+	f, ok := a.files[filepath.Clean(path)]
+	if !ok {
+		return nil, fmt.Errorf("open %s: file does not exist", path)
+	}
+
+	rc, err := f.Open()
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %w", path, err)
+	}
+	defer rc.Close()
+
+	// The size in the zip directory could be wrong, so the limit is checked while reading too.
+	data, err := io.ReadAll(io.LimitReader(rc, MaxArchiveFileSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", path, err)
+	}
+	if int64(len(data)) > MaxArchiveFileSize {
+		return nil, fmt.Errorf("read %s: file is too large", path)
+	}
+	return data, nil
+}
+
+func (a *archive) readDir(path string) ([]FileInfo, error) {
+	path = filepath.Clean(path)
+	if !a.dirs[path] {
+		return nil, fmt.Errorf("open %s: no such directory", path)
+	}
+
+	var fis []FileInfo
+	for _, p := range a.paths() {
+		if filepath.Dir(p) == path && p != path {
+			fis = append(fis, FileInfo{Path: p, IsFile: !a.dirs[p]})
+		}
+	}
+	return fis, nil
+}
+
+func (a *archive) walk(root string, walkFn func(path string, isDir bool) error) error {
+	clean := filepath.Clean(root)
+	if _, ok := a.files[clean]; !ok && !a.dirs[clean] {
+		return fmt.Errorf("lstat %s: no such file or directory", root)
+	}
+
+	skip := ""
+	for _, p := range a.paths() {
+		if p != clean && !strings.HasPrefix(p, clean+string(filepath.Separator)) {
+			continue
+		}
+		if len(skip) > 0 && strings.HasPrefix(p, skip+string(filepath.Separator)) {
+			continue
+		}
+
+		path := p
+		if p == clean {
+			path = root
+		}
+
+		if err := walkFn(path, a.dirs[p]); err != nil {
+			if errors.Is(err, filepath.SkipDir) && a.dirs[p] {
+				skip = p
+				continue
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// paths returns all files and directories of the archive in lexical order, so that walking the archive
+// behaves like filepath.Walk.
+func (a *archive) paths() []string {
+	paths := make([]string, 0, len(a.files)+len(a.dirs))
+	for p := range a.files {
+		paths = append(paths, p)
+	}
+	for p := range a.dirs {
+		paths = append(paths, p)
+	}
+	// Replacing the separator with the lowest byte sorts a directory directly before its content.
+	sort.Slice(paths, func(i, j int) bool {
+		return strings.ReplaceAll(paths[i], string(filepath.Separator), "\x00") < strings.ReplaceAll(paths[j], string(filepath.Separator), "\x00")
+	})
+	return paths
+}
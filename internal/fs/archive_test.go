@@ -0,0 +1,146 @@
+package fs
+
+import (
+	"archive/zip"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeZip(t *testing.T, file string, files map[string]string) {
+	f, err := os.Create(file)
+	assert.NoError(t, err)
+	defer f.Close()
+
+	w := zip.NewWriter(f)
+	for name, content := range files {
+		fw, err := w.Create(name)
+		assert.NoError(t, err)
+		_, err = fw.Write([]byte(content))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, w.Close())
+}
+
+func TestMountArchives(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.Mkdir(filepath.Join(dir, "folder_mod"), 0755))
+	writeZip(t, filepath.Join(dir, "zip_mod.zip"), map[string]string{
+		"meta.json":         `{"name": "Zip"}`,
+		"scripts/cards.lua": "-- cards",
+		"images/card.png":   "png",
+	})
+	writeZip(t, filepath.Join(dir, "nested_mod.eoemod"), map[string]string{
+		"nested_mod/meta.json":     `{"name": "Nested"}`,
+		"nested_mod/locals/en.yml": "en:",
+	})
+	writeZip(t, filepath.Join(dir, "evil.zip"), map[string]string{
+		"../../escape.lua": "-- escape",
+	})
+
+	mountPoints, err := MountArchives(dir)
+	assert.NoError(t, err)
+	assert.Len(t, mountPoints, 3)
+	defer func() {
+		for _, mountPoint := range mountPoints {
+			Unmount(mountPoint)
+		}
+	}()
+
+	t.Run("ReadDir", func(t *testing.T) {
+		entries, err := ReadDir(dir)
+		assert.NoError(t, err)
+
+		dirs := map[string]bool{}
+		for _, e := range entries {
+			if e.IsDir() {
+				dirs[e.Name()] = true
+			}
+		}
+		assert.Equal(t, map[string]bool{"folder_mod": true, "zip_mod": true, "nested_mod": true, "evil": true}, dirs)
+
+		entries, err = ReadDir(filepath.Join(dir, "zip_mod"))
+		assert.NoError(t, err)
+		assert.Equal(t, []FileInfo{
+			{Path: filepath.Join(dir, "zip_mod", "images")},
+			{Path: filepath.Join(dir, "zip_mod", "meta.json"), IsFile: true},
+			{Path: filepath.Join(dir, "zip_mod", "scripts")},
+		}, entries)
+	})
+
+	t.Run("ReadFile", func(t *testing.T) {
+		data, err := ReadFile(filepath.Join(dir, "zip_mod", "/meta.json"))
+		assert.NoError(t, err)
+		assert.Equal(t, `{"name": "Zip"}`, string(data))
+
+		// Single top level folders are stripped.
+		data, err = ReadFile(filepath.Join(dir, "nested_mod", "locals", "en.yml"))
+		assert.NoError(t, err)
+		assert.Equal(t, "en:", string(data))
+
+		_, err = ReadFile(filepath.Join(dir, "zip_mod", "missing.lua"))
+		assert.Error(t, err)
+	})
+
+	t.Run("Walk", func(t *testing.T) {
+		var paths []string
+		assert.NoError(t, Walk(filepath.Join(dir, "zip_mod"), func(path string, isDir bool) error {
+			paths = append(paths, path)
+			return nil
+		}))
+		assert.Equal(t, []string{
+			filepath.Join(dir, "zip_mod"),
+			filepath.Join(dir, "zip_mod", "images"),
+			filepath.Join(dir, "zip_mod", "images", "card.png"),
+			filepath.Join(dir, "zip_mod", "meta.json"),
+			filepath.Join(dir, "zip_mod", "scripts"),
+			filepath.Join(dir, "zip_mod", "scripts", "cards.lua"),
+		}, paths)
+
+		var evil []string
+		assert.NoError(t, Walk(filepath.Join(dir, "evil"), func(path string, isDir bool) error {
+			evil = append(evil, path)
+			return nil
+		}))
+		assert.Equal(t, []string{filepath.Join(dir, "evil")}, evil)
+	})
+
+	t.Run("ReadOnly", func(t *testing.T) {
+		assert.ErrorIs(t, WriteFile(filepath.Join(dir, "zip_mod", "meta.json"), nil), ErrReadOnly)
+		assert.ErrorIs(t, Remove(filepath.Join(dir, "zip_mod", "meta.json")), ErrReadOnly)
+		assert.True(t, IsMounted(filepath.Join(dir, "zip_mod", "scripts")))
+		assert.False(t, IsMounted(filepath.Join(dir, "folder_mod")))
+	})
+}
+
+func TestMountArchivesLimits(t *testing.T) {
+	dir := t.TempDir()
+	writeZip(t, filepath.Join(dir, "small.zip"), map[string]string{
+		"meta.json": `{"name": "Small"}`,
+	})
+	writeZip(t, filepath.Join(dir, "big.zip"), map[string]string{
+		"meta.json": strings.Repeat("x", 2048),
+	})
+
+	defer func(fileSize int64) { MaxArchiveFileSize = fileSize }(MaxArchiveFileSize)
+	MaxArchiveFileSize = 1024
+
+	// Only the archives with the given names are mounted.
+	mountPoints, err := MountArchives(dir, "small")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(dir, "small")}, mountPoints)
+	defer Unmount(filepath.Join(dir, "small"))
+	assert.False(t, IsMounted(filepath.Join(dir, "big")))
+
+	// Archives with files above the limit are rejected.
+	mountPoints, err = MountArchives(dir)
+	assert.Error(t, err)
+	assert.Equal(t, []string{filepath.Join(dir, "small")}, mountPoints)
+	assert.False(t, IsMounted(filepath.Join(dir, "big")))
+
+	data, err := ReadFile(filepath.Join(dir, "small", "meta.json"))
+	assert.NoError(t, err)
+	assert.Equal(t, `{"name": "Small"}`, string(data))
+}
@@ -4,6 +4,7 @@
 package fs
 
 import (
+	"archive/zip"
 	"github.com/samber/lo"
 	"io"
 	"os"
@@ -11,31 +12,55 @@ import (
 )
 
 func ReadDir(path string) ([]FileInfo, error) {
+	if a, ok := findArchive(path); ok {
+		return a.readDir(path)
+	}
+
 	dir, err := os.ReadDir(path)
 	if err != nil {
 		return nil, err
 	}
-	return lo.Map(dir, func(f os.DirEntry, i int) FileInfo {
+	return appendArchiveMounts(path, lo.Map(dir, func(f os.DirEntry, i int) FileInfo {
 		return FileInfo{
 			Path:   filepath.Join(path, f.Name()),
 			IsFile: !f.IsDir(),
 		}
-	}), nil
+	})), nil
 }
 
 func OpenFile(name string, flag int, perm os.FileMode) (io.WriteCloser, error) {
+	if IsMounted(name) {
+		return nil, ErrReadOnly
+	}
 	return os.OpenFile(name, flag, perm)
 }
 
 func ReadFile(path string) ([]byte, error) {
+	if a, ok := findArchive(path); ok {
+		return a.readFile(path)
+	}
 	return os.ReadFile(path)
 }
 
 func WriteFile(path string, data []byte) error {
+	if IsMounted(path) {
+		return ErrReadOnly
+	}
 	return os.WriteFile(path, data, 0644)
 }
 
+func Remove(path string) error {
+	if IsMounted(path) {
+		return ErrReadOnly
+	}
+	return os.Remove(path)
+}
+
 func Walk(root string, walkFn func(path string, isDir bool) error) error {
+	if a, ok := findArchive(root); ok {
+		return a.walk(root, walkFn)
+	}
+
 	return filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
@@ -43,3 +68,25 @@ func Walk(root string, walkFn func(path string, isDir bool) error) error {
 		return walkFn(path, info.IsDir())
 	})
 }
+
+// openArchive opens the zip archive without reading it into memory. The file stays open until the returned
+// closer is called.
+func openArchive(path string) (*zip.Reader, io.Closer, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return nil, nil, err
+	}
+
+	reader, err := zip.NewReader(f, info.Size())
+	if err != nil {
+		_ = f.Close()
+		return nil, nil, err
+	}
+	return reader, f, nil
+}
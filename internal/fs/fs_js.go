@@ -4,9 +4,10 @@
 package fs
 
 import (
+	"archive/zip"
+	"bytes"
 	"encoding/base64"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"github.com/samber/lo"
 	"io"
@@ -54,6 +55,10 @@ func init() {
 }
 
 func ReadDir(path string) ([]FileInfo, error) {
+	if a, ok := findArchive(path); ok {
+		return a.readDir(path)
+	}
+
 	cleanPath := filepath.Clean(path)
 	var fis []FileInfo
 	for indexPath := range fileIndex {
@@ -61,10 +66,26 @@ func ReadDir(path string) ([]FileInfo, error) {
 			fis = append(fis, fileIndex[path])
 		}
 	}
-	return fis, nil
+
+	// Check for temp files
+	jsRes := js.Global().Call("fsList", cleanPath)
+	if !jsRes.IsNull() && !jsRes.IsUndefined() {
+		for i := 0; i < jsRes.Length(); i++ {
+			fis = append(fis, FileInfo{
+				Path:   jsRes.Index(i).String(),
+				IsFile: true,
+			})
+		}
+	}
+
+	return appendArchiveMounts(path, fis), nil
 }
 
 func ReadFile(path string) ([]byte, error) {
+	if a, ok := findArchive(path); ok {
+		return a.readFile(path)
+	}
+
 	// Check for temp file
 	jsRes := js.Global().Call("fsRead", path)
 	if !jsRes.IsNull() && !jsRes.IsUndefined() {
@@ -72,7 +93,7 @@ func ReadFile(path string) ([]byte, error) {
 	}
 
 	if !strings.HasPrefix(filepath.Clean(path), "assets") {
-		return nil, errors.New("could not load file: file not found")
+		return nil, fmt.Errorf("could not load file: %w", os.ErrNotExist)
 	}
 
 	// Check for asset
@@ -92,17 +113,38 @@ func ReadFile(path string) ([]byte, error) {
 }
 
 func OpenFile(name string, flag int, perm os.FileMode) (io.WriteCloser, error) {
+	if IsMounted(name) {
+		return nil, ErrReadOnly
+	}
+
 	// TODO: Implement
 	return noOpWriteCloser{}, nil
 }
 
 func WriteFile(path string, data []byte) error {
+	if IsMounted(path) {
+		return ErrReadOnly
+	}
+
 	// TODO: error handling
 	_ = js.Global().Call("fsWrite", path, base64.StdEncoding.EncodeToString(data))
 	return nil
 }
 
+func Remove(path string) error {
+	if IsMounted(path) {
+		return ErrReadOnly
+	}
+
+	_ = js.Global().Call("fsRemove", path)
+	return nil
+}
+
 func Walk(root string, walkFn func(path string, isDir bool) error) error {
+	if a, ok := findArchive(root); ok {
+		return a.walk(root, walkFn)
+	}
+
 	keys := lo.Keys(fileIndex)
 	sort.Strings(keys)
 
@@ -118,3 +160,18 @@ func Walk(root string, walkFn func(path string, isDir bool) error) error {
 	}
 	return nil
 }
+
+// openArchive opens the zip archive. The browser storage has no random access, so the compressed archive
+// is kept in memory.
+func openArchive(path string) (*zip.Reader, io.Closer, error) {
+	data, err := ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, nil, err
+	}
+	return reader, io.NopCloser(nil), nil
+}
@@ -0,0 +1,298 @@
+package luhelp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	lua "github.com/yuin/gopher-lua"
+	"math"
+	"math/bits"
+	"runtime/metrics"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Limits restricts the resources a single call into lua can use. Calls that are nested inside another call,
+// e.g. a callback that is triggered by a game function that was called from lua, share the limits of the
+// outermost call. A zero value means no limit.
+//
+// Memory limits the growth of the go heap during the call. The heap is sampled between instructions, more
+// often the closer the growth is to the limit, as a single instruction like a concatenation with .. can
+// double the memory a script holds. The heap is shared by the whole process, so allocations of other
+// goroutines count too and the limit should be well above the normal heap growth. The builtins that can
+// create a large string with a single instruction are additionally bounded by StringSize.
+type Limits struct {
+	// Instructions is the maximum number of executed lua instructions.
+	Instructions int64
+
+	// Memory is the maximum number of bytes the heap can grow during the call.
+	Memory int64
+
+	// StringSize is the maximum number of bytes of a string created by string.rep or table.concat.
+	StringSize int
+
+	// Timeout is the maximum wall time of the call. It also covers coroutines and long-running go functions,
+	// which are not counted as instructions.
+	Timeout time.Duration
+}
+
+var (
+	ErrInstructionLimit = errors.New("instruction limit exceeded")
+	ErrMemoryLimit      = errors.New("memory limit exceeded")
+	ErrStringSizeLimit  = errors.New("string size limit exceeded")
+	ErrTimeLimit        = errors.New("time limit exceeded")
+)
+
+const (
+	limitsRegistryKey     = "__eoe_limits"
+	stringCapsRegistryKey = "__eoe_string_caps"
+)
+
+const (
+	// heapMetric are the bytes of the heap objects, including dead objects that are not yet freed.
+	heapMetric = "/memory/classes/heap/objects:bytes"
+
+	// memoryCheckMinGrowth is the growth the check interval is based on as long as the heap grew less. It
+	// covers the data a script already holds when the call starts.
+	memoryCheckMinGrowth = 1 << 20
+
+	// memoryCheckMaxInterval is the maximum number of instructions between two heap samples.
+	memoryCheckMaxInterval = 64
+)
+
+// SetLimits sets the limits that are applied to all calls of callbacks created by BindToLua and to
+// CallLimited.
+func SetLimits(state *lua.LState, limits Limits) {
+	ud := state.NewUserData()
+	ud.Value = limits
+	state.SetField(state.Get(lua.RegistryIndex), limitsRegistryKey, ud)
+	capStrings(state)
+}
+
+// capStrings replaces string.rep and table.concat with versions that check the StringSize limit before the
+// string is allocated. The limit is read on each call, so this only needs to happen once per state.
+func capStrings(state *lua.LState) {
+	registry := state.Get(lua.RegistryIndex)
+	if state.GetField(registry, stringCapsRegistryKey) == lua.LTrue {
+		return
+	}
+	state.SetField(registry, stringCapsRegistryKey, lua.LTrue)
+
+	wrap := func(lib string, name string, size func(state *lua.LState) int) {
+		table, ok := state.GetGlobal(lib).(*lua.LTable)
+		if !ok {
+			return
+		}
+		original, ok := table.RawGetString(name).(*lua.LFunction)
+		if !ok {
+			return
+		}
+
+		table.RawSetString(name, state.NewFunction(func(state *lua.LState) int {
+			if limits, ok := GetLimits(state); ok && limits.StringSize > 0 {
+				if n := size(state); n < 0 || n > limits.StringSize {
+					err := fmt.Errorf("%w (%d bytes)", ErrStringSizeLimit, limits.StringSize)
+					if ctx, ok := state.Context().(*limitContext); ok {
+						ctx.cancel(err)
+					}
+					state.RaiseError("%s", err)
+					return 0
+				}
+			}
+
+			top := state.GetTop()
+			state.Push(original)
+			for i := 1; i <= top; i++ {
+				state.Push(state.Get(i))
+			}
+			state.Call(top, 1)
+			return 1
+		}))
+	}
+
+	// A negative size means the size overflowed.
+	wrap("string", "rep", func(state *lua.LState) int {
+		str := state.CheckString(1)
+		n := state.CheckInt(2)
+		if n <= 0 || len(str) == 0 {
+			return 0
+		}
+		if len(str) > math.MaxInt/n {
+			return -1
+		}
+		return len(str) * n
+	})
+	wrap("table", "concat", func(state *lua.LState) int {
+		table := state.CheckTable(1)
+		sep := state.OptString(2, "")
+		from := state.OptInt(3, 1)
+		to := state.OptInt(4, table.Len())
+
+		size := 0
+		for i := from; i <= to; i++ {
+			if i > from {
+				size += len(sep)
+			}
+			switch value := table.RawGetInt(i).(type) {
+			case lua.LString:
+				size += len(value)
+			case lua.LNumber:
+				size += len(value.String())
+			}
+			if size < 0 {
+				return -1
+			}
+		}
+		return size
+	})
+}
+
+// GetLimits returns the limits of the state. If no limits are set false is returned.
+func GetLimits(state *lua.LState) (Limits, bool) {
+	if ud, ok := state.GetField(state.Get(lua.RegistryIndex), limitsRegistryKey).(*lua.LUserData); ok {
+		limits, ok := ud.Value.(Limits)
+		return limits, ok
+	}
+	return Limits{}, false
+}
+
+// CallLimited runs fn with the limits of the state. If a limit is exceeded the lua code that is running is
+// stopped with an error that contains the exceeded limit.
+func CallLimited(state *lua.LState, fn func() error) error {
+	limits, ok := GetLimits(state)
+	if !ok || state.Context() != nil {
+		return fn()
+	}
+
+	ctx := newLimitContext(limits)
+	state.SetContext(ctx)
+	defer func() {
+		state.RemoveContext()
+		ctx.stop()
+	}()
+
+	if err := fn(); err != nil {
+		if limitErr := ctx.Err(); limitErr != nil {
+			return limitError{lua: err, limit: limitErr}
+		}
+		return err
+	}
+	return nil
+}
+
+// limitError is the lua error of a call that exceeded a limit. It keeps the message and traceback of the
+// lua error, but can be checked with errors.Is against the exceeded limit.
+type limitError struct {
+	lua   error
+	limit error
+}
+
+func (e limitError) Error() string {
+	return e.lua.Error()
+}
+
+func (e limitError) Unwrap() []error {
+	return []error{e.lua, e.limit}
+}
+
+// limitContext is cancelled as soon as a limit is exceeded. gopher-lua checks Done before each instruction
+// it executes, so counting the calls of Done counts the executed instructions.
+type limitContext struct {
+	context.Context
+
+	limits       Limits
+	instructions atomic.Int64
+
+	memoryMtx       sync.Mutex
+	memorySample    []metrics.Sample
+	memoryStart     int64
+	nextMemoryCheck atomic.Int64
+
+	once  sync.Once
+	done  chan struct{}
+	err   error
+	timer *time.Timer
+}
+
+func newLimitContext(limits Limits) *limitContext {
+	ctx := &limitContext{
+		Context: context.Background(),
+		limits:  limits,
+		done:    make(chan struct{}),
+	}
+	if limits.Memory > 0 {
+		ctx.memorySample = []metrics.Sample{{Name: heapMetric}}
+		ctx.memoryStart = ctx.heapBytes()
+	}
+	if limits.Timeout > 0 {
+		ctx.timer = time.AfterFunc(limits.Timeout, func() {
+			ctx.cancel(fmt.Errorf("%w (%s)", ErrTimeLimit, limits.Timeout))
+		})
+	}
+	return ctx
+}
+
+func (c *limitContext) Done() <-chan struct{} {
+	n := c.instructions.Add(1)
+
+	if c.limits.Instructions > 0 && n > c.limits.Instructions {
+		c.cancel(fmt.Errorf("%w (%d)", ErrInstructionLimit, c.limits.Instructions))
+	}
+
+	if c.limits.Memory > 0 && n >= c.nextMemoryCheck.Load() {
+		c.checkMemory(n)
+	}
+
+	return c.done
+}
+
+// checkMemory samples the heap and cancels the context if it grew more than the limit. As the memory held
+// by the script can at most double with each instruction, the next sample is taken after as many
+// instructions as the growth can double before it reaches the limit.
+func (c *limitContext) checkMemory(n int64) {
+	c.memoryMtx.Lock()
+	defer c.memoryMtx.Unlock()
+
+	growth := c.heapBytes() - c.memoryStart
+	if growth > c.limits.Memory {
+		c.cancel(fmt.Errorf("%w (%d bytes)", ErrMemoryLimit, c.limits.Memory))
+		return
+	}
+
+	interval := bits.Len64(uint64(c.limits.Memory/max(growth, memoryCheckMinGrowth))) - 1
+	c.nextMemoryCheck.Store(n + int64(min(max(interval, 1), memoryCheckMaxInterval)))
+}
+
+func (c *limitContext) heapBytes() int64 {
+	metrics.Read(c.memorySample)
+	if c.memorySample[0].Value.Kind() != metrics.KindUint64 {
+		return 0
+	}
+	return int64(c.memorySample[0].Value.Uint64())
+}
+
+func (c *limitContext) Err() error {
+	select {
+	case <-c.done:
+		return c.err
+	default:
+		return nil
+	}
+}
+
+func (c *limitContext) cancel(err error) {
+	c.once.Do(func() {
+		c.err = err
+		close(c.done)
+	})
+}
+
+// stop releases the timer and cancels the context, so that goroutines that wait for it, e.g. started by
+// coroutines, are stopped.
+func (c *limitContext) stop() {
+	if c.timer != nil {
+		c.timer.Stop()
+	}
+	c.cancel(context.Canceled)
+}
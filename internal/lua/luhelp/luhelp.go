@@ -84,13 +84,15 @@ var noProtect = os.Getenv("EOE_NO_PROTECT") == "1"
 func BindToLua(state *lua.LState, value lua.LValue) OwnedCallback {
 	return func(args ...any) (any, error) {
 		// Call our lua function
-		if err := state.CallByParam(lua.P{
-			Fn:      value,
-			NRet:    1,
-			Protect: !noProtect,
-		}, lo.Map(args, func(item any, index int) lua.LValue {
-			return ToLua(state, item)
-		})...); err != nil {
+		if err := CallLimited(state, func() error {
+			return state.CallByParam(lua.P{
+				Fn:      value,
+				NRet:    1,
+				Protect: !noProtect,
+			}, lo.Map(args, func(item any, index int) lua.LValue {
+				return ToLua(state, item)
+			})...)
+		}); err != nil {
 			return nil, err
 		}
 
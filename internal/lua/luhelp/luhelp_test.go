@@ -3,7 +3,9 @@ package luhelp
 import (
 	"github.com/stretchr/testify/assert"
 	lua "github.com/yuin/gopher-lua"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestLuHelp(t *testing.T) {
@@ -88,3 +90,134 @@ func TestLuHelp(t *testing.T) {
 		assert.Equal(t, data, passed)
 	})
 }
+
+func TestLimits(t *testing.T) {
+	state := lua.NewState()
+	defer state.Close()
+
+	bind := func(code string) OwnedCallback {
+		assert.NoError(t, state.DoString("fn = "+code))
+		return BindToLua(state, state.GetGlobal("fn"))
+	}
+
+	t.Run("Instructions", func(t *testing.T) {
+		SetLimits(state, Limits{Instructions: 10000})
+
+		_, err := bind(`function() while true do end end`).Call()
+		assert.ErrorIs(t, err, ErrInstructionLimit)
+
+		res, err := bind(`function(n) local sum = 0 for i = 1, n do sum = sum + i end return sum end`).Call(100)
+		assert.NoError(t, err)
+		assert.Equal(t, float64(5050), res)
+
+		// The state is usable after a limit was exceeded.
+		assert.Nil(t, state.Context())
+	})
+
+	t.Run("StringSize", func(t *testing.T) {
+		SetLimits(state, Limits{StringSize: 1 << 20})
+
+		_, err := bind(`function() return string.rep("x", 2^31) end`).Call()
+		assert.ErrorIs(t, err, ErrStringSizeLimit)
+
+		_, err = bind(`function() return ("xx"):rep(2^62) end`).Call()
+		assert.ErrorIs(t, err, ErrStringSizeLimit)
+
+		_, err = bind(`function() local s = string.rep("x", 2^19) return table.concat({ s, s, s }) end`).Call()
+		assert.ErrorIs(t, err, ErrStringSizeLimit)
+
+		res, err := bind(`function() return table.concat({ string.rep("ab", 2), 1 }, ",") end`).Call()
+		assert.NoError(t, err)
+		assert.Equal(t, "abab,1", res)
+	})
+
+	t.Run("Memory", func(t *testing.T) {
+		SetLimits(state, Limits{Memory: 16 << 20, StringSize: 1 << 20})
+
+		// Each concatenation doubles the string, without the limit this would need 1 TiB.
+		_, err := bind(`function() local s = "x" for i = 1, 40 do s = s .. s end return #s end`).Call()
+		assert.ErrorIs(t, err, ErrMemoryLimit)
+
+		_, err = bind(`function() local s = "x" for i = 1, 20 do s = s .. s .. s .. s end return #s end`).Call()
+		assert.ErrorIs(t, err, ErrMemoryLimit)
+
+		res, err := bind(`function() local s = "x" for i = 1, 10 do s = s .. s end return #s end`).Call()
+		assert.NoError(t, err)
+		assert.Equal(t, float64(1024), res)
+	})
+
+	t.Run("Timeout", func(t *testing.T) {
+		SetLimits(state, Limits{Timeout: time.Millisecond * 50})
+
+		_, err := bind(`function() while true do end end`).Call()
+		assert.ErrorIs(t, err, ErrTimeLimit)
+	})
+
+	t.Run("Nested", func(t *testing.T) {
+		SetLimits(state, Limits{Instructions: 10000})
+
+		inner := bind(`function() for i = 1, 3000 do end end`)
+		state.SetGlobal("inner", state.NewFunction(func(state *lua.LState) int {
+			_, err := inner.Call()
+			if err != nil {
+				state.RaiseError("%s", err)
+			}
+			return 0
+		}))
+
+		// Each inner call is below the limit, but they share the limit of the outer call.
+		_, err := bind(`function() for i = 1, 10 do inner() end end`).Call()
+		assert.ErrorIs(t, err, ErrInstructionLimit)
+	})
+}
+
+func TestProfiler(t *testing.T) {
+	state := lua.NewState()
+	defer state.Close()
+
+	profiler := NewProfiler()
+	SetProfiler(state, profiler)
+
+	assert.NoError(t, state.DoString(`
+function inner() local sum = 0 for i = 1, 10000 do sum = sum + i end return sum end
+function outer() return inner() + call_inner() end
+`))
+
+	inner := Profile(state, "test:INNER:Call", BindToLua(state, state.GetGlobal("inner")))
+	outer := Profile(state, "test:OUTER:Call", BindToLua(state, state.GetGlobal("outer")))
+	state.SetGlobal("call_inner", state.NewFunction(func(state *lua.LState) int {
+		res, _ := inner.Call()
+		state.Push(lua.LNumber(res.(float64)))
+		return 1
+	}))
+
+	for i := 0; i < 3; i++ {
+		res, err := outer.Call()
+		assert.NoError(t, err)
+		assert.Equal(t, float64(100010000), res)
+	}
+
+	entries := profiler.Entries()
+	assert.Len(t, entries, 2)
+
+	byKey := map[string]ProfileEntry{}
+	for _, e := range entries {
+		byKey[e.Key] = e
+	}
+	assert.Equal(t, 3, byKey["test:OUTER:Call"].Calls)
+	assert.Equal(t, 3, byKey["test:INNER:Call"].Calls)
+
+	// The time of the nested inner calls is only part of the total time of outer.
+	assert.Equal(t, byKey["test:INNER:Call"].Total, byKey["test:INNER:Call"].Self)
+	assert.Equal(t, byKey["test:OUTER:Call"].Total-byKey["test:INNER:Call"].Total, byKey["test:OUTER:Call"].Self)
+
+	sb := &strings.Builder{}
+	assert.NoError(t, profiler.WriteReport(sb, 1))
+	assert.Equal(t, 2, strings.Count(sb.String(), "\n"))
+
+	profiler.Reset()
+	assert.Empty(t, profiler.Entries())
+
+	// Without a profiler callbacks are not wrapped.
+	assert.Nil(t, Profile(lua.NewState(), "test:NIL:Call", nil))
+}
@@ -0,0 +1,190 @@
+package luhelp
+
+import (
+	"fmt"
+	lua "github.com/yuin/gopher-lua"
+	"io"
+	"runtime/metrics"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ProfileEntry contains the measurements of all calls of a single callback.
+type ProfileEntry struct {
+	Key   string        `json:"key"`
+	Calls int           `json:"calls"`
+	Total time.Duration `json:"total"`
+	Max   time.Duration `json:"max"`
+
+	// Self is the time spent in the callback itself, without the time of callbacks that were called
+	// while it was running, e.g. an on_damage callback that is triggered by an on_cast callback.
+	Self time.Duration `json:"self"`
+
+	// Allocs are the bytes allocated by the callback itself, without nested callbacks.
+	Allocs uint64 `json:"allocs"`
+}
+
+// Profiler measures the time and allocations of callbacks. Callbacks are profiled by wrapping them with
+// Wrap or Profile. It's safe to use the same profiler from multiple goroutines, but nested calls are only
+// tracked correctly for a single lua state.
+type Profiler struct {
+	mtx     sync.Mutex
+	entries map[string]*ProfileEntry
+	stack   []*profileFrame
+}
+
+type profileFrame struct {
+	children       time.Duration
+	childrenAllocs uint64
+}
+
+const profilerRegistryKey = "__eoe_profiler"
+
+func NewProfiler() *Profiler {
+	return &Profiler{
+		entries: map[string]*ProfileEntry{},
+	}
+}
+
+// SetProfiler sets the profiler that is used by Profile.
+func SetProfiler(state *lua.LState, profiler *Profiler) {
+	ud := state.NewUserData()
+	ud.Value = profiler
+	state.SetField(state.Get(lua.RegistryIndex), profilerRegistryKey, ud)
+}
+
+// GetProfiler returns the profiler of the state or nil if profiling is disabled.
+func GetProfiler(state *lua.LState) *Profiler {
+	if ud, ok := state.GetField(state.Get(lua.RegistryIndex), profilerRegistryKey).(*lua.LUserData); ok {
+		profiler, _ := ud.Value.(*Profiler)
+		return profiler
+	}
+	return nil
+}
+
+// Profile wraps the callback with the profiler of the state under the given key. If profiling is disabled
+// the callback is returned unchanged, so that there is no overhead.
+func Profile(state *lua.LState, key string, cb OwnedCallback) OwnedCallback {
+	if profiler := GetProfiler(state); profiler != nil {
+		return profiler.Wrap(key, cb)
+	}
+	return cb
+}
+
+// Wrap returns a callback that measures each call of cb under the given key.
+func (p *Profiler) Wrap(key string, cb OwnedCallback) OwnedCallback {
+	if cb == nil {
+		return nil
+	}
+
+	return func(args ...any) (any, error) {
+		frame := &profileFrame{}
+		p.mtx.Lock()
+		p.stack = append(p.stack, frame)
+		p.mtx.Unlock()
+
+		allocStart := allocBytes()
+		start := time.Now()
+
+		res, err := cb(args...)
+
+		total := time.Since(start)
+		allocs := allocBytes() - allocStart
+
+		p.mtx.Lock()
+		defer p.mtx.Unlock()
+
+		p.stack = p.stack[:len(p.stack)-1]
+		if len(p.stack) > 0 {
+			parent := p.stack[len(p.stack)-1]
+			parent.children += total
+			parent.childrenAllocs += allocs
+		}
+
+		entry, ok := p.entries[key]
+		if !ok {
+			entry = &ProfileEntry{Key: key}
+			p.entries[key] = entry
+		}
+		entry.Calls += 1
+		entry.Total += total
+		entry.Self += total - frame.children
+		if allocs > frame.childrenAllocs {
+			entry.Allocs += allocs - frame.childrenAllocs
+		}
+		if total > entry.Max {
+			entry.Max = total
+		}
+
+		return res, err
+	}
+}
+
+// Entries returns all entries sorted by self time, so the most expensive callbacks come first.
+func (p *Profiler) Entries() []ProfileEntry {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	entries := make([]ProfileEntry, 0, len(p.entries))
+	for _, entry := range p.entries {
+		entries = append(entries, *entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Self == entries[j].Self {
+			return entries[i].Key < entries[j].Key
+		}
+		return entries[i].Self > entries[j].Self
+	})
+	return entries
+}
+
+// Reset removes all measurements.
+func (p *Profiler) Reset() {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	p.entries = map[string]*ProfileEntry{}
+}
+
+// WriteReport writes the top n entries as text table. If n is 0 or less all entries are written.
+func (p *Profiler) WriteReport(w io.Writer, n int) error {
+	entries := p.Entries()
+	if n > 0 && n < len(entries) {
+		entries = entries[:n]
+	}
+
+	if _, err := fmt.Fprintf(w, "%-50s %8s %12s %12s %12s %12s %12s\n", "Callback", "Calls", "Self", "Total", "Avg", "Max", "Allocs"); err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if _, err := fmt.Fprintf(w, "%-50s %8d %12s %12s %12s %12s %12s\n", e.Key, e.Calls, round(e.Self), round(e.Total), round(e.Total/time.Duration(e.Calls)), round(e.Max), formatBytes(e.Allocs)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func round(d time.Duration) time.Duration {
+	return d.Round(time.Microsecond)
+}
+
+func formatBytes(b uint64) string {
+	switch {
+	case b >= 1<<20:
+		return fmt.Sprintf("%.1fMB", float64(b)/(1<<20))
+	case b >= 1<<10:
+		return fmt.Sprintf("%.1fKB", float64(b)/(1<<10))
+	}
+	return fmt.Sprintf("%dB", b)
+}
+
+// allocBytes returns the bytes allocated on the heap since the program started.
+func allocBytes() uint64 {
+	sample := []metrics.Sample{{Name: "/gc/heap/allocs:bytes"}}
+	metrics.Read(sample)
+	if sample[0].Value.Kind() != metrics.KindUint64 {
+		return 0
+	}
+	return sample[0].Value.Uint64()
+}
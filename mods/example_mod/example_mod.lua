@@ -9,6 +9,9 @@ register_enemy(
             color = "#cccccc",
             initial_hp = 100,
             max_hp = 100,
+            intend = function(ctx)
+                return "Nothing"
+            end,
             callbacks = {
                 on_turn = function(ctx)
                     return nil
@@ -5,11 +5,12 @@
 package audio
 
 import (
+	"bytes"
 	"github.com/BigJk/end_of_eden/internal/fs"
 	"github.com/BigJk/end_of_eden/system/settings"
 
+	"io"
 	"log"
-	"os"
 	"path/filepath"
 	"runtime"
 	"strings"
@@ -29,6 +30,7 @@ const baseVolume = -1
 
 var mtx = sync.Mutex{}
 var sounds = map[string]*beep.Buffer{}
+var modSounds = map[string]*beep.Buffer{}
 var enabled = false
 var allLoaded = false
 var queuedSong = ""
@@ -40,67 +42,7 @@ var music = &beep.Ctrl{
 // InitAudio initializes the audio system. Loads all audio files from the assets/audio folder.
 func InitAudio() {
 	go func() {
-		wg := &sync.WaitGroup{}
-
-		_ = fs.Walk("./assets/audio", func(path string, isDir bool) error {
-			wg.Add(1)
-			go func() {
-				defer wg.Done()
-
-				var streamer beep.StreamSeekCloser
-				var format beep.Format
-
-				if !isDir {
-					if strings.HasSuffix(path, ".mp3") {
-						f, err := os.Open(path)
-						if err != nil {
-							log.Println("Audio error:", err)
-							return
-						}
-
-						streamer, format, err = mp3.Decode(f)
-						if err != nil {
-							log.Println("Audio error:", err)
-							return
-						}
-					} else if strings.HasSuffix(path, ".wav") {
-						f, err := os.Open(path)
-						if err != nil {
-							log.Println("Audio error:", err)
-							return
-						}
-
-						streamer, format, err = wav.Decode(f)
-						if err != nil {
-							log.Println("Audio error:", err)
-							return
-						}
-					}
-				}
-
-				if streamer != nil {
-					buf := beep.NewBuffer(beep.Format{
-						SampleRate:  sampleRate,
-						NumChannels: 2,
-						Precision:   2,
-					})
-
-					if format.SampleRate == sampleRate {
-						buf.Append(streamer)
-					} else {
-						buf.Append(beep.Resample(3, format.SampleRate, sampleRate, streamer))
-					}
-
-					mtx.Lock()
-					sounds[strings.Split(filepath.Base(path), ".")[0]] = buf
-					mtx.Unlock()
-				}
-			}()
-
-			return nil
-		})
-
-		wg.Wait()
+		loadFolder("./assets/audio", sounds)
 
 		mtx.Lock()
 		allLoaded = true
@@ -122,6 +64,99 @@ func InitAudio() {
 	enabled = true
 }
 
+// LoadMods loads the audio files from the audio folders of the given mods in the background. Mod sounds
+// replace the sounds of the base game with the same name. The sounds of previously loaded mods are dropped.
+func LoadMods(mods ...string) {
+	if !enabled {
+		return
+	}
+
+	go func() {
+		loaded := map[string]*beep.Buffer{}
+		for i := range mods {
+			loadFolder(filepath.Join("./mods", mods[i], "audio"), loaded)
+		}
+
+		mtx.Lock()
+		modSounds = loaded
+		mtx.Unlock()
+	}()
+}
+
+// loadFolder decodes all audio files in the folder into the target and returns once all are loaded.
+func loadFolder(folder string, target map[string]*beep.Buffer) {
+	wg := &sync.WaitGroup{}
+
+	_ = fs.Walk(folder, func(path string, isDir bool) error {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			var streamer beep.StreamSeekCloser
+			var format beep.Format
+
+			if !isDir {
+				if strings.HasSuffix(path, ".mp3") {
+					data, err := fs.ReadFile(path)
+					if err != nil {
+						log.Println("Audio error:", err)
+						return
+					}
+
+					streamer, format, err = mp3.Decode(io.NopCloser(bytes.NewReader(data)))
+					if err != nil {
+						log.Println("Audio error:", err)
+						return
+					}
+				} else if strings.HasSuffix(path, ".wav") {
+					data, err := fs.ReadFile(path)
+					if err != nil {
+						log.Println("Audio error:", err)
+						return
+					}
+
+					streamer, format, err = wav.Decode(bytes.NewReader(data))
+					if err != nil {
+						log.Println("Audio error:", err)
+						return
+					}
+				}
+			}
+
+			if streamer != nil {
+				buf := beep.NewBuffer(beep.Format{
+					SampleRate:  sampleRate,
+					NumChannels: 2,
+					Precision:   2,
+				})
+
+				if format.SampleRate == sampleRate {
+					buf.Append(streamer)
+				} else {
+					buf.Append(beep.Resample(3, format.SampleRate, sampleRate, streamer))
+				}
+
+				mtx.Lock()
+				target[strings.Split(filepath.Base(path), ".")[0]] = buf
+				mtx.Unlock()
+			}
+		}()
+
+		return nil
+	})
+
+	wg.Wait()
+}
+
+// sound returns the loaded sound with the given key, preferring the sounds of mods.
+func sound(key string) (*beep.Buffer, bool) {
+	if val, ok := modSounds[key]; ok {
+		return val, true
+	}
+	val, ok := sounds[key]
+	return val, ok
+}
+
 // Play plays a sound effect. If the sound effect is not loaded, nothing will happen.
 func Play(key string, volumeModifier ...float64) {
 	if !enabled {
@@ -132,7 +167,7 @@ func Play(key string, volumeModifier ...float64) {
 		return
 	}
 
-	if val, ok := sounds[key]; ok {
+	if val, ok := sound(key); ok {
 		volume := &effects.Volume{
 			Streamer: val.Streamer(0, val.Len()),
 			Base:     2,
@@ -178,7 +213,7 @@ func PlayMusic(key string) {
 		mtx.Unlock()
 	}
 
-	if val, ok := sounds[key]; ok {
+	if val, ok := sound(key); ok {
 		volume := &effects.Volume{
 			Streamer: beep.Loop(-1, val.Streamer(0, val.Len())),
 			Base:     2,
@@ -15,6 +15,9 @@ import (
 // InitAudio initializes the audio system. Loads all audio files from the assets/audio folder.
 func InitAudio() {}
 
+// LoadMods does nothing, as the browser plays the audio files directly from the assets folder.
+func LoadMods(mods ...string) {}
+
 // Play plays a sound effect. If the sound effect is not loaded, nothing will happen.
 func Play(key string, volumeModifier ...float64) {
 	fs.Walk("./assets/audio", func(path string, isDir bool) error {
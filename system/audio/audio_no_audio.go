@@ -5,6 +5,8 @@ package audio
 
 func InitAudio() {}
 
+func LoadMods(mods ...string) {}
+
 func Play(key string, volumeModifier ...float64) {}
 
 func PlayMusic(key string) {}
@@ -6,6 +6,7 @@ import (
 	"github.com/samber/lo"
 	"math/rand"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 )
@@ -17,6 +18,15 @@ type FaceGenerator struct {
 
 // Gen generates a face with the given id.
 func (gen *FaceGenerator) Gen(id int) string {
+	return gen.gen(rand.Intn, id)
+}
+
+// GenWith generates a face with the given id using the given random source.
+func (gen *FaceGenerator) GenWith(rnd *rand.Rand, id int) string {
+	return gen.gen(rnd.Intn, id)
+}
+
+func (gen *FaceGenerator) gen(intn func(n int) int, id int) string {
 	var face []string
 
 	t := gen.data[id]
@@ -24,7 +34,7 @@ func (gen *FaceGenerator) Gen(id int) string {
 		if len(t[i]) == 0 {
 			continue
 		}
-		face = append(face, t[i][rand.Intn(len(t[i]))])
+		face = append(face, t[i][intn(len(t[i]))])
 	}
 
 	minSpace := lo.Min(lo.Map(face, func(line string, _ int) int {
@@ -51,10 +61,22 @@ func (gen *FaceGenerator) Gen(id int) string {
 
 // GenRand generates a random face.
 func (gen *FaceGenerator) GenRand() string {
+	return gen.genRand(rand.Intn)
+}
+
+// GenRandWith generates a random face using the given random source.
+func (gen *FaceGenerator) GenRandWith(rnd *rand.Rand) string {
+	return gen.genRand(rnd.Intn)
+}
+
+func (gen *FaceGenerator) genRand(intn func(n int) int) string {
 	if gen == nil || gen.data == nil || len(gen.data) == 0 {
 		return ""
 	}
-	return gen.Gen(lo.Shuffle(lo.Keys(gen.data))[0])
+
+	ids := lo.Keys(gen.data)
+	sort.Ints(ids)
+	return gen.gen(intn, ids[intn(len(ids))])
 }
 
 // New creates a new FaceGenerator.
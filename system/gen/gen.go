@@ -36,9 +36,18 @@ func Get(t string) []string {
 
 // GetRandom returns a random entry for the given type.
 func GetRandom(t string) string {
+	return getRandom(rand.Intn, t)
+}
+
+// GetRandomWith returns a random entry for the given type using the given random source.
+func GetRandomWith(rnd *rand.Rand, t string) string {
+	return getRandom(rnd.Intn, t)
+}
+
+func getRandom(intn func(n int) int, t string) string {
 	selected := data[t]
 	if len(selected) == 0 {
 		return ""
 	}
-	return selected[rand.Intn(len(selected))]
+	return selected[intn(len(selected))]
 }
@@ -6,11 +6,15 @@ import (
 	"gopkg.in/yaml.v3"
 	"path/filepath"
 	"strings"
+	"sync"
 )
 
 type Localization struct {
 	current string
 	locals  map[string]map[string]string
+
+	trackMtx sync.Mutex
+	tracked  map[string]bool
 }
 
 func New() *Localization {
@@ -115,6 +119,12 @@ func (l *Localization) GetLocales() []string {
 // it will fall back to the "en" locale and if the key is not found there, it will return the key
 // or the given defaults.
 func (l *Localization) Get(locale, key string, defaults ...string) string {
+	if l.tracked != nil {
+		l.trackMtx.Lock()
+		l.tracked[key] = l.tracked[key] || len(defaults) > 0
+		l.trackMtx.Unlock()
+	}
+
 	if _, ok := l.locals[locale]; !ok {
 		if locale != "en" {
 			return l.Get("en", key, defaults...)
@@ -142,3 +152,33 @@ func (l *Localization) Get(locale, key string, defaults ...string) string {
 func (l *Localization) G(key string, defaults ...string) string {
 	return l.Get(l.current, key, defaults...)
 }
+
+// Has checks if the given locale contains a translation for the key.
+func (l *Localization) Has(locale, key string) bool {
+	_, ok := l.locals[locale][key]
+	return ok
+}
+
+// Track starts recording all keys that are requested from now on. This is used by tools to find
+// keys that are missing in a locale. Tracking has to be enabled before the localization is used.
+func (l *Localization) Track() {
+	l.trackMtx.Lock()
+	defer l.trackMtx.Unlock()
+
+	if l.tracked == nil {
+		l.tracked = map[string]bool{}
+	}
+}
+
+// Tracked returns all keys that were requested since Track was called. The value reports if the key
+// was requested with a default value at least once.
+func (l *Localization) Tracked() map[string]bool {
+	l.trackMtx.Lock()
+	defer l.trackMtx.Unlock()
+
+	tracked := make(map[string]bool, len(l.tracked))
+	for k, v := range l.tracked {
+		tracked[k] = v
+	}
+	return tracked
+}
@@ -22,7 +22,11 @@ func Actor(session *game.Session, actor game.Actor, enemy *game.Enemy, showStatu
 	parts = append(parts, face, enemy.Name)
 
 	if showHp {
-		parts = append(parts, fmt.Sprintf("%d / %d", actor.HP, enemy.MaxHP))
+		parts = append(parts, fmt.Sprintf("%d / %d", actor.HP, actor.MaxHP))
+	}
+
+	if actor.Block > 0 {
+		parts = append(parts, style.BlueText.Copy().Bold(true).Render(fmt.Sprintf("%d Block", actor.Block)))
 	}
 
 	parts = append(parts, lo.Filter(additional, func(item string, index int) bool {
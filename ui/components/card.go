@@ -20,8 +20,11 @@ var (
 func HalfCard(session *game.Session, guid string, active bool, baseHeight int, maxHeight int, minimal bool, width int, checkCasting bool) string {
 	fight := session.GetFight()
 	card, _ := session.GetCard(guid)
-	canCast := !checkCasting || fight.CurrentPoints >= card.PointCost
+	canCast := !checkCasting || fight.CurrentPoints >= card.PointCost && !card.Unplayable
 	cardState := session.GetCardState(guid)
+	if keywords := card.Keywords(); len(keywords) > 0 {
+		cardState = style.GrayText.Copy().Italic(true).Render(strings.Join(keywords, " · ")) + "\n\n" + cardState
+	}
 
 	pointText := strings.Repeat("•", card.PointCost)
 	tagsText := strings.Join(card.Tags, ", ")
@@ -21,6 +21,12 @@ const text = ` ▄▄ •  ▄▄▄· • ▌ ▄ ·. ▄▄▄ .           ▌
 ▐█▄▪▐█▐█ ▪▐▌██ ██▌▐█▌▐█▄▄▌    ▐█▌.▐▌ ███ ▐█▄▄▌▐█•█▌         
 ·▀▀▀▀  ▀  ▀ ▀▀  █▪▀▀▀ ▀▀▀      ▀█▄▀▪. ▀   ▀▀▀ .▀  ▀ ▀  ▀  ▀ `
 
+const victoryText = ` ▌ ▐·▪   ▄▄· ▄▄▄▄▄      ▄▄▄   ▄· ▄▌
+▪█·█▌██ ▐█ ▌▪•██  ▪     ▀▄ █·▐█▪██▌
+▐█▐█•▐█·██ ▄▄ ▐█.▪ ▄█▀▄ ▐▀▀▄ ▐█▌▐█▪
+ ███ ▐█▌▐███▌ ▐█▌·▐█▌.▐▌▐█•█▌ ▐█▀·.
+. ▀  ▀▀▀·▀▀▀  ▀▀▀  ▀█▄▀▪.▀  ▀  ▀ • `
+
 const (
 	ZoneToMenu = "to_menu"
 )
@@ -36,6 +42,7 @@ type Model struct {
 	started   bool
 	progress  float64
 	lastMouse tea.MouseMsg
+	victory   bool
 
 	allDamage         int
 	allDamageReceived int
@@ -70,6 +77,18 @@ func New(zones *zone.Manager, session *game.Session, start game.StateCheckpointM
 	return m
 }
 
+// NewVictory creates the screen for a won run.
+func NewVictory(zones *zone.Manager, session *game.Session, start game.StateCheckpointMarker) Model {
+	m := New(zones, session, start)
+	m.victory = true
+	return m
+}
+
+// GetSession returns the session of the game, so the root model can lock it.
+func (m Model) GetSession() *game.Session {
+	return m.session
+}
+
 func (m Model) Init() tea.Cmd {
 	return nil
 }
@@ -91,7 +110,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 	case GameOverFrame:
 		if m.progress == 0 {
-			audio.Play("game_over")
+			audio.Play(lo.Ternary(m.victory, "new_artifacts", "game_over"))
 		}
 
 		elapsed := 1.0 / 30.0 / 1.5
@@ -119,23 +138,32 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 func (m Model) View() string {
 	top := m.top()
 
+	title := style.RedText.Render(animation.JitterText(text, m.progress, 0, 10))
+	button := "Accept your fate..."
+	if m.victory {
+		title = style.GreenText.Render(animation.JitterText(victoryText, m.progress, 0, 10))
+		button = "Return victorious..."
+	}
+
 	return lipgloss.JoinVertical(
 		lipgloss.Center,
 		top,
 		lipgloss.Place(m.Size.Width, m.Size.Height-lipgloss.Height(top), lipgloss.Center, lipgloss.Center,
 			lipgloss.JoinVertical(lipgloss.Center,
-				style.RedText.Render(animation.JitterText(text, m.progress, 0, 10)),
+				title,
 				lipgloss.NewStyle().Margin(2, 0, 1, 0).Padding(1, 3).Border(lipgloss.ThickBorder()).BorderForeground(style.BaseRedDarker).Foreground(style.BaseWhite).Render(
 					fmt.Sprintf(
-						"%s\n\n%s%d\n%s%d\n%s%d\n%s%d",
+						"%s\n\n%s%d\n%s%d\n%s%d\n%s%d\n%s%d\n%s%d",
 						style.BoldStyle.Render("Run Statistic"),
 						style.BoldStyle.Render(fmt.Sprintf("%-20s :  ", "Stages ")), m.session.GetStagesCleared(),
+						style.BoldStyle.Render(fmt.Sprintf("%-20s :  ", "Difficulty ")), m.session.GetDifficulty(),
+						style.BoldStyle.Render(fmt.Sprintf("%-20s :  ", "Unlocked Difficulty ")), m.session.GetProfile().UnlockedDifficulty,
 						style.BoldStyle.Render(fmt.Sprintf("%-20s :  ", "Damage Done ")), m.allDamage,
 						style.BoldStyle.Render(fmt.Sprintf("%-20s :  ", "Damage Received ")), m.allDamageReceived,
 						style.BoldStyle.Render(fmt.Sprintf("%-20s :  ", "Gold Collected ")), m.allGold,
 					),
 				),
-				m.zones.Mark(ZoneToMenu, style.HeaderStyle.Copy().Background(lo.Ternary(m.zones.Get(ZoneToMenu).InBounds(m.lastMouse), style.BaseRed, style.BaseRedDarker)).Render("Accept your fate...")),
+				m.zones.Mark(ZoneToMenu, style.HeaderStyle.Copy().Background(lo.Ternary(m.zones.Get(ZoneToMenu).InBounds(m.lastMouse), style.BaseRed, style.BaseRedDarker)).Render(button)),
 			),
 		),
 	)
@@ -160,6 +188,6 @@ func (m Model) top() string {
 		lipgloss.NewStyle().Bold(true).Foreground(style.BaseRed).Padding(0, 4, 0, 0).Render(fmt.Sprintf("HP: %d / %d", player.HP, player.MaxHP)),
 		lipgloss.NewStyle().Bold(true).Foreground(style.BaseWhite).Padding(0, 4, 0, 0).Render(fmt.Sprintf("%d. Stage", m.session.GetStagesCleared()+1)),
 		lipgloss.NewStyle().Bold(true).Foreground(style.BaseWhite).Padding(0, 4, 0, 0).Render(fmt.Sprintf("%d. Round", fight.Round+1)),
-		lipgloss.NewStyle().Italic(true).Foreground(style.BaseGray).Padding(0, 4, 0, 0).Render("\"Better luck next time...\""),
+		lipgloss.NewStyle().Italic(true).Foreground(style.BaseGray).Padding(0, 4, 0, 0).Render(lo.Ternary(m.victory, "\"Eden awaits...\"", "\"Better luck next time...\"")),
 	))
 }
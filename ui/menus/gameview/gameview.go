@@ -39,6 +39,7 @@ type Model struct {
 	inPlayerView        bool
 	animations          []tea.Model
 	ctrlDown            bool
+	casual              bool
 
 	lastGameState game.GameState
 	lastEvent     string
@@ -66,11 +67,25 @@ func New(parent tea.Model, zones *zone.Manager, session *game.Session) Model {
 	}
 }
 
+// WithCasualMode enables the casual mode, which allows the player to undo casts with 'u'.
+func (m Model) WithCasualMode(enabled bool) Model {
+	m.casual = enabled
+	return m
+}
+
 func (m Model) Init() tea.Cmd {
 	return nil
 }
 
+// GetSession returns the session of the game, so the root model can lock it.
+func (m Model) GetSession() *game.Session {
+	return m.Session
+}
+
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	// Re-run changed scripts on the ui goroutine, so lua is never called concurrently.
+	m.Session.ApplyHotReload()
+
 	if cmd := root.CheckLuaErrors(m.zones, m.Session); cmd != nil {
 		return m, cmd
 	}
@@ -136,6 +151,11 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.ctrlDown = false
 			}
 
+			// Undo last cast
+			if msg.String() == "u" && m.casual && m.Session.GetGameState() == game.GameStateFight {
+				m = m.undo()
+			}
+
 			// Show tooltip
 			if msg.String() == "x" {
 				for i := 0; i < m.Session.GetOpponentCount(game.PlayerActorID); i++ {
@@ -252,6 +272,8 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		cmds = append(cmds, cmd)
 	case game.GameStateGameOver:
 		return gameover.New(m.zones, m.Session, m.Start), nil
+	case game.GameStateVictory:
+		return gameover.NewVictory(m.zones, m.Session, m.Start), nil
 	}
 
 	if m.Session.GetGameState() != m.lastGameState || m.Session.GetEventID() != m.lastEvent {
@@ -445,6 +467,25 @@ func (m Model) tryCast() Model {
 	return m
 }
 
+func (m Model) undo() Model {
+	if err := m.Session.Undo(); err != nil {
+		audio.Play("btn_deny")
+		return m
+	}
+
+	audio.Play("btn_menu")
+
+	// The undone checkpoints are gone, so the marker needs to be re-set. The state before the cast
+	// was already visible, so there is nothing new to show.
+	m.BeforeStateSwitch = m.Session.MarkState()
+	m.lastGameState = m.Session.GetGameState()
+	m.lastEvent = m.Session.GetEventID()
+	m.inOpponentSelection = false
+	m.selectedCard = 0
+
+	return m
+}
+
 //
 // Fight View
 //
@@ -453,12 +494,15 @@ func (m Model) fightStatusTop() string {
 	fight := m.Session.GetFight()
 	player := m.Session.GetPlayer()
 
-	return components.Header(m.Size.Width, []components.HeaderValue{
+	return components.Header(m.Size.Width, lo.Filter([]components.HeaderValue{
 		components.NewHeaderValue(fmt.Sprintf("Gold: %d", player.Gold), lipgloss.Color("#FFFF00")),
 		components.NewHeaderValue(fmt.Sprintf("HP: %d / %d", player.HP, player.MaxHP), style.BaseRed),
+		components.NewHeaderValue(lo.Ternary(player.Block > 0, fmt.Sprintf("Block: %d", player.Block), ""), style.BaseBlue),
 		components.NewHeaderValue(fmt.Sprintf("%d. Stage", m.Session.GetStagesCleared()+1), style.BaseWhite),
 		components.NewHeaderValue(fmt.Sprintf("%d. Round", fight.Round+1), style.BaseWhite),
-	}, fight.Description)
+	}, func(item components.HeaderValue, index int) bool {
+		return len(item.Text) > 0
+	}), fight.Description)
 }
 
 func (m Model) fightDivider() string {
@@ -488,6 +532,7 @@ func (m Model) fightStatusBottom() string {
 			lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#FFFF00")).Padding(0, 4, 0, 0).Render(fmt.Sprintf("Used: %d", len(fight.Used))),
 			lipgloss.NewStyle().Bold(true).Foreground(style.BaseRed).Padding(0, 4, 0, 0).Render(fmt.Sprintf("Exhausted: %d", len(fight.Exhausted))),
 			lipgloss.NewStyle().Bold(true).Foreground(style.BaseGreen).Padding(0, 4, 0, 0).Render(fmt.Sprintf("Action Points: (%d) %s", fight.CurrentPoints, strings.Repeat("• ", fight.CurrentPoints))),
+			lo.Ternary(m.casual && m.Session.CanUndo(), style.GrayText.Copy().Padding(0, 4, 0, 0).Render("[u] Undo"), ""),
 			m.zones.Mark(ZonePlayerInspect, components.StatusEffects(m.Session, m.Session.GetPlayer())),
 		),
 		),
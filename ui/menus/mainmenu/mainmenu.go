@@ -13,6 +13,7 @@ import (
 	"github.com/BigJk/end_of_eden/ui/menus/gameview"
 	"github.com/BigJk/end_of_eden/ui/menus/intro"
 	"github.com/BigJk/end_of_eden/ui/menus/mods"
+	"github.com/BigJk/end_of_eden/ui/menus/saves"
 	uiset "github.com/BigJk/end_of_eden/ui/menus/settings"
 	"github.com/BigJk/end_of_eden/ui/root"
 	"github.com/BigJk/end_of_eden/ui/style"
@@ -26,6 +27,9 @@ import (
 	"time"
 )
 
+// profileFile contains the progress that is kept across runs, like the unlocked difficulty.
+const profileFile = "./saves/profile.json"
+
 type Model struct {
 	ui.MenuBase
 
@@ -33,9 +37,11 @@ type Model struct {
 	settings settings.Settings
 	zones    *zone.Manager
 	choices  ChoicesModel
+	slots    *game.SaveSlots
 
 	settingValues []uiset.Value
 	settingSaver  uiset.Saver
+	debugConfig   *game.DebugConfig
 	didLoad       bool
 }
 
@@ -49,13 +55,37 @@ func NewModel(zones *zone.Manager, settings settings.Settings, values []uiset.Va
 		zones:         zones,
 		settings:      settings,
 		choices:       NewChoicesModel(zones, len(values) == 0 || saver == nil),
+		slots:         game.NewSaveSlots("./saves"),
 		settingSaver:  saver,
 		settingValues: values,
 	}
 
+	// Older versions saved the run to a single file, which is moved into a save slot once.
+	_ = os.Mkdir("./saves", 0777)
+	if slot, ok, err := model.slots.ImportLegacy("./session.save", "./session.replay"); err != nil {
+		log.Println("Error importing legacy save:", err)
+	} else if ok {
+		log.Println("Imported legacy save into slot:", slot.ID)
+	}
+
 	return model
 }
 
+// WithDebugConfig starts a debug server with the given config for each session that is started from the menu.
+// Without it the debug server is only started if EOE_DEBUG=1 is set.
+func (m Model) WithDebugConfig(config game.DebugConfig) Model {
+	m.debugConfig = &config
+	return m
+}
+
+// debugOption returns the session option that starts the debug server, or nil if debugging is disabled.
+func (m Model) debugOption() func(s *game.Session) {
+	if m.debugConfig != nil {
+		return game.WithDebugConfig(*m.debugConfig)
+	}
+	return lo.Ternary(os.Getenv("EOE_DEBUG") == "1", game.WithDebugConfig(game.DebugConfigFromEnv(8272)), nil)
+}
+
 func (m Model) Init() tea.Cmd {
 	return nil
 }
@@ -92,37 +122,20 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case ChoiceContinue:
 		audio.Play("btn_menu")
 
-		if saved, err := fs.ReadFile("./session.save"); err == nil {
-			f, err := fs.OpenFile("./logs/S "+strings.ReplaceAll(time.Now().Format(time.DateTime), ":", "-")+".txt", os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
-			if err != nil {
-				panic(err)
-			}
-
-			session := game.NewSession(
-				game.WithLogging(log.New(f, "SESSION ", log.Ldate|log.Ltime|log.Lshortfile)),
-				lo.Ternary(os.Getenv("EOE_DEBUG") == "1", game.WithDebugEnabled(8272), nil),
-			)
-			image2.ResetSearchPaths()
-			image2.AddSearchPaths(lo.Map(session.GetLoadedMods(), func(item string, index int) string {
-				return fmt.Sprintf("./mods/%s/images/", item)
-			})...)
-
-			err = session.GobDecode(saved)
-			if err != nil {
-				log.Println("Error loading save:", err)
-			} else {
-				m.choices = m.choices.Clear()
-				return gameview.New(m, m.zones, session), cmd
-			}
-		}
-
+		m.choices = m.choices.Clear()
+		return saves.New(m, m.zones, m.slots, m.loadSlot), cmd
 	case ChoiceNewGame:
 		audio.Play("btn_menu")
 
-		_ = os.Mkdir("./logs", 0777)
-		f, err := fs.OpenFile("./logs/S "+strings.ReplaceAll(time.Now().Format(time.DateTime), ":", "-")+".txt", os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+		_ = os.Mkdir("./saves", 0777)
+		existing, err := m.slots.List()
 		if err != nil {
-			panic(err)
+			log.Println("Error listing save slots:", err)
+		}
+		slot, err := m.slots.Create(fmt.Sprintf("Run %d", len(existing)+1))
+		if err != nil {
+			log.Println("Error creating save slot:", err)
+			return m, cmd
 		}
 
 		image2.ResetSearchPaths()
@@ -130,12 +143,29 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return fmt.Sprintf("./mods/%s/images/", item)
 		})...)
 
-		m.choices = m.choices.Clear()
-		return m, root.Push(gameview.New(m, m.zones, game.NewSession(
-			game.WithLogging(log.New(f, "SESSION ", log.Ldate|log.Ltime|log.Lshortfile)),
+		profile, err := game.LoadProfile(profileFile)
+		if err != nil {
+			log.Println("Error loading profile:", err)
+		}
+
+		session := game.NewSession(
+			game.WithLogging(m.sessionLogger()),
 			game.WithMods(m.settings.GetStrings("mods")),
-			lo.Ternary(os.Getenv("EOE_DEBUG") == "1", game.WithDebugEnabled(8272), nil),
-		)))
+			game.WithProfile(profileFile),
+			game.WithDifficulty(lo.Clamp(m.settings.GetInt("difficulty"), 0, profile.UnlockedDifficulty)),
+			game.WithSaveSlot(m.slots, slot.ID),
+			game.WithReplayFile(m.slots.ReplayFile(slot.ID)),
+			m.debugOption(),
+			lo.Ternary(os.Getenv("EOE_HOT_RELOAD") == "1", game.WithHotReload(), nil),
+			lo.Ternary(os.Getenv("EOE_PROFILE") == "1", game.WithProfiling(), nil),
+		)
+		if err := m.slots.Save(slot.ID, session); err != nil {
+			log.Println("Error saving:", err)
+		}
+		audio.LoadMods(session.GetLoadedMods()...)
+
+		m.choices = m.choices.Clear()
+		return m, root.Push(gameview.New(m, m.zones, session).WithCasualMode(m.settings.GetBool("casual")))
 	case ChoiceAbout:
 		audio.Play("btn_menu")
 
@@ -162,6 +192,43 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, cmd
 }
 
+// loadSlot creates a new session from the save slot and returns the game view for it.
+func (m Model) loadSlot(slot game.SaveSlot) (tea.Model, error) {
+	session := game.NewSession(
+		game.WithLogging(m.sessionLogger()),
+		game.WithSaveSlot(m.slots, slot.ID),
+		game.WithReplayFile(m.slots.ReplayFile(slot.ID)),
+		game.WithProfile(profileFile),
+		m.debugOption(),
+		lo.Ternary(os.Getenv("EOE_HOT_RELOAD") == "1", game.WithHotReload(), nil),
+		lo.Ternary(os.Getenv("EOE_PROFILE") == "1", game.WithProfiling(), nil),
+	)
+
+	if err := m.slots.Load(slot.ID, session); err != nil {
+		log.Println("Error loading save:", err)
+		session.Close()
+		return nil, err
+	}
+
+	image2.ResetSearchPaths()
+	image2.AddSearchPaths(lo.Map(session.GetLoadedMods(), func(item string, index int) string {
+		return fmt.Sprintf("./mods/%s/images/", item)
+	})...)
+	audio.LoadMods(session.GetLoadedMods()...)
+
+	return gameview.New(m, m.zones, session).WithCasualMode(m.settings.GetBool("casual")), nil
+}
+
+// sessionLogger creates a new logger that writes to a new file in the logs folder.
+func (m Model) sessionLogger() *log.Logger {
+	_ = os.Mkdir("./logs", 0777)
+	f, err := fs.OpenFile("./logs/S "+strings.ReplaceAll(time.Now().Format(time.DateTime), ":", "-")+".txt", os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		panic(err)
+	}
+	return log.New(f, "SESSION ", log.Ldate|log.Ltime|log.Lshortfile)
+}
+
 func (m Model) View() string {
 	titleImage := lipgloss.NewStyle().
 		Border(lipgloss.InnerHalfBlockBorder(), false, false, true, false).
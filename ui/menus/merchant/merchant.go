@@ -38,14 +38,20 @@ type Model struct {
 	table   table.Model
 	zones   *zone.Manager
 	session *game.Session
+
+	// The costs are calculated on update, as the OnRuleCalc callbacks are too expensive to call on each render.
+	upgradeCost int
+	removeCost  int
 }
 
 func New(zones *zone.Manager, session *game.Session) Model {
 	return Model{
-		state:   StateMain,
-		zones:   zones,
-		session: session,
-		table:   table.New(table.WithStyles(style.TableStyle)),
+		state:       StateMain,
+		zones:       zones,
+		session:     session,
+		table:       table.New(table.WithStyles(style.TableStyle)),
+		upgradeCost: session.GetRule(game.RuleUpgradeCost),
+		removeCost:  session.GetRule(game.RuleRemoveCost),
 	}
 }
 
@@ -114,6 +120,9 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 	}
 
+	m.upgradeCost = m.session.GetRule(game.RuleUpgradeCost)
+	m.removeCost = m.session.GetRule(game.RuleRemoveCost)
+
 	switch m.state {
 	case StateMain:
 		m.table.SetColumns([]table.Column{
@@ -126,11 +135,11 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.table.SetRows(lo.Flatten([][]table.Row{
 			lo.Map(merchant.Artifacts, func(guid string, index int) table.Row {
 				artifact, _ := m.session.GetArtifact(guid)
-				return table.Row{"Artifact", artifact.Name, fmt.Sprintf("%d$", artifact.Price)}
+				return table.Row{"Artifact", artifact.Name, fmt.Sprintf("%d$", m.session.MerchantPrice(artifact.Price))}
 			}),
 			lo.Map(merchant.Cards, func(guid string, index int) table.Row {
 				card, _ := m.session.GetCard(guid)
-				return table.Row{"Card", card.Name, fmt.Sprintf("%d$", card.Price)}
+				return table.Row{"Card", card.Name, fmt.Sprintf("%d$", m.session.MerchantPrice(card.Price))}
 			}),
 		}))
 	case StateUpgrade:
@@ -156,13 +165,15 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 }
 
 func (m Model) View() string {
+	upgradeCost, removeCost := m.upgradeCost, m.removeCost
+
 	// Face
 	var faceSection string
 	switch m.state {
 	case StateMain:
 		buttons := []string{
 			style.HeaderStyle.Copy().Background(lo.Ternary(m.zones.Get(ZoneUpgrade).InBounds(m.LastMouse), style.BaseRed, style.BaseRedDarker)).Margin(0, 2, 1, 2).
-				Render(m.zones.Mark(ZoneUpgrade, fmt.Sprintf("↑  Upgrade Card (%d$)", game.DefaultUpgradeCost))),
+				Render(m.zones.Mark(ZoneUpgrade, fmt.Sprintf("↑  Upgrade Card (%d$)", upgradeCost))),
 			"",
 			style.HeaderStyle.Copy().Background(lo.Ternary(m.zones.Get(ZoneLeave).InBounds(m.LastMouse), style.BaseRed, style.BaseRedDarker)).Margin(0, 2).
 				Render(m.zones.Mark(ZoneLeave, "Leave Merchant")),
@@ -170,10 +181,10 @@ func (m Model) View() string {
 
 		if len(m.session.GetCards(game.PlayerActorID)) > 3 {
 			buttons[1] = style.HeaderStyle.Copy().Background(lo.Ternary(m.zones.Get(ZoneRemove).InBounds(m.LastMouse), style.BaseRed, style.BaseRedDarker)).Margin(0, 2, 1, 2).
-				Render(m.zones.Mark(ZoneRemove, fmt.Sprintf("✕  Remove Card (%d$)", game.DefaultRemoveCost)))
+				Render(m.zones.Mark(ZoneRemove, fmt.Sprintf("✕  Remove Card (%d$)", removeCost)))
 		} else {
 			buttons[1] = style.HeaderStyle.Copy().Background(style.BaseGrayDarker).Margin(0, 2, 1, 2).
-				Render(fmt.Sprintf("✕  Remove Card (%d$)", game.DefaultRemoveCost))
+				Render(fmt.Sprintf("✕  Remove Card (%d$)", removeCost))
 		}
 
 		faceSection = m.merchantLeft(buttons, "")
@@ -215,10 +226,10 @@ func (m Model) View() string {
 		switch item := selectedItem.(type) {
 		case *game.Artifact:
 			selectedItemLook = components.ArtifactCard(m.session, item.ID, 20, 20)
-			canBuy = m.session.GetPlayer().Gold >= item.Price
+			canBuy = m.session.GetPlayer().Gold >= m.session.MerchantPrice(item.Price)
 		case *game.Card:
 			selectedItemLook = components.HalfCard(m.session, item.ID, false, 20, 20, false, 0, false)
-			canBuy = m.session.GetPlayer().Gold >= item.Price
+			canBuy = m.session.GetPlayer().Gold >= m.session.MerchantPrice(item.Price)
 		}
 
 		rightLook = lipgloss.JoinVertical(lipgloss.Top,
@@ -248,10 +259,10 @@ func (m Model) View() string {
 			selectedItemLook,
 			style.HeaderStyle.Copy().Background(
 				lo.Ternary(
-					lo.Ternary(m.state == StateUpgrade, m.session.GetPlayer().Gold >= game.DefaultUpgradeCost, m.session.GetPlayer().Gold >= game.DefaultRemoveCost),
+					lo.Ternary(m.state == StateUpgrade, m.session.GetPlayer().Gold >= upgradeCost, m.session.GetPlayer().Gold >= removeCost),
 					lo.Ternary(m.zones.Get(ZoneBuyItem).InBounds(m.LastMouse), style.BaseRed, style.BaseRedDarker), style.BaseGrayDarker,
 				),
-			).Margin(1, 2).Render(m.zones.Mark(ZoneBuyItem, lo.Ternary(m.state == StateUpgrade, fmt.Sprintf("↑  Upgrade Card (%d$)", game.DefaultUpgradeCost), fmt.Sprintf("✕  Remove Card (%d$)", game.DefaultRemoveCost)))),
+			).Margin(1, 2).Render(m.zones.Mark(ZoneBuyItem, lo.Ternary(m.state == StateUpgrade, fmt.Sprintf("↑  Upgrade Card (%d$)", upgradeCost), fmt.Sprintf("✕  Remove Card (%d$)", removeCost)))),
 		)
 	}
 
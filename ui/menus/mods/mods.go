@@ -1,6 +1,7 @@
 package mods
 
 import (
+	"fmt"
 	"github.com/BigJk/end_of_eden/game"
 	"github.com/BigJk/end_of_eden/internal/fs"
 	"github.com/BigJk/end_of_eden/system/audio"
@@ -16,12 +17,15 @@ import (
 	"log"
 	"path/filepath"
 	"sort"
+	"strings"
 )
 
 type item struct {
 	active bool
 	mod    game.Mod
 	key    string
+	order  int
+	errors []string
 }
 
 func (i item) Title() string {
@@ -29,9 +33,19 @@ func (i item) Title() string {
 		return i.mod.Name + style.RedDarkerText.Render(" by ") + style.GrayText.Render(i.mod.Author)
 	}
 
-	return lipgloss.NewStyle().Italic(true).Foreground(style.BaseGreen).Render("Active") + " " + style.RedText.Render(i.mod.Name) + style.RedDarkerText.Render(" by ") + style.GrayText.Render(i.mod.Author)
+	status := lipgloss.NewStyle().Italic(true).Foreground(style.BaseGreen).Render(fmt.Sprintf("Active #%d", i.order+1))
+	if i.order < 0 {
+		status = lipgloss.NewStyle().Italic(true).Foreground(style.BaseRed).Render("Not Loaded")
+	}
+
+	return status + " " + style.RedText.Render(i.mod.Name) + style.RedDarkerText.Render(" by ") + style.GrayText.Render(i.mod.Author)
+}
+func (i item) Description() string {
+	if len(i.errors) > 0 {
+		return style.RedText.Render(strings.Join(i.errors, ", "))
+	}
+	return i.mod.Description
 }
-func (i item) Description() string { return i.mod.Description }
 func (i item) FilterValue() string { return i.mod.Name }
 
 type Model struct {
@@ -119,6 +133,10 @@ func (m Model) items() []list.Item {
 	baseKeys := lo.Keys(m.mods)
 	sort.Strings(baseKeys)
 
+	// Resolve the load order of the active mods to show the order they are loaded in and why
+	// mods can't be loaded.
+	order, errs := game.ResolveModOrder("./mods", m.settings.GetStrings("mods"))
+
 	keys := lo.Uniq(append(m.settings.GetStrings("mods"), baseKeys...))
 	items := lo.FilterMap(keys, func(modName string, _ int) (list.Item, bool) {
 		mod, ok := m.mods[modName]
@@ -129,6 +147,10 @@ func (m Model) items() []list.Item {
 			active: m.modActive(modName),
 			key:    modName,
 			mod:    mod,
+			order:  lo.IndexOf(order, modName),
+			errors: lo.FilterMap(errs, func(err game.ModError, _ int) (string, bool) {
+				return err.Message, err.Mod == modName
+			}),
 		}, true
 	})
 
@@ -182,6 +204,10 @@ func (m Model) modActive(mod string) bool {
 }
 
 func (m Model) fetchMods() Model {
+	if err := game.MountModArchives(); err != nil {
+		log.Println("Error while mounting mod archives:", err)
+	}
+
 	entries, err := fs.ReadDir("./mods")
 	if err != nil {
 		log.Println("Error while reading mods directory:", err)
@@ -75,6 +75,11 @@ type MenuModel struct {
 	Session *game.Session
 }
 
+// GetSession returns the session of the game, so the root model can lock it.
+func (m MenuModel) GetSession() *game.Session {
+	return m.Session
+}
+
 func New(parent tea.Model, zones *zone.Manager, session *game.Session) MenuModel {
 	choices := []list.Item{
 		choiceItem{zones, "Character", "Check your stats.", ChoiceCharacter},
@@ -0,0 +1,234 @@
+package saves
+
+import (
+	"fmt"
+	"github.com/BigJk/end_of_eden/game"
+	"github.com/BigJk/end_of_eden/system/audio"
+	"github.com/BigJk/end_of_eden/ui"
+	"github.com/BigJk/end_of_eden/ui/style"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	zone "github.com/lrstanley/bubblezone"
+	"github.com/samber/lo"
+	"strings"
+	"time"
+)
+
+type item struct {
+	slot game.SaveSlot
+}
+
+func (i item) Title() string {
+	if i.slot.Err != nil {
+		return i.slot.Header.Name
+	}
+	return i.slot.Header.Name + style.GrayText.Render(" "+i.slot.Header.Timestamp.Format(time.DateTime))
+}
+
+func (i item) Description() string {
+	if i.slot.Err != nil {
+		return style.RedText.Render(i.slot.Err.Error())
+	}
+
+	header := i.slot.Header
+	parts := []string{
+		fmt.Sprintf("HP %d/%d", header.PlayerHP, header.PlayerMaxHP),
+		fmt.Sprintf("Gold %d", header.PlayerGold),
+		fmt.Sprintf("Stages %d", header.StagesCleared),
+		header.PlayTime.Round(time.Second).String(),
+		header.GameVersion,
+	}
+	if len(header.LoadedMods) > 0 {
+		parts = append(parts, "Mods: "+strings.Join(header.LoadedMods, ", "))
+	}
+	return strings.Join(parts, " · ")
+}
+
+func (i item) FilterValue() string { return i.slot.Header.Name }
+
+// LoadFunc is called when a slot is selected. It should return the model that is shown next.
+type LoadFunc func(slot game.SaveSlot) (tea.Model, error)
+
+type Model struct {
+	ui.MenuBase
+
+	parent tea.Model
+	zones  *zone.Manager
+	slots  *game.SaveSlots
+	load   LoadFunc
+	list   list.Model
+	err    string
+
+	renameInput textinput.Model
+	renaming    bool
+	deleting    bool
+}
+
+func New(parent tea.Model, zones *zone.Manager, slots *game.SaveSlots, load LoadFunc) Model {
+	return Model{
+		parent:      parent,
+		zones:       zones,
+		slots:       slots,
+		load:        load,
+		renameInput: textinput.New(),
+	}.setup()
+}
+
+func (m Model) Init() tea.Cmd {
+	return nil
+}
+
+func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		selected, hasSelected := m.list.SelectedItem().(item)
+
+		if m.renaming {
+			switch msg.Type {
+			case tea.KeyEscape:
+				m.renaming = false
+				return m, nil
+			case tea.KeyEnter:
+				m.renaming = false
+				if err := m.slots.Rename(selected.slot.ID, m.renameInput.Value()); err != nil {
+					m.err = err.Error()
+				}
+				return m.refresh(), nil
+			}
+
+			var cmd tea.Cmd
+			m.renameInput, cmd = m.renameInput.Update(msg)
+			return m, cmd
+		}
+
+		if m.deleting {
+			m.deleting = false
+			if msg.String() == "y" {
+				audio.Play("btn_menu")
+				if err := m.slots.Delete(selected.slot.ID); err != nil {
+					m.err = err.Error()
+				}
+				return m.refresh(), nil
+			}
+			return m, nil
+		}
+
+		switch msg.String() {
+		case "r":
+			if hasSelected {
+				m.renaming = true
+				m.renameInput.CharLimit = 50
+				m.renameInput.Prompt = "> "
+				m.renameInput.Cursor.TextStyle = lipgloss.NewStyle().Foreground(style.BaseGrayDarker).Background(style.BaseWhite)
+				m.renameInput.Cursor.Blink = false
+				m.renameInput.SetValue(selected.slot.Header.Name)
+				m.renameInput.CursorEnd()
+				return m, m.renameInput.Focus()
+			}
+		case "x":
+			if hasSelected {
+				m.deleting = true
+				return m, nil
+			}
+		case "q":
+			fallthrough
+		case "esc":
+			return m.parent, nil
+		}
+
+		switch msg.Type {
+		case tea.KeyDown:
+			fallthrough
+		case tea.KeyUp:
+			audio.Play("interface_move", -1.5)
+		case tea.KeyEnter:
+			if hasSelected {
+				audio.Play("btn_menu")
+
+				next, err := m.load(selected.slot)
+				if err != nil {
+					m.err = err.Error()
+					return m, nil
+				}
+				return next, nil
+			}
+		}
+	case tea.WindowSizeMsg:
+		m.Size = msg
+		m.list.SetSize(msg.Width-4, msg.Height-4)
+	}
+
+	var cmd tea.Cmd
+	m.list, cmd = m.list.Update(msg)
+	return m, cmd
+}
+
+func (m Model) View() string {
+	if m.renaming || m.deleting {
+		var content string
+		if m.renaming {
+			content = lipgloss.JoinVertical(
+				lipgloss.Top,
+				"Enter new name:\n",
+				m.renameInput.View(),
+				style.GrayText.Render("\nPress 'esc' to cancel, 'enter' to save"),
+			)
+		} else {
+			content = lipgloss.JoinVertical(
+				lipgloss.Top,
+				fmt.Sprintf("Delete %s?\n", style.BoldStyle.Render(m.list.SelectedItem().(item).slot.Header.Name)),
+				style.GrayText.Render("Press 'y' to delete, any other key to cancel"),
+			)
+		}
+
+		return lipgloss.Place(m.Size.Width, m.Size.Height, lipgloss.Center, lipgloss.Center,
+			lipgloss.NewStyle().Padding(1, 3).Border(lipgloss.ThickBorder(), true).Render(content),
+			lipgloss.WithWhitespaceChars("?"),
+			lipgloss.WithWhitespaceForeground(style.BaseGrayDarker),
+		)
+	}
+
+	return lipgloss.NewStyle().Padding(1, 2).Render(lipgloss.JoinVertical(
+		lipgloss.Top,
+		m.list.View(),
+		lo.Ternary(m.err != "", style.RedText.Render("Error: "+m.err), ""),
+	))
+}
+
+func (m Model) refresh() Model {
+	slots, err := m.slots.List()
+	if err != nil {
+		m.err = err.Error()
+	}
+
+	m.list.SetItems(lo.Map(slots, func(slot game.SaveSlot, index int) list.Item {
+		return item{slot: slot}
+	}))
+	return m
+}
+
+func (m Model) setup() Model {
+	delegation := list.NewDefaultDelegate()
+	delegation.Styles.SelectedTitle = delegation.Styles.SelectedTitle.Foreground(style.BaseRed).BorderForeground(style.BaseRed)
+	delegation.Styles.SelectedDesc = delegation.Styles.SelectedDesc.Foreground(style.BaseRedDarker).BorderForeground(style.BaseRed)
+
+	m.list = list.New(nil, delegation, 0, 0)
+	m.list.Title = "Continue"
+	m.list.SetFilteringEnabled(false)
+	m.list.SetShowFilter(false)
+	m.list.SetShowStatusBar(false)
+	m.list.AdditionalShortHelpKeys = func() []key.Binding {
+		return []key.Binding{
+			key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "load")),
+			key.NewBinding(key.WithKeys("r"), key.WithHelp("r", "rename")),
+			key.NewBinding(key.WithKeys("x"), key.WithHelp("x", "delete")),
+		}
+	}
+	m.list.AdditionalFullHelpKeys = m.list.AdditionalShortHelpKeys
+	m.list.Styles.Title = lipgloss.NewStyle().Background(style.BaseRedDarker).Foreground(style.BaseWhite).Padding(0, 2, 0, 2)
+
+	return m.refresh()
+}
@@ -20,6 +20,13 @@ type Model struct {
 	tooltips map[string]Tooltip
 }
 
+// SessionModel is a model that works on a game session. The root model holds the lock of the session
+// while the model is updated or rendered, as the debug server accesses the session from other goroutines.
+type SessionModel interface {
+	tea.Model
+	GetSession() *game.Session
+}
+
 // New creates a new root model.
 func New(zones *zone.Manager, root tea.Model) Model {
 	return Model{
@@ -75,6 +82,12 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	curIndex := len(m.stack) - 1
 
+	if model, ok := m.stack[curIndex].(SessionModel); ok {
+		mtx := model.GetSession().Mutex()
+		mtx.Lock()
+		defer mtx.Unlock()
+	}
+
 	var cmd tea.Cmd
 	m.stack[curIndex], cmd = m.stack[curIndex].Update(msg)
 
@@ -108,6 +121,12 @@ func (m Model) View() string {
 		return "stack empty!"
 	}
 
+	if model, ok := m.stack[len(m.stack)-1].(SessionModel); ok {
+		mtx := model.GetSession().Mutex()
+		mtx.RLock()
+		defer mtx.RUnlock()
+	}
+
 	view := m.zones.Scan(m.stack[len(m.stack)-1].View())
 
 	for _, v := range m.tooltips {
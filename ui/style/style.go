@@ -21,6 +21,7 @@ var (
 	BaseGrayDarker = lipgloss.Color("#363636")
 	BaseYellow     = lipgloss.Color("#ffd966")
 	BaseGreen      = lipgloss.Color("#80ed99")
+	BaseBlue       = lipgloss.Color("#219ebc")
 
 	TableStyle = func() table.Styles {
 		s := table.DefaultStyles()
@@ -44,6 +45,7 @@ var (
 	BaseText       = lipgloss.NewStyle().Foreground(BaseWhite)
 	RedText        = lipgloss.NewStyle().Foreground(BaseRed)
 	GreenText      = lipgloss.NewStyle().Foreground(BaseGreen)
+	BlueText       = lipgloss.NewStyle().Foreground(BaseBlue)
 	GrayText       = lipgloss.NewStyle().Foreground(BaseGray)
 	GrayTextDarker = lipgloss.NewStyle().Foreground(BaseGrayDarker)
 	RedDarkerText  = lipgloss.NewStyle().Foreground(BaseRedDarker)